@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"banking-service/domains"
+)
+
+func createAccount(ctx context.Context, app *app, args []string) error {
+	var req domains.CreateAccountRequest
+	flags := flag.NewFlagSet("accounts create", flag.ContinueOnError)
+	flags.StringVar(&req.UserID, "user-id", "", "owner of the account (required)")
+	flags.StringVar(&req.Name, "name", "", "name of the account (required)")
+	flags.StringVar(&req.Currency, "currency", "", "ISO 4217 currency code")
+	if err := parse(flags, args, "user-id", "name"); err != nil {
+		return err
+	}
+
+	return app.audited(ctx, "bankctl accounts create", &req, func(ctx context.Context) (interface{}, error) {
+		return app.operations.CreateAccount(ctx, &req)
+	})
+}
+
+// adjustAccount posts an adjustment, or requests an approval for it above
+// the approval threshold like the API.
+func adjustAccount(ctx context.Context, app *app, args []string) error {
+	var (
+		accountID string
+		tags      stringList
+		req       domains.AdjustAccountRequest
+	)
+	flags := flag.NewFlagSet("accounts adjust", flag.ContinueOnError)
+	flags.StringVar(&accountID, "account-id", "", "account to adjust (required)")
+	flags.Float64Var(&req.Amount, "amount", 0, "signed amount, negative to debit (required)")
+	flags.StringVar(&req.ReasonCode, "reason-code", "", "reason code of the adjustment (required)")
+	flags.StringVar(&req.Description, "description", "", "description of the transaction")
+	flags.StringVar(&req.Reference, "reference", "", "reference of the transaction")
+	flags.StringVar(&req.EndToEndID, "end-to-end-id", "", "end-to-end ID of the transaction")
+	flags.Var(&tags, "tag", "tag of the transaction, repeatable")
+	if err := parse(flags, args, "account-id", "amount", "reason-code"); err != nil {
+		return err
+	}
+	req.Tags = tags
+
+	payload := struct {
+		AccountID string `json:"account_id"`
+		*domains.AdjustAccountRequest
+	}{accountID, &req}
+
+	return app.audited(ctx, "bankctl accounts adjust", &payload, func(ctx context.Context) (interface{}, error) {
+		return app.operations.AdjustAccount(ctx, accountID, &req)
+	})
+}
+
+func freezeAccount(ctx context.Context, app *app, args []string) error {
+	var accountID, reason string
+	flags := flag.NewFlagSet("accounts freeze", flag.ContinueOnError)
+	flags.StringVar(&accountID, "account-id", "", "account to freeze (required)")
+	flags.StringVar(&reason, "reason", "", "why the account is frozen (required)")
+	if err := parse(flags, args, "account-id", "reason"); err != nil {
+		return err
+	}
+
+	payload := map[string]string{"account_id": accountID, "reason": reason}
+
+	return app.audited(ctx, "bankctl accounts freeze", payload, func(ctx context.Context) (interface{}, error) {
+		return app.operations.FreezeAccount(ctx, accountID, reason)
+	})
+}
+
+func unfreezeAccount(ctx context.Context, app *app, args []string) error {
+	var accountID string
+	flags := flag.NewFlagSet("accounts unfreeze", flag.ContinueOnError)
+	flags.StringVar(&accountID, "account-id", "", "account to unfreeze (required)")
+	if err := parse(flags, args, "account-id"); err != nil {
+		return err
+	}
+
+	payload := map[string]string{"account_id": accountID}
+
+	return app.audited(ctx, "bankctl accounts unfreeze", payload, func(ctx context.Context) (interface{}, error) {
+		return app.operations.UnfreezeAccount(ctx, accountID)
+	})
+}
@@ -0,0 +1,314 @@
+// Command bankctl runs back office operations against the banking database:
+// creating users and accounts, adjustments, freezes, reconciliation, outbox
+// replay, transaction lookups and schema migrations. It reads the same
+// BANKING_* environment as the service and prints JSON.
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"os/user"
+	"strings"
+	"syscall"
+	"time"
+
+	"banking-service/configs"
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/handlers"
+	"banking-service/migrations"
+	"banking-service/models"
+	"banking-service/repositories"
+	"banking-service/utilities"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+const usage = `usage: bankctl <command> <subcommand> [flags]
+
+commands:
+  users create          create a user
+  accounts create       open an account
+  accounts adjust       post an adjustment with a reason code
+  accounts freeze       freeze an account
+  accounts unfreeze     unfreeze an account
+  transactions get      show a transaction
+  transactions list     list transactions
+  reconcile             compare account balances with their ledgers
+  outbox replay         mark published events for redelivery
+  migrate up            apply pending schema migrations
+  migrate down          revert the last schema migrations
+  migrate status        list schema migrations and when they were applied
+  migrate baseline      record hand-applied migrations as applied
+
+Run bankctl <command> <subcommand> -h for the flags of a command.
+`
+
+// auditMethod is stored as the method of the audit log entries of bankctl.
+const auditMethod = "CLI"
+
+// errUsage is returned for invalid command lines, after printing usage.
+var errUsage = errors.New("invalid usage")
+
+type command func(ctx context.Context, app *app, args []string) error
+
+var commands = map[string]map[string]command{
+	"users": {
+		"create": createUser,
+	},
+	"accounts": {
+		"create":   createAccount,
+		"adjust":   adjustAccount,
+		"freeze":   freezeAccount,
+		"unfreeze": unfreezeAccount,
+	},
+	"transactions": {
+		"get":  getTransaction,
+		"list": getTransactions,
+	},
+	"reconcile": {
+		"": reconcile,
+	},
+	"outbox": {
+		"replay": replayOutbox,
+	},
+	"migrate": {
+		"up":       migrateUp,
+		"down":     migrateDown,
+		"status":   migrateStatus,
+		"baseline": migrateBaseline,
+	},
+}
+
+type app struct {
+	db                    *gorm.DB
+	idGenerator           utilities.SnowflakeIDGenerator
+	operations            handlers.Operations
+	principal             *domains.Principal
+	migrator              *migrations.Migrator
+	accountRepository     repositories.AccountRepositoryI
+	transactionRepository repositories.TransactionRepositoryI
+	outboxEventRepository repositories.OutboxEventRepositoryI
+	auditLogRepository    repositories.AuditLogRepositoryI
+}
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	cmd, args, ok := lookup(os.Args[1:])
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	cfg, err := configs.Load(os.Args[0], nil)
+	if err == nil {
+		err = cfg.ValidateCLI()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%s\n", err.Error())
+		return 2
+	}
+
+	db, err := gorm.Open(postgres.Open(cfg.Database.DSN()), &gorm.Config{
+		Logger:         gormLogger.Default.LogMode(gormLogger.Silent),
+		TranslateError: true,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "connect database error: %s\n", err.Error())
+		return 1
+	}
+
+	idGenerator, err := utilities.NewSnowflakeIDGenerator()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "new snowflakeIDGenerator error: %s\n", err.Error())
+		return 1
+	}
+
+	migrator, err := migrations.NewMigrator(&migrations.MigratorDeps{
+		DB: db,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "new migrator error: %s\n", err.Error())
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	app := &app{
+		db:          db,
+		idGenerator: idGenerator,
+		operations: handlers.NewOperations(&handlers.OperationsDeps{
+			DB: db,
+			// results and errors are printed, so handler logs are dropped
+			Logger:            zap.NewNop(),
+			IDGenerator:       idGenerator,
+			ApprovalThreshold: cfg.Approval.Threshold,
+			ApprovalTTL:       cfg.Approval.TTL,
+		}),
+		principal: &domains.Principal{
+			Kind:  enums.CLIPrincipal,
+			ID:    "bankctl:" + operator(),
+			Name:  "bankctl",
+			Role:  enums.Admin,
+			Owner: operator(),
+		},
+		migrator:              migrator,
+		accountRepository:     repositories.NewAccountRepository(),
+		transactionRepository: repositories.NewTransactionRepository(),
+		outboxEventRepository: repositories.NewOutboxEventRepository(),
+		auditLogRepository:    repositories.NewAuditLogRepository(),
+	}
+
+	if err := cmd(domains.WithPrincipal(ctx, app.principal), app, args); err != nil {
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+// lookup returns the command named by the leading arguments and the
+// arguments left for its flags.
+func lookup(args []string) (command, []string, bool) {
+	if len(args) == 0 {
+		return nil, nil, false
+	}
+	subcommands, ok := commands[args[0]]
+	if !ok {
+		return nil, nil, false
+	}
+	if cmd, ok := subcommands[""]; ok {
+		return cmd, args[1:], true
+	}
+	if len(args) < 2 {
+		return nil, nil, false
+	}
+	cmd, ok := subcommands[args[1]]
+
+	return cmd, args[2:], ok
+}
+
+// operator names the person running bankctl in the audit log.
+func operator() string {
+	if name := os.Getenv("BANKCTL_OPERATOR"); name != "" {
+		return name
+	}
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+
+	return "unknown"
+}
+
+// parse parses the flags of a command and checks the required ones are set.
+func parse(flags *flag.FlagSet, args []string, required ...string) error {
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	for _, name := range required {
+		if !set[name] {
+			fmt.Fprintf(os.Stderr, "missing required flag -%s\n", name)
+			flags.Usage()
+			return errUsage
+		}
+	}
+
+	return nil
+}
+
+// audited runs a mutating command and records it in the audit log like the
+// API does, with the command as the route and its arguments as the payload.
+func (a *app) audited(ctx context.Context, route string, payload interface{}, fn func(ctx context.Context) (interface{}, error)) error {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	payloadHash := sha256.Sum256(payloadBytes)
+
+	trail := &domains.AuditTrail{}
+	resp, err := fn(domains.WithAuditTrail(ctx, trail))
+
+	status := http.StatusOK
+	changes := []*domains.AccountChange{}
+	if err != nil {
+		status = http.StatusInternalServerError
+		var xErr domains.XError
+		if errors.As(err, &xErr) {
+			status = xErr.HTTPStatus()
+		}
+	} else {
+		changes = trail.Changes()
+	}
+	changesBytes, marshalErr := json.Marshal(changes)
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	// the audit entry is written even when the command was interrupted
+	auditCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if auditErr := a.auditLogRepository.Create(auditCtx, a.db, &models.AuditLog{
+		AuditID:        a.idGenerator.Next().String(),
+		RequestID:      a.idGenerator.Next().String(),
+		ActorKind:      a.principal.Kind.String(),
+		ActorID:        a.principal.ID,
+		ActorRole:      a.principal.Role.String(),
+		Method:         auditMethod,
+		Route:          route,
+		Path:           strings.Join(os.Args, " "),
+		StatusCode:     status,
+		PayloadHash:    hex.EncodeToString(payloadHash[:]),
+		AccountChanges: string(changesBytes),
+		CreatedAt:      time.Now(),
+	}); auditErr != nil {
+		fmt.Fprintf(os.Stderr, "create audit log error: %s\n", auditErr.Error())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return printJSON(resp)
+}
+
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
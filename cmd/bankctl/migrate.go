@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+
+	"banking-service/migrations"
+)
+
+type migrateResp struct {
+	Migrations []*migrations.MigrationStatus `json:"migrations"`
+}
+
+func migrateUp(ctx context.Context, app *app, args []string) error {
+	if err := parse(flag.NewFlagSet("migrate up", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+
+	applied, err := app.migrator.Up(ctx)
+	// report what was applied before a failure too
+	if printErr := printJSON(toMigrateResp(applied)); printErr != nil {
+		return printErr
+	}
+
+	return err
+}
+
+func migrateDown(ctx context.Context, app *app, args []string) error {
+	var steps int
+	flags := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+	flags.IntVar(&steps, "steps", 1, "number of migrations to revert")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if steps <= 0 {
+		return errors.New("steps must be positive")
+	}
+
+	reverted, err := app.migrator.Down(ctx, steps)
+	if printErr := printJSON(toMigrateResp(reverted)); printErr != nil {
+		return printErr
+	}
+
+	return err
+}
+
+func migrateStatus(ctx context.Context, app *app, args []string) error {
+	if err := parse(flag.NewFlagSet("migrate status", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+
+	statuses, err := app.migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(&migrateResp{Migrations: statuses})
+}
+
+// migrateBaseline adopts a database whose migrations were applied by hand.
+func migrateBaseline(ctx context.Context, app *app, args []string) error {
+	var version int64
+	flags := flag.NewFlagSet("migrate baseline", flag.ContinueOnError)
+	flags.Int64Var(&version, "version", 0, "last migration already applied (required)")
+	if err := parse(flags, args, "version"); err != nil {
+		return err
+	}
+
+	recorded, err := app.migrator.Baseline(ctx, version)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(toMigrateResp(recorded))
+}
+
+func toMigrateResp(list []*migrations.Migration) *migrateResp {
+	resp := &migrateResp{
+		Migrations: make([]*migrations.MigrationStatus, 0, len(list)),
+	}
+	for _, migration := range list {
+		resp.Migrations = append(resp.Migrations, &migrations.MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+		})
+	}
+
+	return resp
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"math"
+
+	"banking-service/models"
+	"banking-service/repositories"
+
+	"gorm.io/gorm"
+)
+
+// reconcileTolerance absorbs the float rounding of summing many amounts.
+const reconcileTolerance = 0.005
+
+type (
+	reconcileResp struct {
+		AccountsChecked int                  `json:"accounts_checked"`
+		Mismatches      []*reconcileMismatch `json:"mismatches"`
+	}
+
+	reconcileMismatch struct {
+		AccountID        string  `json:"account_id"`
+		Balance          float64 `json:"balance"`
+		LedgerTotal      float64 `json:"ledger_total"`
+		LastBalance      float64 `json:"last_balance"`
+		TransactionCount int64   `json:"transaction_count"`
+	}
+)
+
+// reconcile checks that the balance of every account, closed ones included,
+// equals the sum of its transactions and the balance recorded by its latest
+// transaction. It reads a single snapshot so concurrent money movements do
+// not show up as mismatches, and fails when any account does not reconcile.
+func reconcile(ctx context.Context, app *app, args []string) error {
+	var (
+		accountIDs stringList
+		batchSize  int
+	)
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	flags.Var(&accountIDs, "account-id", "only this account, repeatable")
+	flags.IntVar(&batchSize, "batch-size", 500, "accounts read per query")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if batchSize <= 0 {
+		return fmt.Errorf("batch-size must be positive")
+	}
+
+	resp := &reconcileResp{
+		Mismatches: []*reconcileMismatch{},
+	}
+	err := app.db.Transaction(func(tx *gorm.DB) error {
+		cursor := ""
+		for {
+			accounts, err := app.accountRepository.GetAccounts(ctx, tx, &repositories.GetAccountsArgs{
+				AccountIDs:  accountIDs,
+				Cursor:      cursor,
+				WithDeleted: true,
+				Limit:       batchSize,
+			})
+			if err != nil {
+				return err
+			}
+			if len(accounts) == 0 {
+				return nil
+			}
+
+			if err := app.reconcileAccounts(ctx, tx, accounts, resp); err != nil {
+				return err
+			}
+			cursor = accounts[len(accounts)-1].AccountID
+		}
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+
+	if err := printJSON(resp); err != nil {
+		return err
+	}
+	if len(resp.Mismatches) != 0 {
+		return fmt.Errorf("%d of %d accounts do not reconcile", len(resp.Mismatches), resp.AccountsChecked)
+	}
+
+	return nil
+}
+
+func (a *app) reconcileAccounts(ctx context.Context, tx *gorm.DB, accounts models.Accounts, resp *reconcileResp) error {
+	accountIDs := make([]string, 0, len(accounts))
+	for _, account := range accounts {
+		accountIDs = append(accountIDs, account.AccountID)
+	}
+
+	totals, err := a.transactionRepository.GetLedgerTotals(ctx, tx, &repositories.GetLedgerTotalsArgs{
+		AccountIDs: accountIDs,
+	})
+	if err != nil {
+		return err
+	}
+	totalsByAccount := make(map[string]*models.LedgerTotal, len(totals))
+	for _, total := range totals {
+		totalsByAccount[total.AccountID] = total
+	}
+
+	for _, account := range accounts {
+		resp.AccountsChecked++
+
+		total, ok := totalsByAccount[account.AccountID]
+		if !ok {
+			total = &models.LedgerTotal{AccountID: account.AccountID}
+		}
+		if math.Abs(account.Balance-total.Total) <= reconcileTolerance &&
+			math.Abs(account.Balance-total.LastBalance) <= reconcileTolerance {
+			continue
+		}
+
+		resp.Mismatches = append(resp.Mismatches, &reconcileMismatch{
+			AccountID:        account.AccountID,
+			Balance:          account.Balance,
+			LedgerTotal:      total.Total,
+			LastBalance:      total.LastBalance,
+			TransactionCount: total.TransactionCount,
+		})
+	}
+
+	return nil
+}
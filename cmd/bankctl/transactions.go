@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"banking-service/handlers"
+	"banking-service/repositories"
+
+	"gorm.io/gorm"
+)
+
+func getTransaction(ctx context.Context, app *app, args []string) error {
+	var transactionID string
+	flags := flag.NewFlagSet("transactions get", flag.ContinueOnError)
+	flags.StringVar(&transactionID, "transaction-id", "", "transaction to show (required)")
+	if err := parse(flags, args, "transaction-id"); err != nil {
+		return err
+	}
+
+	transaction, err := app.transactionRepository.GetTransaction(ctx, app.db, &repositories.GetTransactionArgs{
+		TransactionID: transactionID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("transaction_id %s not found", transactionID)
+		}
+		return err
+	}
+
+	return printJSON(handlers.TransactionResponse(transaction))
+}
+
+func getTransactions(ctx context.Context, app *app, args []string) error {
+	var (
+		tags    stringList
+		getArgs repositories.GetTransactionsArgs
+		flags   = flag.NewFlagSet("transactions list", flag.ContinueOnError)
+	)
+	flags.StringVar(&getArgs.AccountID, "account-id", "", "only transactions of this account")
+	flags.StringVar(&getArgs.Reference, "reference", "", "only transactions with this reference")
+	flags.StringVar(&getArgs.EndToEndID, "end-to-end-id", "", "only transactions with this end-to-end ID")
+	flags.StringVar(&getArgs.Description, "q", "", "only transactions whose description contains this text")
+	flags.Var(&tags, "tag", "only transactions with this tag, repeatable")
+	flags.StringVar(&getArgs.Cursor, "cursor", "", "next_cursor of the previous page")
+	flags.IntVar(&getArgs.Limit, "limit", 100, "page size")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if getArgs.Limit <= 0 {
+		return errors.New("limit must be positive")
+	}
+	getArgs.Tags = tags
+
+	transactions, err := app.transactionRepository.GetTransactions(ctx, app.db, &getArgs)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(handlers.TransactionsResponse(transactions))
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"banking-service/domains"
+)
+
+func createUser(ctx context.Context, app *app, args []string) error {
+	var req domains.CreateUserRequest
+	flags := flag.NewFlagSet("users create", flag.ContinueOnError)
+	flags.StringVar(&req.Name, "name", "", "name of the user (required)")
+	flags.StringVar(&req.Email, "email", "", "email address")
+	flags.StringVar(&req.Phone, "phone", "", "phone number")
+	flags.StringVar(&req.DateOfBirth, "date-of-birth", "", "date of birth, YYYY-MM-DD")
+	flags.StringVar(&req.PreferredLanguage, "preferred-language", "", "preferred language tag")
+	if err := parse(flags, args, "name"); err != nil {
+		return err
+	}
+
+	return app.audited(ctx, "bankctl users create", &req, func(ctx context.Context) (interface{}, error) {
+		return app.operations.CreateUser(ctx, &req)
+	})
+}
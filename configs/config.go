@@ -1,6 +1,9 @@
 package configs
 
-import "os"
+import (
+	"fmt"
+	"time"
+)
 
 type Database struct {
 	Host     string
@@ -8,30 +11,230 @@ type Database struct {
 	Password string
 	Name     string
 	Port     string
+	// MigrateOnStartup applies pending migrations before serving.
+	MigrateOnStartup bool
+	// LogLevel is silent, error, warn or info; warn logs failed and slow
+	// queries, info every query.
+	LogLevel           string
+	SlowQueryThreshold time.Duration
+	// MaxOpenConns bounds the connection pool; MaxIdleConns of them are kept
+	// open when idle.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// ReplicaDSN is the connection string of a read replica serving the list
+	// endpoints; they read from the primary when it is empty.
+	ReplicaDSN string
+	// ReplicaMaxLag is the replay lag above which reads go back to the
+	// primary; the lag is checked every ReplicaCheckInterval.
+	ReplicaMaxLag        time.Duration
+	ReplicaCheckInterval time.Duration
+}
+
+// DSN is the PostgreSQL connection string of the database.
+func (d Database) DSN() string {
+	return fmt.Sprintf(
+		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
+		d.Host,
+		d.Username,
+		d.Password,
+		d.Name,
+		d.Port,
+	)
 }
 
 type BankingService struct {
+	Port        string
+	GRPCPort    string
+	GRPCEnabled bool
+	// ReadHeaderTimeout and IdleTimeout bound slow and idle clients. There is
+	// no write timeout because event streams stay open.
+	ReadHeaderTimeout time.Duration
+	IdleTimeout       time.Duration
+	// MaxPageSize is the largest limit accepted by list endpoints; pages hold
+	// 100 items when no limit is given.
+	MaxPageSize int
+}
+
+type Auth struct {
+	JWTSecret string
+	JWTIssuer string
+	// BootstrapAPIKey is accepted as an API key so the first real keys can
+	// be issued.
+	BootstrapAPIKey string
+}
+
+type Approval struct {
+	// Threshold is the amount above which transfers and adjustments need a
+	// second person to approve them.
+	Threshold float64
+	// TTL is how long a pending approval waits before it expires.
+	TTL            time.Duration
+	ExpiryInterval time.Duration
+}
+
+type Outbox struct {
+	RelayInterval  time.Duration
+	RelayBatchSize int
+	// MaxAttempts is the number of failed deliveries before an event is
+	// dead-lettered; PublishTimeout bounds each sink call.
+	MaxAttempts    int
+	PublishTimeout time.Duration
+}
+
+type Webhook struct {
+	Workers      int
+	PollInterval time.Duration
+	Timeout      time.Duration
+	// MaxAttempts is the number of attempts before a delivery is
+	// dead-lettered.
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// AllowInsecureURLs accepts http URLs and hosts on internal networks.
+	// Customers register webhooks, so it is only meant for development.
+	AllowInsecureURLs bool
+}
+
+type Notification struct {
+	MaxConnectionsPerUser int
+	MaxConnectionAge      time.Duration
+	PingInterval          time.Duration
+	PongWait              time.Duration
+	WriteWait             time.Duration
+	SendBuffer            int
+	// EventPollInterval and EventGapTimeout tune how the outbox is tailed
+	// for push notifications.
+	EventPollInterval time.Duration
+	EventGapTimeout   time.Duration
+	// SSEHeartbeatInterval is how often idle event streams get a comment
+	// line, which also re-checks for missed transactions.
+	SSEHeartbeatInterval time.Duration
+}
+
+type ChangeFeed struct {
+	SequenceInterval  time.Duration
+	SequenceBatchSize int
+}
+
+type Metrics struct {
+	// Port serves /metrics apart from the API so it is not exposed with it.
 	Port string
 }
 
+type Tracing struct {
+	// Exporter is none, otlp to send spans to a collector at OTLPEndpoint,
+	// or stdout to print them.
+	Exporter     string
+	OTLPEndpoint string
+	OTLPInsecure bool
+	// SampleRatio is the share of requests traced when the caller did not
+	// decide already.
+	SampleRatio float64
+}
+
+type Health struct {
+	// ReadinessTimeout bounds the dependency checks of a readiness request.
+	ReadinessTimeout time.Duration
+	// DrainDelay is how long readiness fails before the servers stop
+	// accepting requests, so load balancers stop routing to the instance.
+	DrainDelay time.Duration
+	// ShutdownTimeout bounds waiting for in-flight requests on shutdown.
+	ShutdownTimeout time.Duration
+}
+
+type OpenAPI struct {
+	// ValidateResponses checks every response against the specification and
+	// logs mismatches. It buffers response bodies, so it is meant for tests.
+	ValidateResponses bool
+}
+
 type Config struct {
 	Database       Database
 	BankingService BankingService
+	Auth           Auth
+	Approval       Approval
+	Outbox         Outbox
+	Webhook        Webhook
+	Notification   Notification
+	ChangeFeed     ChangeFeed
+	OpenAPI        OpenAPI
+	Health         Health
+	Metrics        Metrics
+	Tracing        Tracing
 }
 
-var Cfg Config
-
-func LoadConfig() {
-	Cfg = Config{
+// Default returns the configuration used for every setting that is not
+// set by a file, the environment or a flag.
+func Default() *Config {
+	return &Config{
 		Database: Database{
-			Host:     os.Getenv("BANKING_DB_HOST"),
-			Username: os.Getenv("BANKING_DB_USERNAME"),
-			Password: os.Getenv("BANKING_DB_PASSWORD"),
-			Name:     os.Getenv("BANKING_DB_NAME"),
-			Port:     os.Getenv("BANKING_DB_PORT"),
+			Port:                 "5432",
+			LogLevel:             "warn",
+			SlowQueryThreshold:   200 * time.Millisecond,
+			MaxOpenConns:         25,
+			MaxIdleConns:         10,
+			ConnMaxLifetime:      30 * time.Minute,
+			ConnMaxIdleTime:      5 * time.Minute,
+			ReplicaMaxLag:        5 * time.Second,
+			ReplicaCheckInterval: time.Second,
 		},
 		BankingService: BankingService{
-			Port: os.Getenv("BANKING_SERVICE_PORT"),
+			Port:              "8081",
+			GRPCPort:          "9090",
+			GRPCEnabled:       true,
+			ReadHeaderTimeout: 10 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			MaxPageSize:       500,
+		},
+		Approval: Approval{
+			Threshold:      10000,
+			TTL:            24 * time.Hour,
+			ExpiryInterval: time.Minute,
+		},
+		Outbox: Outbox{
+			RelayInterval:  time.Second,
+			RelayBatchSize: 100,
+			MaxAttempts:    10,
+			PublishTimeout: 10 * time.Second,
+		},
+		Webhook: Webhook{
+			Workers:      4,
+			PollInterval: time.Second,
+			Timeout:      10 * time.Second,
+			MaxAttempts:  8,
+			BaseBackoff:  10 * time.Second,
+			MaxBackoff:   time.Hour,
+		},
+		Notification: Notification{
+			MaxConnectionsPerUser: 5,
+			MaxConnectionAge:      time.Hour,
+			PingInterval:          30 * time.Second,
+			PongWait:              time.Minute,
+			WriteWait:             10 * time.Second,
+			SendBuffer:            64,
+			EventPollInterval:     500 * time.Millisecond,
+			EventGapTimeout:       5 * time.Second,
+			SSEHeartbeatInterval:  15 * time.Second,
+		},
+		ChangeFeed: ChangeFeed{
+			SequenceInterval:  500 * time.Millisecond,
+			SequenceBatchSize: 1000,
+		},
+		Metrics: Metrics{
+			Port: "2112",
+		},
+		Tracing: Tracing{
+			Exporter:     "none",
+			OTLPEndpoint: "localhost:4317",
+			OTLPInsecure: true,
+			SampleRatio:  1,
+		},
+		Health: Health{
+			ReadinessTimeout: 2 * time.Second,
+			DrainDelay:       5 * time.Second,
+			ShutdownTimeout:  10 * time.Second,
 		},
 	}
 }
@@ -0,0 +1,369 @@
+package configs
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvConfigFile names the configuration file when the -config flag is not
+// given.
+const EnvConfigFile = "BANKING_CONFIG_FILE"
+
+// fileSuffix marks the settings reading a secret from a file, such as
+// BANKING_DB_PASSWORD_FILE or database.password_file.
+const fileSuffix = "_file"
+
+// setting binds a field of Config to its key, used in configuration files
+// and as flag name, and to its environment variable.
+type setting struct {
+	key   string
+	env   string
+	usage string
+	value flag.Value
+	// alias is another flag name, kept for compatibility.
+	alias string
+	// secret settings can also be read from a file, so the secret is not in
+	// the environment of the process.
+	secret bool
+}
+
+// settings lists every setting of c.
+func settings(c *Config) []*setting {
+	list := []*setting{
+		{key: "database.host", env: "BANKING_DB_HOST", value: &stringValue{&c.Database.Host}, usage: "database host"},
+		{key: "database.port", env: "BANKING_DB_PORT", value: &stringValue{&c.Database.Port}, usage: "database port"},
+		{key: "database.username", env: "BANKING_DB_USERNAME", value: &stringValue{&c.Database.Username}, usage: "database user"},
+		{key: "database.password", env: "BANKING_DB_PASSWORD", value: &stringValue{&c.Database.Password}, usage: "database password", secret: true},
+		{key: "database.name", env: "BANKING_DB_NAME", value: &stringValue{&c.Database.Name}, usage: "database name"},
+		{key: "database.migrate_on_startup", env: "BANKING_MIGRATE_ON_STARTUP", value: &boolValue{&c.Database.MigrateOnStartup}, usage: "apply pending schema migrations before serving", alias: "migrate"},
+		{key: "database.log_level", env: "BANKING_DB_LOG_LEVEL", value: &stringValue{&c.Database.LogLevel}, usage: "SQL log level: silent, error, warn or info"},
+		{key: "database.slow_query_threshold", env: "BANKING_DB_SLOW_QUERY_THRESHOLD", value: &durationValue{&c.Database.SlowQueryThreshold}, usage: "duration above which queries are logged as slow"},
+		{key: "database.max_open_conns", env: "BANKING_DB_MAX_OPEN_CONNS", value: &intValue{&c.Database.MaxOpenConns}, usage: "maximum open database connections"},
+		{key: "database.max_idle_conns", env: "BANKING_DB_MAX_IDLE_CONNS", value: &intValue{&c.Database.MaxIdleConns}, usage: "database connections kept open when idle"},
+		{key: "database.conn_max_lifetime", env: "BANKING_DB_CONN_MAX_LIFETIME", value: &durationValue{&c.Database.ConnMaxLifetime}, usage: "duration after which database connections are replaced"},
+		{key: "database.conn_max_idle_time", env: "BANKING_DB_CONN_MAX_IDLE_TIME", value: &durationValue{&c.Database.ConnMaxIdleTime}, usage: "duration after which idle database connections are closed"},
+		{key: "database.replica_dsn", env: "BANKING_DB_REPLICA_DSN", value: &stringValue{&c.Database.ReplicaDSN}, usage: "connection string of a read replica for list endpoints", secret: true},
+		{key: "database.replica_max_lag", env: "BANKING_DB_REPLICA_MAX_LAG", value: &durationValue{&c.Database.ReplicaMaxLag}, usage: "replica lag above which reads use the primary"},
+		{key: "database.replica_check_interval", env: "BANKING_DB_REPLICA_CHECK_INTERVAL", value: &durationValue{&c.Database.ReplicaCheckInterval}, usage: "interval between replica lag checks"},
+
+		{key: "service.port", env: "BANKING_SERVICE_PORT", value: &stringValue{&c.BankingService.Port}, usage: "REST API port"},
+		{key: "service.grpc_port", env: "BANKING_GRPC_PORT", value: &stringValue{&c.BankingService.GRPCPort}, usage: "gRPC API port"},
+		{key: "service.grpc_enabled", env: "BANKING_GRPC_ENABLED", value: &boolValue{&c.BankingService.GRPCEnabled}, usage: "serve the gRPC API"},
+		{key: "service.read_header_timeout", env: "BANKING_READ_HEADER_TIMEOUT", value: &durationValue{&c.BankingService.ReadHeaderTimeout}, usage: "time allowed to read request headers"},
+		{key: "service.idle_timeout", env: "BANKING_IDLE_TIMEOUT", value: &durationValue{&c.BankingService.IdleTimeout}, usage: "time idle keep-alive connections stay open"},
+		{key: "service.max_page_size", env: "BANKING_MAX_PAGE_SIZE", value: &intValue{&c.BankingService.MaxPageSize}, usage: "largest limit accepted by list endpoints"},
+
+		{key: "auth.jwt_secret", env: "BANKING_JWT_SECRET", value: &stringValue{&c.Auth.JWTSecret}, usage: "secret signing access tokens, at least 32 bytes", secret: true},
+		{key: "auth.jwt_issuer", env: "BANKING_JWT_ISSUER", value: &stringValue{&c.Auth.JWTIssuer}, usage: "issuer of access tokens"},
+		{key: "auth.bootstrap_api_key", env: "BANKING_BOOTSTRAP_API_KEY", value: &stringValue{&c.Auth.BootstrapAPIKey}, usage: "API key accepted to issue the first keys", secret: true},
+
+		{key: "approval.threshold", env: "BANKING_APPROVAL_THRESHOLD", value: &floatValue{&c.Approval.Threshold}, usage: "amount above which money movements need approval"},
+		{key: "approval.ttl", env: "BANKING_APPROVAL_TTL", value: &durationValue{&c.Approval.TTL}, usage: "time before pending approvals expire"},
+		{key: "approval.expiry_interval", env: "BANKING_APPROVAL_EXPIRY_INTERVAL", value: &durationValue{&c.Approval.ExpiryInterval}, usage: "interval of the approval expirer"},
+
+		{key: "outbox.relay_interval", env: "BANKING_OUTBOX_RELAY_INTERVAL", value: &durationValue{&c.Outbox.RelayInterval}, usage: "interval of the outbox relay"},
+		{key: "outbox.relay_batch_size", env: "BANKING_OUTBOX_RELAY_BATCH_SIZE", value: &intValue{&c.Outbox.RelayBatchSize}, usage: "events relayed per batch"},
+		{key: "outbox.max_attempts", env: "BANKING_OUTBOX_MAX_ATTEMPTS", value: &intValue{&c.Outbox.MaxAttempts}, usage: "failed deliveries before an outbox event is dead-lettered"},
+		{key: "outbox.publish_timeout", env: "BANKING_OUTBOX_PUBLISH_TIMEOUT", value: &durationValue{&c.Outbox.PublishTimeout}, usage: "timeout of each sink call"},
+
+		{key: "webhook.workers", env: "BANKING_WEBHOOK_WORKERS", value: &intValue{&c.Webhook.Workers}, usage: "concurrent webhook deliveries"},
+		{key: "webhook.poll_interval", env: "BANKING_WEBHOOK_POLL_INTERVAL", value: &durationValue{&c.Webhook.PollInterval}, usage: "interval of the webhook dispatcher"},
+		{key: "webhook.timeout", env: "BANKING_WEBHOOK_TIMEOUT", value: &durationValue{&c.Webhook.Timeout}, usage: "timeout of a webhook delivery"},
+		{key: "webhook.max_attempts", env: "BANKING_WEBHOOK_MAX_ATTEMPTS", value: &intValue{&c.Webhook.MaxAttempts}, usage: "attempts before a delivery is dead-lettered"},
+		{key: "webhook.base_backoff", env: "BANKING_WEBHOOK_BASE_BACKOFF", value: &durationValue{&c.Webhook.BaseBackoff}, usage: "delay before the first retry"},
+		{key: "webhook.max_backoff", env: "BANKING_WEBHOOK_MAX_BACKOFF", value: &durationValue{&c.Webhook.MaxBackoff}, usage: "longest delay between retries"},
+		{key: "webhook.allow_insecure_urls", env: "BANKING_WEBHOOK_ALLOW_INSECURE_URLS", value: &boolValue{&c.Webhook.AllowInsecureURLs}, usage: "accept http webhook URLs and internal hosts, for development"},
+
+		{key: "notification.max_connections_per_user", env: "BANKING_WS_MAX_CONNECTIONS_PER_USER", value: &intValue{&c.Notification.MaxConnectionsPerUser}, usage: "WebSocket connections per user"},
+		{key: "notification.max_connection_age", env: "BANKING_WS_MAX_CONNECTION_AGE", value: &durationValue{&c.Notification.MaxConnectionAge}, usage: "age at which WebSocket connections are closed"},
+		{key: "notification.ping_interval", env: "BANKING_WS_PING_INTERVAL", value: &durationValue{&c.Notification.PingInterval}, usage: "interval of WebSocket pings"},
+		{key: "notification.pong_wait", env: "BANKING_WS_PONG_WAIT", value: &durationValue{&c.Notification.PongWait}, usage: "time to wait for a WebSocket pong"},
+		{key: "notification.write_wait", env: "BANKING_WS_WRITE_WAIT", value: &durationValue{&c.Notification.WriteWait}, usage: "timeout of a WebSocket write"},
+		{key: "notification.send_buffer", env: "BANKING_WS_SEND_BUFFER", value: &intValue{&c.Notification.SendBuffer}, usage: "messages buffered per WebSocket connection"},
+		{key: "notification.event_poll_interval", env: "BANKING_EVENT_POLL_INTERVAL", value: &durationValue{&c.Notification.EventPollInterval}, usage: "interval at which the outbox is tailed"},
+		{key: "notification.event_gap_timeout", env: "BANKING_EVENT_GAP_TIMEOUT", value: &durationValue{&c.Notification.EventGapTimeout}, usage: "time to wait for a missing outbox sequence"},
+		{key: "notification.sse_heartbeat_interval", env: "BANKING_SSE_HEARTBEAT_INTERVAL", value: &durationValue{&c.Notification.SSEHeartbeatInterval}, usage: "interval of event stream heartbeats"},
+
+		{key: "change_feed.sequence_interval", env: "BANKING_CHANGES_SEQUENCE_INTERVAL", value: &durationValue{&c.ChangeFeed.SequenceInterval}, usage: "interval of the change sequencer"},
+		{key: "change_feed.sequence_batch_size", env: "BANKING_CHANGES_SEQUENCE_BATCH_SIZE", value: &intValue{&c.ChangeFeed.SequenceBatchSize}, usage: "changes sequenced per batch"},
+
+		{key: "openapi.validate_responses", env: "BANKING_OPENAPI_VALIDATE_RESPONSES", value: &boolValue{&c.OpenAPI.ValidateResponses}, usage: "log responses not matching the specification"},
+
+		{key: "metrics.port", env: "BANKING_METRICS_PORT", value: &stringValue{&c.Metrics.Port}, usage: "port serving /metrics"},
+
+		{key: "tracing.exporter", env: "BANKING_TRACING_EXPORTER", value: &stringValue{&c.Tracing.Exporter}, usage: "span exporter: none, otlp or stdout"},
+		{key: "tracing.otlp_endpoint", env: "BANKING_TRACING_OTLP_ENDPOINT", value: &stringValue{&c.Tracing.OTLPEndpoint}, usage: "host:port of the OTLP gRPC collector"},
+		{key: "tracing.otlp_insecure", env: "BANKING_TRACING_OTLP_INSECURE", value: &boolValue{&c.Tracing.OTLPInsecure}, usage: "send spans without TLS"},
+		{key: "tracing.sample_ratio", env: "BANKING_TRACING_SAMPLE_RATIO", value: &floatValue{&c.Tracing.SampleRatio}, usage: "share of new traces recorded"},
+
+		{key: "health.readiness_timeout", env: "BANKING_READINESS_TIMEOUT", value: &durationValue{&c.Health.ReadinessTimeout}, usage: "timeout of the readiness checks"},
+		{key: "health.drain_delay", env: "BANKING_SHUTDOWN_DRAIN_DELAY", value: &durationValue{&c.Health.DrainDelay}, usage: "time readiness fails before shutting down"},
+		{key: "health.shutdown_timeout", env: "BANKING_SHUTDOWN_TIMEOUT", value: &durationValue{&c.Health.ShutdownTimeout}, usage: "time in-flight requests get on shutdown"},
+	}
+
+	for _, s := range list {
+		if s.secret {
+			list = append(list, &setting{
+				key:   s.key + fileSuffix,
+				env:   s.env + strings.ToUpper(fileSuffix),
+				value: &fileValue{s.value},
+				usage: "file holding the " + s.usage,
+			})
+		}
+	}
+
+	return list
+}
+
+// Load returns the default configuration overridden by the YAML file named
+// by -config or BANKING_CONFIG_FILE, then by the environment, then by the
+// flags in args. It does not validate the result.
+func Load(name string, args []string) (*Config, error) {
+	c := Default()
+	list := settings(c)
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv(EnvConfigFile), "YAML configuration file ("+EnvConfigFile+")")
+	flagValues := map[string]string{}
+	for _, s := range list {
+		recorder := &flagRecorder{setting: s, values: flagValues}
+		fs.Var(recorder, s.key, fmt.Sprintf("%s (%s)", s.usage, s.env))
+		if s.alias != "" {
+			fs.Var(recorder, s.alias, "alias of -"+s.key)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	if *configFile != "" {
+		fileValues, err := readFile(*configFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := apply(list, fileValues, func(s *setting) string {
+			return fmt.Sprintf("%s in %s", s.key, *configFile)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	envValues := map[string]string{}
+	for _, s := range list {
+		if v := os.Getenv(s.env); v != "" {
+			envValues[s.key] = v
+		}
+	}
+	if err := apply(list, envValues, func(s *setting) string {
+		return s.env
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := apply(list, flagValues, func(s *setting) string {
+		return "-" + s.key
+	}); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// apply sets the settings of one source from values by key. Unknown keys
+// and secrets given both inline and as a file are errors.
+func apply(list []*setting, values map[string]string, name func(*setting) string) error {
+	var errs []error
+	known := make(map[string]bool, len(list))
+	for _, s := range list {
+		known[s.key] = true
+		raw, ok := values[s.key]
+		if !ok {
+			continue
+		}
+		if _, both := values[s.key+fileSuffix]; s.secret && both {
+			errs = append(errs, fmt.Errorf("%s: set either the secret or its file", name(s)))
+			continue
+		}
+		if err := s.value.Set(raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name(s), err))
+		}
+	}
+
+	var unknown []string
+	for key := range values {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		errs = append(errs, fmt.Errorf("unknown setting %s", key))
+	}
+
+	return errors.Join(errs...)
+}
+
+// readFile flattens a YAML file into values by dotted key.
+func readFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	if err := flatten("", doc, values); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	return values, nil
+}
+
+func flatten(prefix string, doc map[string]interface{}, values map[string]string) error {
+	for key, v := range doc {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := v.(type) {
+		case nil:
+		case map[string]interface{}:
+			if err := flatten(key, v, values); err != nil {
+				return err
+			}
+		case []interface{}:
+			return fmt.Errorf("%s must be a single value", key)
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+
+	return nil
+}
+
+// flagRecorder keeps the flags aside, so they are applied after the file
+// and the environment whatever their order on the command line.
+type flagRecorder struct {
+	setting *setting
+	values  map[string]string
+}
+
+func (r *flagRecorder) Set(v string) error {
+	r.values[r.setting.key] = v
+	return nil
+}
+
+func (r *flagRecorder) String() string {
+	return ""
+}
+
+func (r *flagRecorder) IsBoolFlag() bool {
+	_, ok := r.setting.value.(*boolValue)
+	return ok
+}
+
+type stringValue struct{ p *string }
+
+func (v *stringValue) Set(s string) error {
+	*v.p = s
+	return nil
+}
+
+func (v *stringValue) String() string {
+	return *v.p
+}
+
+type boolValue struct{ p *bool }
+
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return fmt.Errorf("invalid boolean %q, expected true or false", s)
+	}
+	*v.p = b
+
+	return nil
+}
+
+func (v *boolValue) String() string {
+	return strconv.FormatBool(*v.p)
+}
+
+type intValue struct{ p *int }
+
+func (v *intValue) Set(s string) error {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("invalid integer %q", s)
+	}
+	*v.p = i
+
+	return nil
+}
+
+func (v *intValue) String() string {
+	return strconv.Itoa(*v.p)
+}
+
+type floatValue struct{ p *float64 }
+
+func (v *floatValue) Set(s string) error {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %q", s)
+	}
+	*v.p = f
+
+	return nil
+}
+
+func (v *floatValue) String() string {
+	return strconv.FormatFloat(*v.p, 'f', -1, 64)
+}
+
+type durationValue struct{ p *time.Duration }
+
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q, expected a value such as 500ms or 30s", s)
+	}
+	*v.p = d
+
+	return nil
+}
+
+func (v *durationValue) String() string {
+	return v.p.String()
+}
+
+// fileValue sets a secret to the content of the file it is given, without
+// the trailing newline editors and secret mounts add.
+type fileValue struct{ secret flag.Value }
+
+func (v *fileValue) Set(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return v.secret.Set(strings.TrimRight(string(content), "\r\n"))
+}
+
+func (v *fileValue) String() string {
+	return ""
+}
@@ -0,0 +1,163 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// defaultPageSize is the page size of list endpoints called without limit.
+const defaultPageSize = 100
+
+const minJWTSecretLength = 32
+
+var (
+	logLevels = map[string]bool{"silent": true, "error": true, "warn": true, "info": true}
+	exporters = map[string]bool{"none": true, "otlp": true, "stdout": true}
+)
+
+// validator collects the errors of a configuration, naming each setting
+// with its key and environment variable.
+type validator struct {
+	envs map[string]string
+	errs []error
+}
+
+func newValidator(c *Config) *validator {
+	v := &validator{envs: map[string]string{}}
+	for _, s := range settings(c) {
+		v.envs[s.key] = s.env
+	}
+
+	return v
+}
+
+func (v *validator) check(ok bool, key string, format string, args ...interface{}) {
+	if !ok {
+		v.errs = append(v.errs, fmt.Errorf("%s (%s) %s", key, v.envs[key], fmt.Sprintf(format, args...)))
+	}
+}
+
+func (v *validator) required(key string, value string) {
+	v.check(value != "", key, "is required")
+}
+
+func (v *validator) port(key string, value string) {
+	port, err := strconv.Atoi(value)
+	v.check(err == nil && port > 0 && port <= 65535, key, "must be a port number, got %q", value)
+}
+
+func (v *validator) positive(key string, value int) {
+	v.check(value > 0, key, "must be positive, got %d", value)
+}
+
+func (v *validator) positiveDuration(key string, value time.Duration) {
+	v.check(value > 0, key, "must be a positive duration, got %s", value)
+}
+
+func (v *validator) err() error {
+	return errors.Join(v.errs...)
+}
+
+// Validate reports every setting the service cannot start with.
+func (c *Config) Validate() error {
+	v := newValidator(c)
+	c.validateDatabase(v)
+	c.validateApproval(v)
+
+	d := c.Database
+	v.check(logLevels[d.LogLevel], "database.log_level", "must be silent, error, warn or info, got %q", d.LogLevel)
+	v.check(d.SlowQueryThreshold >= 0, "database.slow_query_threshold", "must not be negative")
+	v.positive("database.max_open_conns", d.MaxOpenConns)
+	v.check(d.MaxIdleConns >= 0 && d.MaxIdleConns <= d.MaxOpenConns, "database.max_idle_conns", "must be between 0 and database.max_open_conns (%d), got %d", d.MaxOpenConns, d.MaxIdleConns)
+	v.positiveDuration("database.conn_max_lifetime", d.ConnMaxLifetime)
+	v.positiveDuration("database.conn_max_idle_time", d.ConnMaxIdleTime)
+	if d.ReplicaDSN != "" {
+		v.positiveDuration("database.replica_max_lag", d.ReplicaMaxLag)
+		v.positiveDuration("database.replica_check_interval", d.ReplicaCheckInterval)
+		// a check older than the allowed lag cannot vouch for the replica
+		v.check(d.ReplicaCheckInterval < d.ReplicaMaxLag, "database.replica_check_interval", "must be shorter than database.replica_max_lag (%s), got %s", d.ReplicaMaxLag, d.ReplicaCheckInterval)
+	}
+
+	s := c.BankingService
+	v.port("service.port", s.Port)
+	if s.GRPCEnabled {
+		v.port("service.grpc_port", s.GRPCPort)
+		v.check(s.GRPCPort != s.Port, "service.grpc_port", "must differ from service.port")
+	}
+	v.port("metrics.port", c.Metrics.Port)
+	v.check(c.Metrics.Port != s.Port, "metrics.port", "must differ from service.port")
+	v.positiveDuration("service.read_header_timeout", s.ReadHeaderTimeout)
+	v.positiveDuration("service.idle_timeout", s.IdleTimeout)
+	v.check(s.MaxPageSize >= defaultPageSize, "service.max_page_size", "must be at least the default page size %d, got %d", defaultPageSize, s.MaxPageSize)
+
+	v.required("auth.jwt_secret", c.Auth.JWTSecret)
+	v.check(c.Auth.JWTSecret == "" || len(c.Auth.JWTSecret) >= minJWTSecretLength, "auth.jwt_secret", "must be at least %d bytes", minJWTSecretLength)
+
+	v.positiveDuration("approval.expiry_interval", c.Approval.ExpiryInterval)
+
+	v.positiveDuration("outbox.relay_interval", c.Outbox.RelayInterval)
+	v.positive("outbox.relay_batch_size", c.Outbox.RelayBatchSize)
+	v.positive("outbox.max_attempts", c.Outbox.MaxAttempts)
+	v.positiveDuration("outbox.publish_timeout", c.Outbox.PublishTimeout)
+
+	w := c.Webhook
+	v.positive("webhook.workers", w.Workers)
+	v.positiveDuration("webhook.poll_interval", w.PollInterval)
+	v.positiveDuration("webhook.timeout", w.Timeout)
+	v.positive("webhook.max_attempts", w.MaxAttempts)
+	v.positiveDuration("webhook.base_backoff", w.BaseBackoff)
+	v.check(w.MaxBackoff >= w.BaseBackoff, "webhook.max_backoff", "must be at least webhook.base_backoff (%s), got %s", w.BaseBackoff, w.MaxBackoff)
+
+	n := c.Notification
+	v.positive("notification.max_connections_per_user", n.MaxConnectionsPerUser)
+	v.positiveDuration("notification.max_connection_age", n.MaxConnectionAge)
+	v.positiveDuration("notification.ping_interval", n.PingInterval)
+	// a pong only arrives after a ping
+	v.check(n.PongWait > n.PingInterval, "notification.pong_wait", "must be longer than notification.ping_interval (%s), got %s", n.PingInterval, n.PongWait)
+	v.positiveDuration("notification.write_wait", n.WriteWait)
+	v.positive("notification.send_buffer", n.SendBuffer)
+	v.positiveDuration("notification.event_poll_interval", n.EventPollInterval)
+	v.positiveDuration("notification.event_gap_timeout", n.EventGapTimeout)
+	v.positiveDuration("notification.sse_heartbeat_interval", n.SSEHeartbeatInterval)
+
+	v.positiveDuration("change_feed.sequence_interval", c.ChangeFeed.SequenceInterval)
+	v.positive("change_feed.sequence_batch_size", c.ChangeFeed.SequenceBatchSize)
+
+	t := c.Tracing
+	v.check(exporters[t.Exporter], "tracing.exporter", "must be none, otlp or stdout, got %q", t.Exporter)
+	if t.Exporter == "otlp" {
+		v.required("tracing.otlp_endpoint", t.OTLPEndpoint)
+	}
+	v.check(t.SampleRatio >= 0 && t.SampleRatio <= 1, "tracing.sample_ratio", "must be between 0 and 1, got %g", t.SampleRatio)
+
+	h := c.Health
+	v.positiveDuration("health.readiness_timeout", h.ReadinessTimeout)
+	v.check(h.DrainDelay >= 0, "health.drain_delay", "must not be negative")
+	v.positiveDuration("health.shutdown_timeout", h.ShutdownTimeout)
+
+	return v.err()
+}
+
+// ValidateCLI reports the settings bankctl cannot run with; it only uses
+// the database and the approval rules.
+func (c *Config) ValidateCLI() error {
+	v := newValidator(c)
+	c.validateDatabase(v)
+	c.validateApproval(v)
+
+	return v.err()
+}
+
+func (c *Config) validateDatabase(v *validator) {
+	v.required("database.host", c.Database.Host)
+	v.port("database.port", c.Database.Port)
+	v.required("database.username", c.Database.Username)
+	v.required("database.name", c.Database.Name)
+}
+
+func (c *Config) validateApproval(v *validator) {
+	v.check(c.Approval.Threshold > 0, "approval.threshold", "must be positive, got %g", c.Approval.Threshold)
+	v.positiveDuration("approval.ttl", c.Approval.TTL)
+}
@@ -2,22 +2,40 @@ package domains
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
+
+	"banking-service/enums"
+)
+
+const (
+	maxDescriptionLength = 255
+	maxReferenceLength   = 140
+	maxEndToEndIDLength  = 35
+	maxTags              = 10
+	maxTagLength         = 50
+
+	DefaultCurrency = "USD"
 )
 
 type (
 	CreateAccountRequest struct {
-		UserID string `json:"user_id"`
-		Name   string `json:"name"`
+		UserID   string `json:"user_id"`
+		Name     string `json:"name"`
+		Currency string `json:"currency,omitempty"`
 	}
 
 	Account struct {
-		AccountID string
-		UserID    string
-		Name      string
-		Balance   float64
-		CreatedAt time.Time
-		UpdatedAt time.Time
+		AccountID string  `json:"account_id"`
+		UserID    string  `json:"user_id"`
+		Name      string  `json:"name"`
+		Currency  string  `json:"currency"`
+		Balance   float64 `json:"balance"`
+		// FrozenAt is set while the account accepts no money movements.
+		FrozenAt  *time.Time `json:"frozen_at,omitempty"`
+		CreatedAt time.Time  `json:"created_at"`
+		UpdatedAt time.Time  `json:"updated_at"`
 	}
 
 	GetAccountsResponse struct {
@@ -25,8 +43,30 @@ type (
 		NextCursor string     `json:"next_cursor"`
 	}
 
+	CurrencyTotal struct {
+		Currency     string  `json:"currency"`
+		Balance      float64 `json:"balance"`
+		AccountCount int64   `json:"account_count"`
+	}
+
+	GetUserAccountsResponse struct {
+		Accounts   []*Account       `json:"accounts"`
+		Totals     []*CurrencyTotal `json:"totals"`
+		NextCursor string           `json:"next_cursor"`
+	}
+
+	// TransactionDetails holds the optional user-supplied fields accepted by
+	// every money movement request.
+	TransactionDetails struct {
+		Description string   `json:"description,omitempty"`
+		Reference   string   `json:"reference,omitempty"`
+		EndToEndID  string   `json:"end_to_end_id,omitempty"`
+		Tags        []string `json:"tags,omitempty"`
+	}
+
 	DepositAccountRequest struct {
 		Amount float64 `json:"amount"`
+		TransactionDetails
 	}
 
 	DepositAccountResponse struct {
@@ -35,6 +75,7 @@ type (
 
 	WithdrawAccountRequest struct {
 		Amount float64 `json:"amount"`
+		TransactionDetails
 	}
 
 	WithdrawAccountResponse struct {
@@ -44,10 +85,29 @@ type (
 	TransferAccountRequest struct {
 		ToAccountID string  `json:"to_account_id"`
 		Amount      float64 `json:"amount"`
+		TransactionDetails
 	}
 
+	// TransferAccountResponse carries ApprovalID instead of TransactionID
+	// when the transfer waits for a maker-checker approval.
 	TransferAccountResponse struct {
-		TransactionID string `json:"transaction_id"`
+		TransactionID string `json:"transaction_id,omitempty"`
+		ApprovalID    string `json:"approval_id,omitempty"`
+		Status        string `json:"status"`
+	}
+
+	// AdjustAccountRequest credits (positive amount) or debits (negative
+	// amount) an account outside of the customer flows.
+	AdjustAccountRequest struct {
+		Amount     float64 `json:"amount"`
+		ReasonCode string  `json:"reason_code"`
+		TransactionDetails
+	}
+
+	AdjustAccountResponse struct {
+		TransactionID string `json:"transaction_id,omitempty"`
+		ApprovalID    string `json:"approval_id,omitempty"`
+		Status        string `json:"status"`
 	}
 )
 
@@ -56,15 +116,37 @@ func (r *CreateAccountRequest) Validate() error {
 		return errors.New("missing name")
 	}
 
+	r.Currency = strings.ToUpper(strings.TrimSpace(r.Currency))
+	if r.Currency == "" {
+		r.Currency = DefaultCurrency
+	}
+	if !isCurrencyCode(r.Currency) {
+		return fmt.Errorf("invalid currency %q", r.Currency)
+	}
+
 	return nil
 }
 
+// isCurrencyCode reports whether s looks like an ISO 4217 alphabetic code.
+func isCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (r *DepositAccountRequest) Validate() error {
 	if r.Amount <= 0 {
 		return errors.New("insufficient amount")
 	}
 
-	return nil
+	return r.TransactionDetails.Validate()
 }
 
 func (r *WithdrawAccountRequest) Validate() error {
@@ -72,5 +154,67 @@ func (r *WithdrawAccountRequest) Validate() error {
 		return errors.New("insufficient amount")
 	}
 
+	return r.TransactionDetails.Validate()
+}
+
+func (r *TransferAccountRequest) Validate() error {
+	if r.ToAccountID == "" {
+		return errors.New("missing to_account_id")
+	}
+	if r.Amount <= 0 {
+		return errors.New("insufficient amount")
+	}
+
+	return r.TransactionDetails.Validate()
+}
+
+func (r *AdjustAccountRequest) Validate() error {
+	if r.Amount == 0 {
+		return errors.New("amount must not be zero")
+	}
+	if _, ok := enums.ParseAdjustmentReason(r.ReasonCode); !ok {
+		return fmt.Errorf("invalid reason_code %q", r.ReasonCode)
+	}
+
+	return r.TransactionDetails.Validate()
+}
+
+// Validate trims the details in place and checks them against the column limits.
+func (d *TransactionDetails) Validate() error {
+	d.Description = strings.TrimSpace(d.Description)
+	d.Reference = strings.TrimSpace(d.Reference)
+	d.EndToEndID = strings.TrimSpace(d.EndToEndID)
+
+	if len(d.Description) > maxDescriptionLength {
+		return fmt.Errorf("description exceeds %d characters", maxDescriptionLength)
+	}
+	if len(d.Reference) > maxReferenceLength {
+		return fmt.Errorf("reference exceeds %d characters", maxReferenceLength)
+	}
+	if len(d.EndToEndID) > maxEndToEndIDLength {
+		return fmt.Errorf("end_to_end_id exceeds %d characters", maxEndToEndIDLength)
+	}
+	if len(d.Tags) > maxTags {
+		return fmt.Errorf("at most %d tags are allowed", maxTags)
+	}
+
+	seen := make(map[string]struct{}, len(d.Tags))
+	tags := make([]string, 0, len(d.Tags))
+	for _, tag := range d.Tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return errors.New("tags must not be empty")
+		}
+		if len(tag) > maxTagLength {
+			return fmt.Errorf("tag %q exceeds %d characters", tag, maxTagLength)
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		tags = append(tags, tag)
+	}
+	d.Tags = tags
+
 	return nil
 }
@@ -0,0 +1,45 @@
+package domains
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type (
+	Approval struct {
+		ApprovalID     string          `json:"approval_id"`
+		Type           string          `json:"type"`
+		AccountID      string          `json:"account_id"`
+		Amount         float64         `json:"amount"`
+		Payload        json.RawMessage `json:"payload"`
+		Status         string          `json:"status"`
+		RequestedBy    string          `json:"requested_by"`
+		DecidedBy      string          `json:"decided_by,omitempty"`
+		DecisionReason string          `json:"decision_reason,omitempty"`
+		TransactionID  string          `json:"transaction_id,omitempty"`
+		ExpiresAt      time.Time       `json:"expires_at"`
+		DecidedAt      *time.Time      `json:"decided_at,omitempty"`
+		CreatedAt      time.Time       `json:"created_at"`
+		UpdatedAt      time.Time       `json:"updated_at"`
+	}
+
+	GetApprovalsResponse struct {
+		Approvals  []*Approval `json:"approvals"`
+		NextCursor string      `json:"next_cursor"`
+	}
+
+	DecideApprovalRequest struct {
+		Reason string `json:"reason,omitempty"`
+	}
+)
+
+func (r *DecideApprovalRequest) Validate() error {
+	r.Reason = strings.TrimSpace(r.Reason)
+	if len(r.Reason) > maxReasonLength {
+		return fmt.Errorf("reason exceeds %d characters", maxReasonLength)
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package domains
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type auditTrailContextKey struct{}
+
+type (
+	// AccountState is a snapshot of an account taken for the audit log.
+	AccountState struct {
+		UserID    string     `json:"user_id"`
+		Name      string     `json:"name"`
+		Currency  string     `json:"currency"`
+		Balance   float64    `json:"balance"`
+		FrozenAt  *time.Time `json:"frozen_at,omitempty"`
+		DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	}
+
+	// AccountChange is the state of an account before and after a request.
+	// Before is nil for created accounts.
+	AccountChange struct {
+		AccountID string        `json:"account_id"`
+		Before    *AccountState `json:"before"`
+		After     *AccountState `json:"after"`
+	}
+
+	AuditLog struct {
+		AuditID        string           `json:"audit_id"`
+		RequestID      string           `json:"request_id"`
+		ActorKind      string           `json:"actor_kind,omitempty"`
+		ActorID        string           `json:"actor_id,omitempty"`
+		ActorRole      string           `json:"actor_role,omitempty"`
+		ClientIP       string           `json:"client_ip"`
+		Method         string           `json:"method"`
+		Route          string           `json:"route"`
+		Path           string           `json:"path"`
+		StatusCode     int              `json:"status_code"`
+		PayloadHash    string           `json:"payload_hash"`
+		AccountChanges []*AccountChange `json:"account_changes"`
+		CreatedAt      time.Time        `json:"created_at"`
+	}
+
+	GetAuditLogsResponse struct {
+		AuditLogs  []*AuditLog `json:"audit_logs"`
+		NextCursor string      `json:"next_cursor"`
+	}
+)
+
+// AuditTrail collects the account changes made while serving a request. The
+// audit middleware attaches one to the request context.
+type AuditTrail struct {
+	mu      sync.Mutex
+	changes []*AccountChange
+}
+
+func WithAuditTrail(ctx context.Context, trail *AuditTrail) context.Context {
+	return context.WithValue(ctx, auditTrailContextKey{}, trail)
+}
+
+// RecordAccountChange adds a change to the audit trail of ctx, if any. When
+// the same account changes several times in a request, the first before and
+// the last after state are kept.
+func RecordAccountChange(ctx context.Context, accountID string, before, after *AccountState) {
+	trail, _ := ctx.Value(auditTrailContextKey{}).(*AuditTrail)
+	if trail == nil {
+		return
+	}
+
+	trail.mu.Lock()
+	defer trail.mu.Unlock()
+
+	for _, change := range trail.changes {
+		if change.AccountID == accountID {
+			change.After = after
+			return
+		}
+	}
+
+	trail.changes = append(trail.changes, &AccountChange{
+		AccountID: accountID,
+		Before:    before,
+		After:     after,
+	})
+}
+
+func (t *AuditTrail) Changes() []*AccountChange {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]*AccountChange{}, t.changes...)
+}
@@ -0,0 +1,129 @@
+package domains
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"banking-service/enums"
+)
+
+const (
+	maxAPIKeyNameLength = 80
+	maxTokenTTL         = 24 * time.Hour
+	defaultTokenTTL     = time.Hour
+)
+
+type (
+	CreateAPIKeyRequest struct {
+		Name string `json:"name"`
+		// Owner is the staff member or service holding the key. Keys of the
+		// same owner count as one person for maker-checker approvals.
+		Owner            string `json:"owner"`
+		Role             string `json:"role,omitempty"`
+		ExpiresInSeconds int64  `json:"expires_in_seconds,omitempty"`
+	}
+
+	APIKey struct {
+		KeyID       string     `json:"key_id"`
+		Name        string     `json:"name"`
+		Owner       string     `json:"owner"`
+		Role        string     `json:"role"`
+		RotatedFrom string     `json:"rotated_from,omitempty"`
+		LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+		ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+		RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+		CreatedAt   time.Time  `json:"created_at"`
+		UpdatedAt   time.Time  `json:"updated_at"`
+	}
+
+	// CreateAPIKeyResponse is the only time the plaintext key is returned.
+	CreateAPIKeyResponse struct {
+		*APIKey
+		Key string `json:"key"`
+	}
+
+	GetAPIKeysResponse struct {
+		APIKeys    []*APIKey `json:"api_keys"`
+		NextCursor string    `json:"next_cursor"`
+	}
+
+	// RotateAPIKeyRequest keeps the old key valid for GracePeriodSeconds so
+	// clients can roll over; zero revokes it immediately.
+	RotateAPIKeyRequest struct {
+		GracePeriodSeconds int64 `json:"grace_period_seconds,omitempty"`
+	}
+
+	CreateTokenRequest struct {
+		UserID     string `json:"user_id"`
+		TTLSeconds int64  `json:"ttl_seconds,omitempty"`
+	}
+
+	CreateTokenResponse struct {
+		Token     string    `json:"token"`
+		TokenType string    `json:"token_type"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+)
+
+func (r *CreateAPIKeyRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return errors.New("missing name")
+	}
+	if len(r.Name) > maxAPIKeyNameLength {
+		return fmt.Errorf("name exceeds %d characters", maxAPIKeyNameLength)
+	}
+	r.Owner = strings.TrimSpace(r.Owner)
+	if r.Owner == "" {
+		return errors.New("missing owner")
+	}
+	if len(r.Owner) > maxAPIKeyNameLength {
+		return fmt.Errorf("owner exceeds %d characters", maxAPIKeyNameLength)
+	}
+	if r.Role == "" {
+		r.Role = enums.Operator.String()
+	}
+	if role, ok := enums.ParseRole(r.Role); !ok || role == enums.Customer {
+		return fmt.Errorf("invalid role %q, expected Operator, Auditor or Admin", r.Role)
+	}
+	if r.ExpiresInSeconds < 0 {
+		return errors.New("expires_in_seconds must not be negative")
+	}
+
+	return nil
+}
+
+func (r *RotateAPIKeyRequest) Validate() error {
+	if r.GracePeriodSeconds < 0 {
+		return errors.New("grace_period_seconds must not be negative")
+	}
+	if time.Duration(r.GracePeriodSeconds)*time.Second > 7*24*time.Hour {
+		return errors.New("grace_period_seconds must not exceed 7 days")
+	}
+
+	return nil
+}
+
+func (r *CreateTokenRequest) Validate() error {
+	if r.UserID == "" {
+		return errors.New("missing user_id")
+	}
+	if r.TTLSeconds < 0 {
+		return errors.New("ttl_seconds must not be negative")
+	}
+	if time.Duration(r.TTLSeconds)*time.Second > maxTokenTTL {
+		return fmt.Errorf("ttl_seconds must not exceed %d", int64(maxTokenTTL/time.Second))
+	}
+
+	return nil
+}
+
+func (r *CreateTokenRequest) TTL() time.Duration {
+	if r.TTLSeconds == 0 {
+		return defaultTokenTTL
+	}
+
+	return time.Duration(r.TTLSeconds) * time.Second
+}
@@ -0,0 +1,27 @@
+package domains
+
+import "time"
+
+type (
+	// Change is one entry of the change feed. Data is the current state of
+	// the entity when the feed is read, which may already include later
+	// changes; consumers upsert it by entity_type and entity_id.
+	Change struct {
+		Position   int64     `json:"position"`
+		EntityType string    `json:"entity_type"`
+		EntityID   string    `json:"entity_id"`
+		Operation  string    `json:"operation"`
+		ChangedAt  time.Time `json:"changed_at"`
+		// Deleted is set for closed accounts and rows that no longer exist,
+		// the latter without Data.
+		Deleted bool        `json:"deleted"`
+		Data    interface{} `json:"data"`
+	}
+
+	// GetChangesResponse carries the cursor to pass as after on the next
+	// call, unchanged when there was nothing new.
+	GetChangesResponse struct {
+		Changes    []*Change `json:"changes"`
+		NextCursor string    `json:"next_cursor"`
+	}
+)
@@ -0,0 +1,155 @@
+package domains
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	maxSubjectLength     = 255
+	maxChatMessageLength = 4000
+	maxConversationUsers = 10
+)
+
+type (
+	// CreateConversationRequest opens a support conversation. Staff may add
+	// several users, for instance the holders of a joint account; customers
+	// can only open conversations for themselves.
+	CreateConversationRequest struct {
+		Subject   string   `json:"subject"`
+		AccountID string   `json:"account_id,omitempty"`
+		UserIDs   []string `json:"user_ids,omitempty"`
+		// Text is an optional first message.
+		Text string `json:"text,omitempty"`
+	}
+
+	ConversationParticipant struct {
+		ParticipantID     string     `json:"participant_id"`
+		ParticipantKind   string     `json:"participant_kind"`
+		LastReadMessageID string     `json:"last_read_message_id,omitempty"`
+		LastReadAt        *time.Time `json:"last_read_at,omitempty"`
+		JoinedAt          time.Time  `json:"joined_at"`
+	}
+
+	Conversation struct {
+		ConversationID string                     `json:"conversation_id"`
+		Subject        string                     `json:"subject"`
+		AccountID      string                     `json:"account_id,omitempty"`
+		CreatedBy      string                     `json:"created_by"`
+		LastMessageID  string                     `json:"last_message_id,omitempty"`
+		Participants   []*ConversationParticipant `json:"participants,omitempty"`
+		CreatedAt      time.Time                  `json:"created_at"`
+		UpdatedAt      time.Time                  `json:"updated_at"`
+	}
+
+	GetConversationsResponse struct {
+		Conversations []*Conversation `json:"conversations"`
+		NextCursor    string          `json:"next_cursor"`
+	}
+
+	ChatMessage struct {
+		MessageID      string    `json:"message_id"`
+		ConversationID string    `json:"conversation_id"`
+		SenderID       string    `json:"sender_id"`
+		SenderKind     string    `json:"sender_kind"`
+		Text           string    `json:"text"`
+		CreatedAt      time.Time `json:"created_at"`
+	}
+
+	GetChatMessagesResponse struct {
+		Messages   []*ChatMessage `json:"messages"`
+		NextCursor string         `json:"next_cursor"`
+	}
+
+	SendChatMessageRequest struct {
+		Text string `json:"text"`
+	}
+
+	// MarkConversationReadRequest moves the read receipt of the caller up to
+	// MessageID.
+	MarkConversationReadRequest struct {
+		MessageID string `json:"message_id"`
+	}
+
+	// ChatMessageSentEvent and ChatMessageReadEvent only carry IDs so the
+	// message text never leaves the database through the outbox.
+	ChatMessageSentEvent struct {
+		ConversationID string   `json:"conversation_id"`
+		MessageID      string   `json:"message_id"`
+		SenderID       string   `json:"sender_id"`
+		RecipientIDs   []string `json:"recipient_ids"`
+	}
+
+	ChatMessageReadEvent struct {
+		ConversationID string   `json:"conversation_id"`
+		MessageID      string   `json:"message_id"`
+		ReaderID       string   `json:"reader_id"`
+		RecipientIDs   []string `json:"recipient_ids"`
+	}
+)
+
+func (r *CreateConversationRequest) Validate() (err error) {
+	r.Subject = strings.TrimSpace(r.Subject)
+	if r.Subject == "" {
+		return errors.New("missing subject")
+	}
+	if len(r.Subject) > maxSubjectLength {
+		return fmt.Errorf("subject exceeds %d characters", maxSubjectLength)
+	}
+	r.AccountID = strings.TrimSpace(r.AccountID)
+	if len(r.UserIDs) > maxConversationUsers {
+		return fmt.Errorf("at most %d user_ids are allowed", maxConversationUsers)
+	}
+
+	seen := make(map[string]struct{}, len(r.UserIDs))
+	userIDs := make([]string, 0, len(r.UserIDs))
+	for _, userID := range r.UserIDs {
+		userID = strings.TrimSpace(userID)
+		if userID == "" {
+			return errors.New("user_ids must not be empty")
+		}
+		if _, ok := seen[userID]; ok {
+			continue
+		}
+		seen[userID] = struct{}{}
+		userIDs = append(userIDs, userID)
+	}
+	r.UserIDs = userIDs
+
+	if r.Text != "" {
+		if r.Text, err = NormalizeChatText(r.Text); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *SendChatMessageRequest) Validate() (err error) {
+	r.Text, err = NormalizeChatText(r.Text)
+	return err
+}
+
+func (r *MarkConversationReadRequest) Validate() error {
+	r.MessageID = strings.TrimSpace(r.MessageID)
+	if r.MessageID == "" {
+		return errors.New("missing message_id")
+	}
+
+	return nil
+}
+
+// NormalizeChatText trims a chat message and checks its length.
+func NormalizeChatText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", errors.New("missing text")
+	}
+	if len(text) > maxChatMessageLength {
+		return "", fmt.Errorf("text exceeds %d characters", maxChatMessageLength)
+	}
+
+	return text, nil
+}
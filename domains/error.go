@@ -5,14 +5,25 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type (
 	ErrorResp struct {
 		Message string `json:"message"`
+		// RequestID lets clients quote the request when reporting an error.
+		RequestID string `json:"request_id,omitempty"`
 	}
 )
 
+func NewErrorResp(c *gin.Context, message string) ErrorResp {
+	return ErrorResp{
+		Message:   message,
+		RequestID: GetRequestID(c),
+	}
+}
+
 type XError struct {
 	Err       error
 	ErrorCode enums.ErrorCode
@@ -25,7 +36,7 @@ func NewXError(err error, errCode enums.ErrorCode) XError {
 	}
 }
 
-func (xerror XError) Is(errCode enums.ErrorCode) bool {
+func (xerror XError) HasCode(errCode enums.ErrorCode) bool {
 	return xerror.ErrorCode == errCode
 }
 
@@ -33,15 +44,47 @@ func (xerror XError) Error() string {
 	return xerror.Err.Error()
 }
 
+// Response writes the error and attaches it to c for the access log.
 func (xerror XError) Response(c *gin.Context) {
+	_ = c.Error(xerror)
+	c.JSON(xerror.HTTPStatus(), NewErrorResp(c, xerror.Err.Error()))
+}
+
+// GRPCStatus maps the error to the gRPC status matching its HTTP response.
+func (xerror XError) GRPCStatus() *status.Status {
+	code := codes.Unknown
 	switch xerror.ErrorCode {
 	case enums.BadRequest:
-		c.JSON(http.StatusBadRequest, ErrorResp{
-			Message: xerror.Err.Error(),
-		})
+		code = codes.InvalidArgument
+	case enums.NotFound:
+		code = codes.NotFound
+	case enums.Conflict:
+		code = codes.AlreadyExists
+	case enums.Unauthorized:
+		code = codes.Unauthenticated
+	case enums.Forbidden:
+		code = codes.PermissionDenied
 	case enums.InternalError:
-		c.JSON(http.StatusInternalServerError, ErrorResp{
-			Message: xerror.Err.Error(),
-		})
+		code = codes.Internal
+	}
+
+	return status.New(code, xerror.Err.Error())
+}
+
+// HTTPStatus is the status code of Response.
+func (xerror XError) HTTPStatus() int {
+	switch xerror.ErrorCode {
+	case enums.BadRequest:
+		return http.StatusBadRequest
+	case enums.NotFound:
+		return http.StatusNotFound
+	case enums.Conflict:
+		return http.StatusConflict
+	case enums.Unauthorized:
+		return http.StatusUnauthorized
+	case enums.Forbidden:
+		return http.StatusForbidden
 	}
+
+	return http.StatusInternalServerError
 }
@@ -0,0 +1,139 @@
+package domains
+
+import (
+	"encoding/json"
+	"time"
+
+	"banking-service/enums"
+)
+
+type (
+	// Event is a domain event as delivered to sinks. Sequence increases with
+	// every event; events of the same account are always delivered in order.
+	Event struct {
+		EventID     string          `json:"event_id"`
+		Sequence    int64           `json:"sequence"`
+		Type        string          `json:"type"`
+		AggregateID string          `json:"aggregate_id"`
+		Payload     json.RawMessage `json:"payload"`
+		CreatedAt   time.Time       `json:"created_at"`
+	}
+
+	AccountCreatedEvent struct {
+		AccountID string `json:"account_id"`
+		UserID    string `json:"user_id"`
+		Name      string `json:"name"`
+		Currency  string `json:"currency"`
+	}
+
+	AccountClosedEvent struct {
+		AccountID string `json:"account_id"`
+		UserID    string `json:"user_id"`
+	}
+
+	// AccountFreezeEvent is the payload of AccountFrozen and AccountUnfrozen.
+	AccountFreezeEvent struct {
+		AccountID string `json:"account_id"`
+		UserID    string `json:"user_id"`
+		Reason    string `json:"reason,omitempty"`
+	}
+
+	// FundsMovedEvent is the payload of FundsDeposited and FundsWithdrawn.
+	// Amount is negative for withdrawals.
+	FundsMovedEvent struct {
+		TransactionID string   `json:"transaction_id"`
+		AccountID     string   `json:"account_id"`
+		UserID        string   `json:"user_id"`
+		Amount        float64  `json:"amount"`
+		Balance       float64  `json:"balance"`
+		Currency      string   `json:"currency"`
+		Description   string   `json:"description,omitempty"`
+		Reference     string   `json:"reference,omitempty"`
+		EndToEndID    string   `json:"end_to_end_id,omitempty"`
+		Tags          []string `json:"tags,omitempty"`
+	}
+
+	TransferCompletedEvent struct {
+		TransactionID            string   `json:"transaction_id"`
+		DestinationTransactionID string   `json:"destination_transaction_id"`
+		FromAccountID            string   `json:"from_account_id"`
+		ToAccountID              string   `json:"to_account_id"`
+		Amount                   float64  `json:"amount"`
+		FromBalance              float64  `json:"from_balance"`
+		ToBalance                float64  `json:"to_balance"`
+		Currency                 string   `json:"currency"`
+		ApprovalID               string   `json:"approval_id,omitempty"`
+		Description              string   `json:"description,omitempty"`
+		Reference                string   `json:"reference,omitempty"`
+		EndToEndID               string   `json:"end_to_end_id,omitempty"`
+		Tags                     []string `json:"tags,omitempty"`
+	}
+
+	AdjustmentPostedEvent struct {
+		TransactionID string   `json:"transaction_id"`
+		AccountID     string   `json:"account_id"`
+		UserID        string   `json:"user_id"`
+		Amount        float64  `json:"amount"`
+		Balance       float64  `json:"balance"`
+		Currency      string   `json:"currency"`
+		ReasonCode    string   `json:"reason_code"`
+		ApprovalID    string   `json:"approval_id,omitempty"`
+		Description   string   `json:"description,omitempty"`
+		Reference     string   `json:"reference,omitempty"`
+		EndToEndID    string   `json:"end_to_end_id,omitempty"`
+		Tags          []string `json:"tags,omitempty"`
+	}
+)
+
+// eventAccounts holds the account fields of every event payload.
+type eventAccounts struct {
+	AccountID     string `json:"account_id"`
+	FromAccountID string `json:"from_account_id"`
+	ToAccountID   string `json:"to_account_id"`
+}
+
+// AccountIDs returns the accounts touched by the event.
+func (e *Event) AccountIDs() []string {
+	var accounts eventAccounts
+	if err := json.Unmarshal(e.Payload, &accounts); err != nil {
+		return nil
+	}
+
+	accountIDs := make([]string, 0, 2)
+	for _, accountID := range []string{accounts.AccountID, accounts.FromAccountID, accounts.ToAccountID} {
+		if accountID != "" {
+			accountIDs = append(accountIDs, accountID)
+		}
+	}
+
+	return accountIDs
+}
+
+// RedactCounterparty returns the event without the balance of each side of
+// a transfer for which visible returns false. Other events are returned
+// unchanged.
+func (e *Event) RedactCounterparty(visible func(accountID string) bool) *Event {
+	if e.Type != enums.TransferCompleted.String() {
+		return e
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(e.Payload, &payload); err != nil {
+		return e
+	}
+	if fromAccountID, _ := payload["from_account_id"].(string); !visible(fromAccountID) {
+		delete(payload, "from_balance")
+	}
+	if toAccountID, _ := payload["to_account_id"].(string); !visible(toAccountID) {
+		delete(payload, "to_balance")
+	}
+
+	redacted, err := json.Marshal(payload)
+	if err != nil {
+		return e
+	}
+
+	redactedEvent := *e
+	redactedEvent.Payload = redacted
+	return &redactedEvent
+}
@@ -0,0 +1,30 @@
+package domains
+
+import "time"
+
+type (
+	// HealthResponse is the body of the readiness endpoint. Status is "ok"
+	// when every check passed and "unavailable" otherwise.
+	HealthResponse struct {
+		Status  string                  `json:"status"`
+		Checks  map[string]*HealthCheck `json:"checks,omitempty"`
+		Workers []*WorkerStatus         `json:"workers,omitempty"`
+	}
+
+	HealthCheck struct {
+		Status string `json:"status"`
+		Error  string `json:"error,omitempty"`
+	}
+
+	// WorkerStatus reports a background worker. Workers do not serve
+	// requests, so an unhealthy worker does not fail readiness.
+	WorkerStatus struct {
+		Name      string     `json:"name"`
+		Running   bool       `json:"running"`
+		LastRunAt *time.Time `json:"last_run_at,omitempty"`
+		LastError string     `json:"last_error,omitempty"`
+		// Stale is set when a running worker has not completed a run for
+		// much longer than its interval.
+		Stale bool `json:"stale"`
+	}
+)
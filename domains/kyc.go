@@ -0,0 +1,105 @@
+package domains
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"banking-service/enums"
+)
+
+// NoLimit marks an operation as unrestricted for a KYC tier.
+const NoLimit = math.MaxFloat64
+
+// KYCTier holds the per-transaction and daily limits of a KYC level. A zero
+// limit forbids the operation.
+type KYCTier struct {
+	MaxDeposit     float64
+	MaxWithdrawal  float64
+	MaxTransferOut float64
+	// DailyOutflow caps withdrawals and outgoing transfers across all
+	// accounts of the user since midnight UTC.
+	DailyOutflow float64
+}
+
+var KYCTiers = map[enums.KYCLevel]KYCTier{
+	enums.KYCNone: {
+		MaxDeposit: 1000,
+	},
+	enums.KYCBasic: {
+		MaxDeposit:     10000,
+		MaxWithdrawal:  1000,
+		MaxTransferOut: 1000,
+		DailyOutflow:   2000,
+	},
+	enums.KYCFull: {
+		MaxDeposit:     NoLimit,
+		MaxWithdrawal:  NoLimit,
+		MaxTransferOut: NoLimit,
+		DailyOutflow:   NoLimit,
+	},
+}
+
+// CheckIncoming reports whether a user may receive amount through a deposit
+// or an incoming transfer.
+func CheckIncoming(status, level string, txType enums.TransactionType, amount float64) error {
+	userStatus, tier, err := parseStatusAndTier(status, level)
+	if err != nil {
+		return err
+	}
+
+	if userStatus != enums.UserPending && userStatus != enums.UserVerified {
+		return fmt.Errorf("user is %s and cannot receive funds", status)
+	}
+	if txType == enums.Deposit && amount > tier.MaxDeposit {
+		return fmt.Errorf("deposit exceeds the %s KYC limit of %v", level, tier.MaxDeposit)
+	}
+
+	return nil
+}
+
+// CheckOutgoing reports whether a user may send amount through a withdrawal
+// or an outgoing transfer, given what already left today.
+func CheckOutgoing(status, level string, txType enums.TransactionType, amount, outflowToday float64) error {
+	userStatus, tier, err := parseStatusAndTier(status, level)
+	if err != nil {
+		return err
+	}
+
+	if userStatus != enums.UserVerified {
+		return fmt.Errorf("user is %s and cannot move funds out", status)
+	}
+
+	max := tier.MaxWithdrawal
+	if txType == enums.Transfer {
+		max = tier.MaxTransferOut
+	}
+	if max == 0 {
+		return fmt.Errorf("%s is not allowed at KYC level %s", txType, level)
+	}
+	if amount > max {
+		return fmt.Errorf("%s exceeds the %s KYC limit of %v", txType, level, max)
+	}
+	if tier.DailyOutflow != NoLimit && outflowToday+amount > tier.DailyOutflow {
+		return fmt.Errorf("daily outflow limit of %v for KYC level %s exceeded", tier.DailyOutflow, level)
+	}
+
+	return nil
+}
+
+func parseStatusAndTier(status, level string) (enums.UserStatus, KYCTier, error) {
+	userStatus, ok := enums.ParseUserStatus(status)
+	if !ok {
+		return 0, KYCTier{}, fmt.Errorf("unknown user status %q", status)
+	}
+	kycLevel, ok := enums.ParseKYCLevel(level)
+	if !ok {
+		return 0, KYCTier{}, fmt.Errorf("unknown kyc level %q", level)
+	}
+	tier, ok := KYCTiers[kycLevel]
+	if !ok {
+		return 0, KYCTier{}, errors.New("kyc tier not configured")
+	}
+
+	return userStatus, tier, nil
+}
@@ -0,0 +1,69 @@
+package domains
+
+import (
+	"context"
+
+	"banking-service/enums"
+
+	"github.com/gin-gonic/gin"
+)
+
+const principalContextKey = "principal"
+
+// principalKey stores the principal in a context.Context, for APIs served
+// outside of gin.
+type principalKey struct{}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Kind enums.PrincipalKind
+	// ID is the API key ID for service clients and the user ID for end users.
+	ID   string
+	Name string
+	Role enums.Role
+	// Owner is the person or service accountable for the credential: the
+	// owner of an API key, the operator running bankctl, and for a JWT the
+	// owner of the key that issued it, or the user for older tokens.
+	Owner string
+}
+
+func (p *Principal) IsUser() bool {
+	return p.Kind == enums.UserPrincipal
+}
+
+func (p *Principal) HasRole(roles ...enums.Role) bool {
+	for _, role := range roles {
+		if p.Role == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+func SetPrincipal(c *gin.Context, principal *Principal) {
+	c.Set(principalContextKey, principal)
+}
+
+// GetPrincipal returns the principal set by the authentication middleware,
+// or nil on public routes.
+func GetPrincipal(c *gin.Context) *Principal {
+	v, ok := c.Get(principalContextKey)
+	if !ok {
+		return nil
+	}
+
+	principal, _ := v.(*Principal)
+	return principal
+}
+
+// WithPrincipal returns ctx carrying principal, for the gRPC API.
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal set by WithPrincipal, or nil.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
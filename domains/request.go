@@ -0,0 +1,35 @@
+package domains
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+const requestIDContextKey = "request_id"
+
+// requestIDKey stores the request ID in a context.Context, so it reaches the
+// repositories and the gRPC API.
+type requestIDKey struct{}
+
+// SetRequestID stores requestID on the gin context and on its request
+// context.
+func SetRequestID(c *gin.Context, requestID string) {
+	c.Set(requestIDContextKey, requestID)
+	c.Request = c.Request.WithContext(WithRequestID(c.Request.Context(), requestID))
+}
+
+// GetRequestID returns the ID set by the request ID middleware, or "".
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDContextKey)
+}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the ID set by WithRequestID, or "".
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
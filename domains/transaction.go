@@ -4,16 +4,20 @@ import "time"
 
 type (
 	Transaction struct {
-		TransactionID string
-		AccountID     string
-		UserID        string
-		Amount        float64
-		Balance       float64
-		Type          string
-		Status        string
-		Metadata      string
-		CreatedAt     time.Time
-		UpdatedAt     time.Time
+		TransactionID string    `json:"transaction_id"`
+		AccountID     string    `json:"account_id"`
+		UserID        string    `json:"user_id"`
+		Amount        float64   `json:"amount"`
+		Balance       float64   `json:"balance"`
+		Type          string    `json:"type"`
+		Status        string    `json:"status"`
+		Description   string    `json:"description,omitempty"`
+		Reference     string    `json:"reference,omitempty"`
+		EndToEndID    string    `json:"end_to_end_id,omitempty"`
+		Tags          []string  `json:"tags,omitempty"`
+		Metadata      string    `json:"metadata,omitempty"`
+		CreatedAt     time.Time `json:"created_at"`
+		UpdatedAt     time.Time `json:"updated_at"`
 	}
 
 	GetTransactionsResp struct {
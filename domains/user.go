@@ -1,29 +1,347 @@
 package domains
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"banking-service/enums"
+
+	"golang.org/x/text/language"
+)
+
+const (
+	maxNameLength        = 80
+	maxReasonLength      = 255
+	maxEmailLength       = 254
+	maxAddressLineLength = 255
+	maxCityLength        = 100
+	maxPostalCodeLength  = 20
+	maxAgeYears          = 150
+	maxLanguageTagLength = 35
+
+	// DateLayout is the format of dates such as date_of_birth.
+	DateLayout = "2006-01-02"
+
+	// ErasedValue replaces personal data of erased users.
+	ErasedValue = "[erased]"
+)
+
+var e164Regexp = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
 
 type (
-	CreateUserRequest struct {
-		Name string `json:"name"`
+	Address struct {
+		Line1      string `json:"line1"`
+		Line2      string `json:"line2,omitempty"`
+		City       string `json:"city"`
+		PostalCode string `json:"postal_code,omitempty"`
+		Country    string `json:"country"`
 	}
 
-	CreateUserResponse struct {
-		ID        string    `json:"id"`
-		Name      string    `json:"name"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
+	CreateUserRequest struct {
+		Name              string   `json:"name"`
+		Email             string   `json:"email,omitempty"`
+		Phone             string   `json:"phone,omitempty"`
+		DateOfBirth       string   `json:"date_of_birth,omitempty"`
+		Address           *Address `json:"address,omitempty"`
+		PreferredLanguage string   `json:"preferred_language,omitempty"`
 	}
 
 	User struct {
-		ID         string    `json:"id"`
-		Name       string    `json:"name"`
-		AccountIDs []string  `json:"account_ids,omitempty"`
-		CreatedAt  time.Time `json:"created_at"`
-		UpdatedAt  time.Time `json:"updated_at"`
+		ID                string     `json:"id"`
+		Name              string     `json:"name"`
+		Email             string     `json:"email,omitempty"`
+		Phone             string     `json:"phone,omitempty"`
+		DateOfBirth       string     `json:"date_of_birth,omitempty"`
+		Address           *Address   `json:"address,omitempty"`
+		PreferredLanguage string     `json:"preferred_language,omitempty"`
+		Status            string     `json:"status"`
+		KYCLevel          string     `json:"kyc_level"`
+		AccountIDs        []string   `json:"account_ids,omitempty"`
+		CreatedAt         time.Time  `json:"created_at"`
+		UpdatedAt         time.Time  `json:"updated_at"`
+		ErasedAt          *time.Time `json:"erased_at,omitempty"`
 	}
 
 	GetUsersResponse struct {
 		Users      []*User `json:"users"`
-		NextCursor string  `json:"cursor"`
+		NextCursor string  `json:"next_cursor"`
+	}
+
+	// UpdateUserRequest only changes the fields that are present. An empty
+	// string clears an optional field.
+	UpdateUserRequest struct {
+		Name              *string  `json:"name"`
+		Email             *string  `json:"email"`
+		Phone             *string  `json:"phone"`
+		DateOfBirth       *string  `json:"date_of_birth"`
+		Address           *Address `json:"address"`
+		PreferredLanguage *string  `json:"preferred_language"`
+		Reason            string   `json:"reason,omitempty"`
+	}
+
+	UpdateUserStatusRequest struct {
+		Status   string `json:"status"`
+		KYCLevel string `json:"kyc_level,omitempty"`
+		Reason   string `json:"reason"`
+	}
+
+	EraseUserRequest struct {
+		Reason string `json:"reason"`
+	}
+
+	FieldChange struct {
+		Old string `json:"old"`
+		New string `json:"new"`
+	}
+
+	UserAudit struct {
+		AuditID   string                 `json:"audit_id"`
+		UserID    string                 `json:"user_id"`
+		Action    string                 `json:"action"`
+		Changes   map[string]FieldChange `json:"changes"`
+		Reason    string                 `json:"reason,omitempty"`
+		CreatedAt time.Time              `json:"created_at"`
+	}
+
+	GetUserAuditsResponse struct {
+		Audits     []*UserAudit `json:"audits"`
+		NextCursor string       `json:"next_cursor"`
 	}
 )
+
+func (r *CreateUserRequest) Validate() (err error) {
+	if r.Name, err = normalizeName(r.Name); err != nil {
+		return err
+	}
+	if r.Email, err = NormalizeEmail(r.Email); err != nil {
+		return err
+	}
+	if r.Phone, err = NormalizePhone(r.Phone); err != nil {
+		return err
+	}
+	if r.DateOfBirth, err = normalizeDateOfBirth(r.DateOfBirth); err != nil {
+		return err
+	}
+	if r.Address != nil {
+		if err := r.Address.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.PreferredLanguage, err = normalizeLanguage(r.PreferredLanguage); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *UpdateUserRequest) Validate() (err error) {
+	if r.Name == nil && r.Email == nil && r.Phone == nil && r.DateOfBirth == nil &&
+		r.Address == nil && r.PreferredLanguage == nil {
+		return errors.New("nothing to update")
+	}
+
+	if r.Name != nil {
+		if *r.Name, err = normalizeName(*r.Name); err != nil {
+			return err
+		}
+	}
+	if r.Email != nil {
+		if *r.Email, err = NormalizeEmail(*r.Email); err != nil {
+			return err
+		}
+	}
+	if r.Phone != nil {
+		if *r.Phone, err = NormalizePhone(*r.Phone); err != nil {
+			return err
+		}
+	}
+	if r.DateOfBirth != nil {
+		if *r.DateOfBirth, err = normalizeDateOfBirth(*r.DateOfBirth); err != nil {
+			return err
+		}
+	}
+	if r.Address != nil {
+		if err := r.Address.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.PreferredLanguage != nil {
+		if *r.PreferredLanguage, err = normalizeLanguage(*r.PreferredLanguage); err != nil {
+			return err
+		}
+	}
+
+	r.Reason = strings.TrimSpace(r.Reason)
+	if len(r.Reason) > maxReasonLength {
+		return fmt.Errorf("reason exceeds %d characters", maxReasonLength)
+	}
+
+	return nil
+}
+
+func (r *UpdateUserStatusRequest) Validate() error {
+	if _, ok := enums.ParseUserStatus(r.Status); !ok {
+		return fmt.Errorf("invalid status %q", r.Status)
+	}
+	if r.KYCLevel != "" {
+		if _, ok := enums.ParseKYCLevel(r.KYCLevel); !ok {
+			return fmt.Errorf("invalid kyc_level %q", r.KYCLevel)
+		}
+	}
+
+	r.Reason = strings.TrimSpace(r.Reason)
+	if r.Reason == "" {
+		return errors.New("missing reason")
+	}
+	if len(r.Reason) > maxReasonLength {
+		return fmt.Errorf("reason exceeds %d characters", maxReasonLength)
+	}
+
+	return nil
+}
+
+func (r *EraseUserRequest) Validate() error {
+	r.Reason = strings.TrimSpace(r.Reason)
+	if r.Reason == "" {
+		return errors.New("missing reason")
+	}
+	if len(r.Reason) > maxReasonLength {
+		return fmt.Errorf("reason exceeds %d characters", maxReasonLength)
+	}
+
+	return nil
+}
+
+// Validate trims the address in place. An address with every field empty is
+// valid and clears the stored address.
+func (a *Address) Validate() error {
+	a.Line1 = strings.TrimSpace(a.Line1)
+	a.Line2 = strings.TrimSpace(a.Line2)
+	a.City = strings.TrimSpace(a.City)
+	a.PostalCode = strings.TrimSpace(a.PostalCode)
+	a.Country = strings.ToUpper(strings.TrimSpace(a.Country))
+
+	if *a == (Address{}) {
+		return nil
+	}
+	if a.Line1 == "" {
+		return errors.New("missing address.line1")
+	}
+	if a.City == "" {
+		return errors.New("missing address.city")
+	}
+	if len(a.Line1) > maxAddressLineLength || len(a.Line2) > maxAddressLineLength {
+		return fmt.Errorf("address lines must not exceed %d characters", maxAddressLineLength)
+	}
+	if len(a.City) > maxCityLength {
+		return fmt.Errorf("address.city exceeds %d characters", maxCityLength)
+	}
+	if len(a.PostalCode) > maxPostalCodeLength {
+		return fmt.Errorf("address.postal_code exceeds %d characters", maxPostalCodeLength)
+	}
+	if !isCountryCode(a.Country) {
+		return fmt.Errorf("invalid address.country %q, expected an ISO 3166-1 alpha-2 code", a.Country)
+	}
+
+	return nil
+}
+
+// NormalizeEmail lower-cases and validates an email address. An empty string
+// is returned unchanged.
+func NormalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return "", nil
+	}
+	if len(email) > maxEmailLength {
+		return "", fmt.Errorf("email exceeds %d characters", maxEmailLength)
+	}
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", fmt.Errorf("invalid email %q", email)
+	}
+
+	return email, nil
+}
+
+// NormalizePhone validates a phone number in E.164 format. Spaces, dashes and
+// parentheses are stripped first. An empty string is returned unchanged.
+func NormalizePhone(phone string) (string, error) {
+	phone = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(strings.TrimSpace(phone))
+	if phone == "" {
+		return "", nil
+	}
+	if !e164Regexp.MatchString(phone) {
+		return "", fmt.Errorf("invalid phone %q, expected E.164 format such as +14155552671", phone)
+	}
+
+	return phone, nil
+}
+
+func normalizeName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("missing name")
+	}
+	if len(name) > maxNameLength {
+		return "", fmt.Errorf("name exceeds %d characters", maxNameLength)
+	}
+
+	return name, nil
+}
+
+func normalizeDateOfBirth(date string) (string, error) {
+	date = strings.TrimSpace(date)
+	if date == "" {
+		return "", nil
+	}
+
+	dob, err := time.Parse(DateLayout, date)
+	if err != nil {
+		return "", fmt.Errorf("invalid date_of_birth %q, expected YYYY-MM-DD", date)
+	}
+	now := time.Now()
+	if dob.After(now) {
+		return "", errors.New("date_of_birth is in the future")
+	}
+	if dob.Before(now.AddDate(-maxAgeYears, 0, 0)) {
+		return "", errors.New("date_of_birth is too far in the past")
+	}
+
+	return date, nil
+}
+
+func normalizeLanguage(lang string) (string, error) {
+	lang = strings.TrimSpace(lang)
+	if lang == "" {
+		return "", nil
+	}
+	if len(lang) > maxLanguageTagLength {
+		return "", fmt.Errorf("preferred_language exceeds %d characters", maxLanguageTagLength)
+	}
+
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return "", fmt.Errorf("invalid preferred_language %q, expected a BCP 47 tag such as en-US", lang)
+	}
+
+	return tag.String(), nil
+}
+
+func isCountryCode(s string) bool {
+	if len(s) != 2 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
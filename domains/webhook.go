@@ -0,0 +1,210 @@
+package domains
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"banking-service/enums"
+)
+
+const (
+	maxWebhookURLLength     = 2048
+	minWebhookSecretLength  = 16
+	maxWebhookSecretLength  = 128
+	maxWebhookAccountFilter = 100
+)
+
+type (
+	CreateWebhookSubscriptionRequest struct {
+		URL string `json:"url"`
+		// Secret signs the deliveries; one is generated when empty.
+		Secret     string   `json:"secret,omitempty"`
+		EventTypes []string `json:"event_types,omitempty"`
+		AccountIDs []string `json:"account_ids,omitempty"`
+		// UserID restricts the subscription to the accounts of a user. It is
+		// set to the caller for customers.
+		UserID string `json:"user_id,omitempty"`
+	}
+
+	UpdateWebhookSubscriptionRequest struct {
+		URL        *string   `json:"url,omitempty"`
+		Secret     *string   `json:"secret,omitempty"`
+		EventTypes *[]string `json:"event_types,omitempty"`
+		AccountIDs *[]string `json:"account_ids,omitempty"`
+		Active     *bool     `json:"active,omitempty"`
+	}
+
+	WebhookSubscription struct {
+		SubscriptionID string    `json:"subscription_id"`
+		UserID         string    `json:"user_id,omitempty"`
+		URL            string    `json:"url"`
+		EventTypes     []string  `json:"event_types"`
+		AccountIDs     []string  `json:"account_ids"`
+		Active         bool      `json:"active"`
+		CreatedBy      string    `json:"created_by"`
+		CreatedAt      time.Time `json:"created_at"`
+		UpdatedAt      time.Time `json:"updated_at"`
+	}
+
+	// CreateWebhookSubscriptionResponse is the only time the secret is
+	// returned.
+	CreateWebhookSubscriptionResponse struct {
+		*WebhookSubscription
+		Secret string `json:"secret"`
+	}
+
+	GetWebhookSubscriptionsResponse struct {
+		Subscriptions []*WebhookSubscription `json:"subscriptions"`
+		NextCursor    string                 `json:"next_cursor"`
+	}
+
+	WebhookDeliveryAttempt struct {
+		Attempt      int       `json:"attempt"`
+		StatusCode   int       `json:"status_code,omitempty"`
+		ResponseBody string    `json:"response_body,omitempty"`
+		Error        string    `json:"error,omitempty"`
+		DurationMs   int64     `json:"duration_ms"`
+		CreatedAt    time.Time `json:"created_at"`
+	}
+
+	WebhookDelivery struct {
+		DeliveryID     string                    `json:"delivery_id"`
+		SubscriptionID string                    `json:"subscription_id"`
+		EventID        string                    `json:"event_id"`
+		EventType      string                    `json:"event_type"`
+		Status         string                    `json:"status"`
+		Attempts       int                       `json:"attempts"`
+		NextAttemptAt  time.Time                 `json:"next_attempt_at"`
+		LastError      string                    `json:"last_error,omitempty"`
+		DeliveredAt    *time.Time                `json:"delivered_at,omitempty"`
+		CreatedAt      time.Time                 `json:"created_at"`
+		UpdatedAt      time.Time                 `json:"updated_at"`
+		AttemptLog     []*WebhookDeliveryAttempt `json:"attempt_log,omitempty"`
+	}
+
+	GetWebhookDeliveriesResponse struct {
+		Deliveries []*WebhookDelivery `json:"deliveries"`
+		NextCursor string             `json:"next_cursor"`
+	}
+)
+
+func (r *CreateWebhookSubscriptionRequest) Validate() (err error) {
+	if r.URL, err = normalizeWebhookURL(r.URL); err != nil {
+		return err
+	}
+	if r.Secret != "" {
+		if err := validateWebhookSecret(r.Secret); err != nil {
+			return err
+		}
+	}
+	if r.EventTypes, err = normalizeEventTypes(r.EventTypes); err != nil {
+		return err
+	}
+	if r.AccountIDs, err = normalizeAccountFilter(r.AccountIDs); err != nil {
+		return err
+	}
+	r.UserID = strings.TrimSpace(r.UserID)
+
+	return nil
+}
+
+func (r *UpdateWebhookSubscriptionRequest) Validate() (err error) {
+	if r.URL == nil && r.Secret == nil && r.EventTypes == nil && r.AccountIDs == nil && r.Active == nil {
+		return errors.New("nothing to update")
+	}
+
+	if r.URL != nil {
+		if *r.URL, err = normalizeWebhookURL(*r.URL); err != nil {
+			return err
+		}
+	}
+	if r.Secret != nil {
+		if err := validateWebhookSecret(*r.Secret); err != nil {
+			return err
+		}
+	}
+	if r.EventTypes != nil {
+		if *r.EventTypes, err = normalizeEventTypes(*r.EventTypes); err != nil {
+			return err
+		}
+	}
+	if r.AccountIDs != nil {
+		if *r.AccountIDs, err = normalizeAccountFilter(*r.AccountIDs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func normalizeWebhookURL(rawURL string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return "", errors.New("missing url")
+	}
+	if len(rawURL) > maxWebhookURLLength {
+		return "", fmt.Errorf("url exceeds %d characters", maxWebhookURLLength)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "", fmt.Errorf("invalid url %q, expected an absolute http or https URL", rawURL)
+	}
+	if u.User != nil {
+		return "", errors.New("url must not contain credentials")
+	}
+
+	return u.String(), nil
+}
+
+func validateWebhookSecret(secret string) error {
+	if len(secret) < minWebhookSecretLength || len(secret) > maxWebhookSecretLength {
+		return fmt.Errorf("secret must be between %d and %d characters", minWebhookSecretLength, maxWebhookSecretLength)
+	}
+
+	return nil
+}
+
+// normalizeEventTypes dedupes the event types; an empty list means all.
+func normalizeEventTypes(eventTypes []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(eventTypes))
+	normalized := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		if t, ok := enums.ParseEventType(eventType); !ok || !t.IsAccountEvent() {
+			return nil, fmt.Errorf("invalid event type %q", eventType)
+		}
+		if _, ok := seen[eventType]; ok {
+			continue
+		}
+		seen[eventType] = struct{}{}
+		normalized = append(normalized, eventType)
+	}
+
+	return normalized, nil
+}
+
+// normalizeAccountFilter dedupes the account IDs; an empty list means all.
+func normalizeAccountFilter(accountIDs []string) ([]string, error) {
+	if len(accountIDs) > maxWebhookAccountFilter {
+		return nil, fmt.Errorf("at most %d account_ids are allowed", maxWebhookAccountFilter)
+	}
+
+	seen := make(map[string]struct{}, len(accountIDs))
+	normalized := make([]string, 0, len(accountIDs))
+	for _, accountID := range accountIDs {
+		accountID = strings.TrimSpace(accountID)
+		if accountID == "" {
+			return nil, errors.New("account_ids must not be empty")
+		}
+		if _, ok := seen[accountID]; ok {
+			continue
+		}
+		seen[accountID] = struct{}{}
+		normalized = append(normalized, accountID)
+	}
+
+	return normalized, nil
+}
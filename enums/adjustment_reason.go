@@ -0,0 +1,35 @@
+package enums
+
+type AdjustmentReason int64
+
+const (
+	CorrectionAdjustment AdjustmentReason = iota + 1
+	FeeAdjustment
+	RefundAdjustment
+	ChargebackAdjustment
+	GoodwillAdjustment
+	WriteOffAdjustment
+)
+
+var AdjustmentReasonMap = map[AdjustmentReason]string{
+	CorrectionAdjustment: "Correction",
+	FeeAdjustment:        "Fee",
+	RefundAdjustment:     "Refund",
+	ChargebackAdjustment: "Chargeback",
+	GoodwillAdjustment:   "Goodwill",
+	WriteOffAdjustment:   "WriteOff",
+}
+
+func (r AdjustmentReason) String() string {
+	return AdjustmentReasonMap[r]
+}
+
+func ParseAdjustmentReason(s string) (AdjustmentReason, bool) {
+	for reason, name := range AdjustmentReasonMap {
+		if name == s {
+			return reason, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,47 @@
+package enums
+
+type ApprovalType int64
+
+const (
+	TransferApproval ApprovalType = iota + 1
+	AdjustmentApproval
+)
+
+var ApprovalTypeMap = map[ApprovalType]string{
+	TransferApproval:   "Transfer",
+	AdjustmentApproval: "Adjustment",
+}
+
+func (t ApprovalType) String() string {
+	return ApprovalTypeMap[t]
+}
+
+type ApprovalStatus int64
+
+const (
+	ApprovalPending ApprovalStatus = iota + 1
+	ApprovalApproved
+	ApprovalRejected
+	ApprovalExpired
+)
+
+var ApprovalStatusMap = map[ApprovalStatus]string{
+	ApprovalPending:  "Pending",
+	ApprovalApproved: "Approved",
+	ApprovalRejected: "Rejected",
+	ApprovalExpired:  "Expired",
+}
+
+func (s ApprovalStatus) String() string {
+	return ApprovalStatusMap[s]
+}
+
+func ParseApprovalStatus(s string) (ApprovalStatus, bool) {
+	for status, name := range ApprovalStatusMap {
+		if name == s {
+			return status, true
+		}
+	}
+
+	return 0, false
+}
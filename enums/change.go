@@ -0,0 +1,31 @@
+package enums
+
+// ChangeEntityType is the kind of row a change feed entry refers to. The
+// names are written by the record_change database trigger.
+type ChangeEntityType int64
+
+const (
+	UserEntity ChangeEntityType = iota + 1
+	AccountEntity
+	TransactionEntity
+)
+
+var ChangeEntityTypeMap = map[ChangeEntityType]string{
+	UserEntity:        "User",
+	AccountEntity:     "Account",
+	TransactionEntity: "Transaction",
+}
+
+func (t ChangeEntityType) String() string {
+	return ChangeEntityTypeMap[t]
+}
+
+func ParseChangeEntityType(s string) (ChangeEntityType, bool) {
+	for entityType, name := range ChangeEntityTypeMap {
+		if name == s {
+			return entityType, true
+		}
+	}
+
+	return 0, false
+}
@@ -7,4 +7,8 @@ const (
 
 	BadRequest ErrorCode = iota + 1
 	InternalError
+	NotFound
+	Conflict
+	Unauthorized
+	Forbidden
 )
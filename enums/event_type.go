@@ -0,0 +1,49 @@
+package enums
+
+type EventType int64
+
+const (
+	AccountCreated EventType = iota + 1
+	AccountClosed
+	FundsDeposited
+	FundsWithdrawn
+	TransferCompleted
+	AdjustmentPosted
+	ChatMessageSent
+	ChatMessageRead
+	AccountFrozen
+	AccountUnfrozen
+)
+
+var EventTypeMap = map[EventType]string{
+	AccountCreated:    "AccountCreated",
+	AccountClosed:     "AccountClosed",
+	FundsDeposited:    "FundsDeposited",
+	FundsWithdrawn:    "FundsWithdrawn",
+	TransferCompleted: "TransferCompleted",
+	AdjustmentPosted:  "AdjustmentPosted",
+	ChatMessageSent:   "ChatMessageSent",
+	ChatMessageRead:   "ChatMessageRead",
+	AccountFrozen:     "AccountFrozen",
+	AccountUnfrozen:   "AccountUnfrozen",
+}
+
+func (t EventType) String() string {
+	return EventTypeMap[t]
+}
+
+// IsAccountEvent reports whether the event is about an account, as opposed
+// to support chat events.
+func (t EventType) IsAccountEvent() bool {
+	return t != ChatMessageSent && t != ChatMessageRead
+}
+
+func ParseEventType(s string) (EventType, bool) {
+	for eventType, name := range EventTypeMap {
+		if name == s {
+			return eventType, true
+		}
+	}
+
+	return 0, false
+}
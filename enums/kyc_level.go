@@ -0,0 +1,29 @@
+package enums
+
+type KYCLevel int64
+
+const (
+	KYCNone KYCLevel = iota + 1
+	KYCBasic
+	KYCFull
+)
+
+var KYCLevelMap = map[KYCLevel]string{
+	KYCNone:  "None",
+	KYCBasic: "Basic",
+	KYCFull:  "Full",
+}
+
+func (l KYCLevel) String() string {
+	return KYCLevelMap[l]
+}
+
+func ParseKYCLevel(s string) (KYCLevel, bool) {
+	for level, name := range KYCLevelMap {
+		if name == s {
+			return level, true
+		}
+	}
+
+	return 0, false
+}
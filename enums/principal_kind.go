@@ -0,0 +1,20 @@
+package enums
+
+type PrincipalKind int64
+
+const (
+	APIKeyPrincipal PrincipalKind = iota + 1
+	UserPrincipal
+	// CLIPrincipal is an operator running bankctl with database access.
+	CLIPrincipal
+)
+
+var PrincipalKindMap = map[PrincipalKind]string{
+	APIKeyPrincipal: "APIKey",
+	UserPrincipal:   "User",
+	CLIPrincipal:    "CLI",
+}
+
+func (k PrincipalKind) String() string {
+	return PrincipalKindMap[k]
+}
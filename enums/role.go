@@ -0,0 +1,31 @@
+package enums
+
+type Role int64
+
+const (
+	Customer Role = iota + 1
+	Operator
+	Auditor
+	Admin
+)
+
+var RoleMap = map[Role]string{
+	Customer: "Customer",
+	Operator: "Operator",
+	Auditor:  "Auditor",
+	Admin:    "Admin",
+}
+
+func (r Role) String() string {
+	return RoleMap[r]
+}
+
+func ParseRole(s string) (Role, bool) {
+	for role, name := range RoleMap {
+		if name == s {
+			return role, true
+		}
+	}
+
+	return 0, false
+}
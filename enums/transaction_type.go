@@ -6,12 +6,14 @@ const (
 	Deposit TransactionType = iota + 1
 	Withdrawal
 	Transfer
+	Adjustment
 )
 
 var TransactionTypeMap = map[TransactionType]string{
 	Deposit:    "Deposit",
 	Withdrawal: "Withdrawal",
 	Transfer:   "Transfer",
+	Adjustment: "Adjustment",
 }
 
 func (tt TransactionType) String() string {
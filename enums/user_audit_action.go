@@ -0,0 +1,19 @@
+package enums
+
+type UserAuditAction int64
+
+const (
+	UserUpdated UserAuditAction = iota + 1
+	UserErased
+	UserStatusChanged
+)
+
+var UserAuditActionMap = map[UserAuditAction]string{
+	UserUpdated:       "Updated",
+	UserErased:        "Erased",
+	UserStatusChanged: "StatusChanged",
+}
+
+func (a UserAuditAction) String() string {
+	return UserAuditActionMap[a]
+}
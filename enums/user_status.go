@@ -0,0 +1,48 @@
+package enums
+
+type UserStatus int64
+
+const (
+	UserPending UserStatus = iota + 1
+	UserVerified
+	UserSuspended
+	UserClosed
+)
+
+var UserStatusMap = map[UserStatus]string{
+	UserPending:   "Pending",
+	UserVerified:  "Verified",
+	UserSuspended: "Suspended",
+	UserClosed:    "Closed",
+}
+
+// userStatusTransitions lists the statuses each status may move to. Closed is final.
+var userStatusTransitions = map[UserStatus][]UserStatus{
+	UserPending:   {UserVerified, UserClosed},
+	UserVerified:  {UserSuspended, UserClosed},
+	UserSuspended: {UserVerified, UserClosed},
+}
+
+func (s UserStatus) String() string {
+	return UserStatusMap[s]
+}
+
+func (s UserStatus) CanTransitionTo(to UserStatus) bool {
+	for _, next := range userStatusTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+func ParseUserStatus(s string) (UserStatus, bool) {
+	for status, name := range UserStatusMap {
+		if name == s {
+			return status, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,31 @@
+package enums
+
+type WebhookDeliveryStatus int64
+
+const (
+	WebhookPending WebhookDeliveryStatus = iota + 1
+	WebhookSucceeded
+	// WebhookDeadLetter deliveries ran out of attempts and are only retried
+	// through the redeliver endpoint.
+	WebhookDeadLetter
+)
+
+var WebhookDeliveryStatusMap = map[WebhookDeliveryStatus]string{
+	WebhookPending:    "Pending",
+	WebhookSucceeded:  "Succeeded",
+	WebhookDeadLetter: "DeadLetter",
+}
+
+func (s WebhookDeliveryStatus) String() string {
+	return WebhookDeliveryStatusMap[s]
+}
+
+func ParseWebhookDeliveryStatus(s string) (WebhookDeliveryStatus, bool) {
+	for status, name := range WebhookDeliveryStatusMap {
+		if name == s {
+			return status, true
+		}
+	}
+
+	return 0, false
+}
@@ -1,20 +1,24 @@
 package handlers
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
 
 	"banking-service/domains"
 	"banking-service/enums"
+	"banking-service/metrics"
+	"banking-service/middlewares"
 	"banking-service/models"
 	"banking-service/repositories"
 	"banking-service/utilities"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
@@ -31,16 +35,36 @@ type AccountHandlers interface {
 	DepositAccountHandler(*gin.Context)
 	WithdrawAccountHandler(*gin.Context)
 	TransferAmountHandler(*gin.Context)
+	AdjustAccountHandler(*gin.Context)
 }
 
 type AccountHandlersDeps struct {
 	DB          *gorm.DB
+	Logger      *zap.Logger
 	IDGenerator utilities.SnowflakeIDGenerator
+	Authorizer  *middlewares.Authorizer
+	// ApprovalThreshold is the amount above which transfers and adjustments
+	// wait for a second person; ApprovalTTL is how long they may wait.
+	ApprovalThreshold float64
+	ApprovalTTL       time.Duration
+	// ReadDB is a read replica for the account list, used while Replica
+	// reports it fresh enough for the request. The list reads from DB when
+	// it is nil; writes always go to DB.
+	ReadDB  *gorm.DB
+	Replica ReplicaStatus
 }
 
 type accountHandlers struct {
 	db                    *gorm.DB
+	logger                *zap.Logger
+	reads                 *readRouter
 	idGenerator           utilities.SnowflakeIDGenerator
+	authorizer            *middlewares.Authorizer
+	approvalThreshold     float64
+	moneyMovement         *moneyMovement
+	approvalRequester     *approvalRequester
+	events                *eventEmitter
+	userRepository        repositories.UserRepositoryI
 	accountRepository     repositories.AccountRepositoryI
 	transactionRepository repositories.TransactionRepositoryI
 }
@@ -52,19 +76,29 @@ func NewAccountHandlers(deps *AccountHandlersDeps) AccountHandlers {
 
 	return &accountHandlers{
 		db:                    deps.DB,
+		logger:                deps.Logger,
+		reads:                 newReadRouter(deps.DB, deps.ReadDB, deps.Replica, deps.Logger),
 		idGenerator:           deps.IDGenerator,
+		authorizer:            deps.Authorizer,
+		approvalThreshold:     deps.ApprovalThreshold,
+		moneyMovement:         newMoneyMovement(deps.IDGenerator),
+		approvalRequester:     newApprovalRequester(deps.DB, deps.IDGenerator, deps.ApprovalTTL),
+		events:                newEventEmitter(deps.IDGenerator),
+		userRepository:        repositories.NewUserRepository(),
 		accountRepository:     repositories.NewAccountRepository(),
 		transactionRepository: repositories.NewTransactionRepository(),
 	}
 }
 
 func (u *accountHandlers) RouteGroup(rg *gin.Engine) {
-	rg.POST("/accounts", u.CreateAccountHandler)
-	rg.GET("/accounts", u.GetAccountsHandler)
-	rg.GET("/accounts/:accountID", u.GetAccountHandler)
-	rg.POST("/accounts/:accountID/deposit", u.DepositAccountHandler)
-	rg.POST("/accounts/:accountID/withdraw", u.WithdrawAccountHandler)
-	rg.POST("/accounts/:accountID/transfer", u.TransferAmountHandler)
+	// customers may only create accounts for themselves, checked in the handler
+	rg.POST("/accounts", u.authorizer.Roles(enums.Customer, enums.Operator, enums.Admin), u.CreateAccountHandler)
+	rg.GET("/accounts", u.authorizer.Roles(middlewares.ReadRoles...), u.GetAccountsHandler)
+	rg.GET("/accounts/:accountID", u.authorizer.AccountOwnerOr(middlewares.ReadRoles...), u.GetAccountHandler)
+	rg.POST("/accounts/:accountID/deposit", u.authorizer.AccountOwnerOr(middlewares.StaffRoles...), u.DepositAccountHandler)
+	rg.POST("/accounts/:accountID/withdraw", u.authorizer.AccountOwnerOr(middlewares.StaffRoles...), u.WithdrawAccountHandler)
+	rg.POST("/accounts/:accountID/transfer", u.authorizer.AccountOwnerOr(middlewares.StaffRoles...), u.TransferAmountHandler)
+	rg.POST("/accounts/:accountID/adjustments", u.authorizer.Roles(middlewares.StaffRoles...), u.AdjustAccountHandler)
 }
 
 func (u *accountHandlers) CreateAccountHandler(c *gin.Context) {
@@ -72,42 +106,72 @@ func (u *accountHandlers) CreateAccountHandler(c *gin.Context) {
 
 	var req domains.CreateAccountRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, domains.ErrorResp{
-			Message: err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
 		return
 	}
 	if err := req.Validate(); err != nil {
-		c.JSON(http.StatusBadRequest, domains.ErrorResp{
-			Message: err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	account, err := u.createAccount(ctx, domains.GetPrincipal(c), &req)
+	if err != nil {
+		err.(domains.XError).Response(c)
 		return
 	}
+	u.reads.markWrite(c)
+
+	c.JSON(http.StatusOK, toAccountResp(account))
+}
+
+// createAccount opens an account for req.UserID. Customers may only open
+// accounts for themselves. It returns domains.XError.
+func (u *accountHandlers) createAccount(ctx context.Context, principal *domains.Principal, req *domains.CreateAccountRequest) (*models.Account, error) {
+	if !middlewares.CanActOnUser(principal, req.UserID, middlewares.StaffRoles...) {
+		return nil, domains.NewXError(errors.New("forbidden"), enums.Forbidden)
+	}
+
+	owner, err := u.userRepository.GetUser(ctx, u.db, &repositories.GetUserArgs{
+		UserID: req.UserID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domains.NewXError(fmt.Errorf("user_id %s not found", req.UserID), enums.BadRequest)
+		}
+		return nil, domains.NewXError(err, enums.InternalError)
+	}
+	if owner.Status == enums.UserClosed.String() || owner.Status == enums.UserSuspended.String() {
+		return nil, domains.NewXError(fmt.Errorf("user_id %s is %s", req.UserID, owner.Status), enums.BadRequest)
+	}
 
 	account := &models.Account{
 		AccountID: u.idGenerator.Next().String(),
 		UserID:    req.UserID,
 		Name:      req.Name,
+		Currency:  req.Currency,
 		Balance:   0,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	if err := u.accountRepository.Create(ctx, u.db, account); err != nil {
-		c.JSON(http.StatusInternalServerError, domains.ErrorResp{
-			Message: err.Error(),
+	err = auditedTransaction(ctx, u.db, http.StatusOK, func(tx *gorm.DB) error {
+		if err := u.accountRepository.Create(ctx, tx, account); err != nil {
+			return domains.NewXError(err, enums.InternalError)
+		}
+		recordAccountChange(ctx, account.AccountID, nil, account)
+
+		return u.events.emit(ctx, tx, enums.AccountCreated, account.AccountID, &domains.AccountCreatedEvent{
+			AccountID: account.AccountID,
+			UserID:    account.UserID,
+			Name:      account.Name,
+			Currency:  account.Currency,
 		})
-		return
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	c.JSON(http.StatusOK, domains.Account{
-		AccountID: account.AccountID,
-		UserID:    account.UserID,
-		Name:      account.Name,
-		Balance:   account.Balance,
-		CreatedAt: account.CreatedAt,
-		UpdatedAt: account.UpdatedAt,
-	})
+	return account, nil
 }
 
 func (u *accountHandlers) GetAccountsHandler(c *gin.Context) {
@@ -115,8 +179,6 @@ func (u *accountHandlers) GetAccountsHandler(c *gin.Context) {
 	limitStr := c.Query("limit")
 	cursorStr := c.Query("cursor")
 
-	fmt.Println("limit: " + limitStr)
-
 	var (
 		limit int
 		err   error
@@ -124,35 +186,24 @@ func (u *accountHandlers) GetAccountsHandler(c *gin.Context) {
 	if limitStr != "" {
 		limit, err = strconv.Atoi(limitStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, domains.ErrorResp{
-				Message: err.Error(),
-			})
+			c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
 			return
 		}
 	}
 
-	accounts, err := u.accountRepository.GetAccounts(ctx, u.db, &repositories.GetAccountsArgs{
+	accounts, err := u.accountRepository.GetAccounts(ctx, u.reads.db(c), &repositories.GetAccountsArgs{
 		Cursor: cursorStr,
 		Limit:  limit,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domains.ErrorResp{
-			Message: err.Error(),
-		})
+		domains.NewXError(err, enums.InternalError).Response(c)
 		return
 	}
 
 	accountsResp := make([]*domains.Account, 0, len(accounts))
 
 	for _, account := range accounts {
-		accountsResp = append(accountsResp, &domains.Account{
-			AccountID: account.AccountID,
-			UserID:    account.UserID,
-			Name:      account.Name,
-			Balance:   account.Balance,
-			CreatedAt: account.CreatedAt,
-			UpdatedAt: account.UpdatedAt,
-		})
+		accountsResp = append(accountsResp, toAccountResp(account))
 	}
 
 	var nextCursor string
@@ -166,33 +217,28 @@ func (u *accountHandlers) GetAccountsHandler(c *gin.Context) {
 }
 
 func (u *accountHandlers) GetAccountHandler(c *gin.Context) {
-	ctx := c.Request.Context()
-	accountID := c.Param("accountID")
+	account, err := u.getAccount(c.Request.Context(), c.Param("accountID"))
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
 
+	c.JSON(http.StatusOK, toAccountResp(account))
+}
+
+// getAccount returns domains.XError.
+func (u *accountHandlers) getAccount(ctx context.Context, accountID string) (*models.Account, error) {
 	account, err := u.accountRepository.GetAccount(ctx, u.db, &repositories.GetAccountArgs{
 		AccountID: accountID,
 	})
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusInternalServerError, domains.ErrorResp{
-				Message: fmt.Sprintf("account_id %s not found", accountID),
-			})
-			return
+			return nil, domains.NewXError(fmt.Errorf("account_id %s not found", accountID), enums.NotFound)
 		}
-		c.JSON(http.StatusInternalServerError, domains.ErrorResp{
-			Message: err.Error(),
-		})
-		return
+		return nil, domains.NewXError(err, enums.InternalError)
 	}
 
-	c.JSON(http.StatusOK, &domains.Account{
-		AccountID: account.AccountID,
-		UserID:    account.UserID,
-		Name:      account.Name,
-		Balance:   account.Balance,
-		CreatedAt: account.CreatedAt,
-		UpdatedAt: account.UpdatedAt,
-	})
+	return account, nil
 }
 
 func (u *accountHandlers) DepositAccountHandler(c *gin.Context) {
@@ -204,59 +250,25 @@ func (u *accountHandlers) DepositAccountHandler(c *gin.Context) {
 		transactionID string
 	)
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, domains.ErrorResp{
-			Message: err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
 		return
 	}
 
 	if err := req.Validate(); err != nil {
-		c.JSON(http.StatusBadRequest, domains.ErrorResp{
-			Message: err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
 		return
 	}
 
-	err := u.db.Transaction(func(tx *gorm.DB) error {
-		account, err := u.accountRepository.GetAccount(ctx, tx, &repositories.GetAccountArgs{
-			AccountID: accountID,
-			ForUpdate: true,
-		})
-		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return domains.NewXError(fmt.Errorf("account_id %s not found", accountID), enums.BadRequest)
-			}
-			return domains.NewXError(err, enums.InternalError)
-		}
-
-		account.Balance += req.Amount
-		if err := u.accountRepository.Update(ctx, tx, account); err != nil {
-			return domains.NewXError(err, enums.InternalError)
-		}
-
-		transactionID = u.idGenerator.Next().String()
-		transaction := &models.Transaction{
-			TransactionID: transactionID,
-			UserID:        account.UserID,
-			AccountID:     account.AccountID,
-			Amount:        req.Amount,
-			Balance:       account.Balance,
-			Type:          enums.Deposit.String(),
-			Status:        enums.Completed.String(),
-			Metadata:      "{}",
-			CreatedAt:     time.Now(),
-			UpdatedAt:     time.Now(),
-		}
-		if err := u.transactionRepository.Create(ctx, tx, transaction); err != nil {
-			return domains.NewXError(err, enums.InternalError)
-		}
-
-		return nil
+	err := auditedTransaction(ctx, u.db, http.StatusOK, func(tx *gorm.DB) (err error) {
+		transactionID, err = u.moneyMovement.deposit(ctx, tx, accountID, &req)
+		return err
 	})
+	observeMoneyMovement(enums.Deposit, req.Amount, err)
 	if err != nil {
 		err.(domains.XError).Response(c)
 		return
 	}
+	u.reads.markWrite(c)
 
 	c.JSON(http.StatusOK, &domains.DepositAccountResponse{
 		TransactionID: transactionID,
@@ -272,63 +284,25 @@ func (u *accountHandlers) WithdrawAccountHandler(c *gin.Context) {
 		transactionID string
 	)
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, domains.ErrorResp{
-			Message: err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
 		return
 	}
 
 	if err := req.Validate(); err != nil {
-		c.JSON(http.StatusBadRequest, domains.ErrorResp{
-			Message: err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
 		return
 	}
 
-	err := u.db.Transaction(func(tx *gorm.DB) error {
-		account, err := u.accountRepository.GetAccount(ctx, tx, &repositories.GetAccountArgs{
-			AccountID: accountID,
-			ForUpdate: true,
-		})
-		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return domains.NewXError(fmt.Errorf("account_id %s not found", accountID), enums.BadRequest)
-			}
-			return domains.NewXError(err, enums.InternalError)
-		}
-
-		if account.Balance-req.Amount < 0 {
-			return domains.NewXError(errors.New("insufficient balance"), enums.BadRequest)
-		}
-
-		account.Balance = account.Balance - req.Amount
-		if err := u.accountRepository.Update(ctx, tx, account); err != nil {
-			return domains.NewXError(err, enums.InternalError)
-		}
-
-		transactionID = u.idGenerator.Next().String()
-		transaction := &models.Transaction{
-			TransactionID: transactionID,
-			UserID:        account.UserID,
-			AccountID:     account.AccountID,
-			Amount:        -req.Amount,
-			Balance:       account.Balance,
-			Type:          enums.Withdrawal.String(),
-			Status:        enums.Completed.String(),
-			Metadata:      "{}",
-			CreatedAt:     time.Now(),
-			UpdatedAt:     time.Now(),
-		}
-		if err := u.transactionRepository.Create(ctx, tx, transaction); err != nil {
-			return domains.NewXError(err, enums.InternalError)
-		}
-
-		return nil
+	err := auditedTransaction(ctx, u.db, http.StatusOK, func(tx *gorm.DB) (err error) {
+		transactionID, err = u.moneyMovement.withdraw(ctx, tx, accountID, &req)
+		return err
 	})
+	observeMoneyMovement(enums.Withdrawal, req.Amount, err)
 	if err != nil {
 		err.(domains.XError).Response(c)
 		return
 	}
+	u.reads.markWrite(c)
 
 	c.JSON(http.StatusOK, &domains.WithdrawAccountResponse{
 		TransactionID: transactionID,
@@ -339,103 +313,191 @@ func (u *accountHandlers) TransferAmountHandler(c *gin.Context) {
 	ctx := c.Request.Context()
 	accountID := c.Param("accountID")
 
-	var (
-		req           domains.TransferAccountRequest
-		transactionID string
-	)
+	var req domains.TransferAccountRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.Error(err)
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
 		return
 	}
 
-	err := u.db.Transaction(func(tx *gorm.DB) error {
-		account, err := u.accountRepository.GetAccount(ctx, tx, &repositories.GetAccountArgs{
-			AccountID: accountID,
-			ForUpdate: true,
-		})
-		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return domains.NewXError(fmt.Errorf("account_id %s not found", accountID), enums.BadRequest)
-			}
-			return domains.NewXError(err, enums.InternalError)
-		}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
 
-		destinationAccount, err := u.accountRepository.GetAccount(ctx, tx, &repositories.GetAccountArgs{
-			AccountID: req.ToAccountID,
-			ForUpdate: true,
-		})
+	resp, err := u.transfer(ctx, domains.GetPrincipal(c), accountID, &req)
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+	u.reads.markWrite(c)
+
+	if resp.ApprovalID != "" {
+		c.JSON(http.StatusAccepted, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// transfer runs a validated transfer, or requests an approval for it above
+// the approval threshold. It returns domains.XError.
+func (u *accountHandlers) transfer(ctx context.Context, principal *domains.Principal, accountID string, req *domains.TransferAccountRequest) (*domains.TransferAccountResponse, error) {
+	// rejected again when executed; checked here so no approval is requested
+	if req.ToAccountID == accountID {
+		return nil, domains.NewXError(errSelfTransfer, enums.BadRequest)
+	}
+	if req.Amount > u.approvalThreshold {
+		approval, err := u.approvalRequester.request(ctx, principal, enums.TransferApproval, accountID, req.Amount, req)
 		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return domains.NewXError(fmt.Errorf("destination account_id %s not found", req.ToAccountID), enums.BadRequest)
-			}
-			return domains.NewXError(err, enums.InternalError)
+			return nil, err
 		}
+		observeMoneyMovementOutcome(enums.Transfer, metrics.OutcomePendingApproval, req.Amount)
 
-		if account.Balance-req.Amount < 0 {
-			return domains.NewXError(errors.New("insufficient balance"), enums.BadRequest)
-		}
+		return &domains.TransferAccountResponse{
+			ApprovalID: approval.ApprovalID,
+			Status:     approval.Status,
+		}, nil
+	}
 
-		account.Balance = account.Balance - req.Amount
-		if err := u.accountRepository.Update(ctx, tx, account); err != nil {
-			return domains.NewXError(err, enums.InternalError)
-		}
+	var transactionID string
+	err := auditedTransaction(ctx, u.db, http.StatusOK, func(tx *gorm.DB) (err error) {
+		transactionID, err = u.moneyMovement.transfer(ctx, tx, accountID, req, "")
+		return err
+	})
+	observeMoneyMovement(enums.Transfer, req.Amount, err)
+	if err != nil {
+		return nil, err
+	}
 
-		destinationAccount.Balance = destinationAccount.Balance + req.Amount
-		if err := u.accountRepository.Update(ctx, tx, destinationAccount); err != nil {
-			return domains.NewXError(err, enums.InternalError)
-		}
+	return &domains.TransferAccountResponse{
+		TransactionID: transactionID,
+		Status:        enums.Completed.String(),
+	}, nil
+}
 
-		metadata := models.TransactionMetadata{
-			FromAccountID: account.AccountID,
-			ToAccountID:   destinationAccount.AccountID,
-		}
+// AdjustAccountHandler posts a manual credit or debit with a reason code.
+// Adjustments above the approval threshold wait for a second person.
+func (u *accountHandlers) AdjustAccountHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	accountID := c.Param("accountID")
 
-		metadataBytes, err := json.Marshal(metadata)
+	var req domains.AdjustAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	resp, err := u.adjust(ctx, domains.GetPrincipal(c), accountID, &req)
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+	u.reads.markWrite(c)
+
+	if resp.ApprovalID != "" {
+		c.JSON(http.StatusAccepted, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// adjust posts a validated adjustment, or requests an approval for it above
+// the approval threshold. It returns domains.XError.
+func (u *accountHandlers) adjust(ctx context.Context, principal *domains.Principal, accountID string, req *domains.AdjustAccountRequest) (*domains.AdjustAccountResponse, error) {
+	if math.Abs(req.Amount) > u.approvalThreshold {
+		approval, err := u.approvalRequester.request(ctx, principal, enums.AdjustmentApproval, accountID, math.Abs(req.Amount), req)
 		if err != nil {
-			return domains.NewXError(err, enums.InternalError)
+			return nil, err
 		}
+		observeMoneyMovementOutcome(enums.Adjustment, metrics.OutcomePendingApproval, req.Amount)
 
-		transactionID = u.idGenerator.Next().String()
-		transaction := &models.Transaction{
-			TransactionID: transactionID,
-			UserID:        account.UserID,
-			AccountID:     accountID,
-			Amount:        -req.Amount,
-			Balance:       account.Balance,
-			Type:          enums.Transfer.String(),
-			Status:        enums.Completed.String(),
-			Metadata:      string(metadataBytes),
-			CreatedAt:     time.Now(),
-			UpdatedAt:     time.Now(),
-		}
-		if err := u.transactionRepository.Create(ctx, tx, transaction); err != nil {
+		return &domains.AdjustAccountResponse{
+			ApprovalID: approval.ApprovalID,
+			Status:     approval.Status,
+		}, nil
+	}
+
+	var transactionID string
+	err := auditedTransaction(ctx, u.db, http.StatusOK, func(tx *gorm.DB) (err error) {
+		transactionID, err = u.moneyMovement.adjust(ctx, tx, accountID, req, "")
+		return err
+	})
+	observeMoneyMovement(enums.Adjustment, req.Amount, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domains.AdjustAccountResponse{
+		TransactionID: transactionID,
+		Status:        enums.Completed.String(),
+	}, nil
+}
+
+// freeze freezes accountID or, when frozen is false, unfreezes it. It returns
+// domains.XError.
+func (u *accountHandlers) freeze(ctx context.Context, accountID string, frozen bool, reason string) (*models.Account, error) {
+	var account *models.Account
+	err := u.db.Transaction(func(tx *gorm.DB) (err error) {
+		account, err = u.accountRepository.GetAccount(ctx, tx, &repositories.GetAccountArgs{
+			AccountID: accountID,
+			ForUpdate: true,
+		})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domains.NewXError(fmt.Errorf("account_id %s not found", accountID), enums.NotFound)
+			}
 			return domains.NewXError(err, enums.InternalError)
 		}
+		if (account.FrozenAt != nil) == frozen {
+			if frozen {
+				return domains.NewXError(fmt.Errorf("account_id %s is already frozen", accountID), enums.Conflict)
+			}
+			return domains.NewXError(fmt.Errorf("account_id %s is not frozen", accountID), enums.Conflict)
+		}
 
-		transactionDestination := &models.Transaction{
-			TransactionID: u.idGenerator.Next().String(),
-			UserID:        destinationAccount.UserID,
-			AccountID:     destinationAccount.AccountID,
-			Amount:        req.Amount,
-			Balance:       destinationAccount.Balance,
-			Type:          enums.Transfer.String(),
-			Status:        enums.Completed.String(),
-			Metadata:      string(metadataBytes),
-			CreatedAt:     time.Now(),
-			UpdatedAt:     time.Now(),
+		before := toAccountState(account)
+		eventType := enums.AccountUnfrozen
+		account.FrozenAt, account.FreezeReason = nil, ""
+		if frozen {
+			now := time.Now()
+			eventType = enums.AccountFrozen
+			account.FrozenAt, account.FreezeReason = &now, reason
 		}
-		if err := u.transactionRepository.Create(ctx, tx, transactionDestination); err != nil {
+		if err := u.accountRepository.SetFrozen(ctx, tx, &repositories.SetFrozenArgs{
+			AccountID: accountID,
+			FrozenAt:  account.FrozenAt,
+			Reason:    account.FreezeReason,
+		}); err != nil {
 			return domains.NewXError(err, enums.InternalError)
 		}
+		recordAccountChange(ctx, accountID, before, account)
 
-		return nil
+		return u.events.emit(ctx, tx, eventType, accountID, &domains.AccountFreezeEvent{
+			AccountID: accountID,
+			UserID:    account.UserID,
+			Reason:    reason,
+		})
 	})
 	if err != nil {
-		err.(domains.XError).Response(c)
-		return
+		return nil, err
 	}
 
-	c.JSON(http.StatusOK, &domains.TransferAccountResponse{
-		TransactionID: transactionID,
-	})
+	return account, nil
+}
+
+func toAccountResp(account *models.Account) *domains.Account {
+	return &domains.Account{
+		AccountID: account.AccountID,
+		UserID:    account.UserID,
+		Name:      account.Name,
+		Currency:  account.Currency,
+		Balance:   account.Balance,
+		FrozenAt:  account.FrozenAt,
+		CreatedAt: account.CreatedAt,
+		UpdatedAt: account.UpdatedAt,
+	}
 }
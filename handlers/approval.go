@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/middlewares"
+	"banking-service/models"
+	"banking-service/repositories"
+	"banking-service/utilities"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	_ ApprovalHandlers = &approvalHandlers{}
+)
+
+type ApprovalHandlers interface {
+	RouteGroup(r *gin.Engine)
+
+	GetApprovalsHandler(*gin.Context)
+	GetApprovalHandler(*gin.Context)
+	ApproveHandler(*gin.Context)
+	RejectHandler(*gin.Context)
+}
+
+type ApprovalHandlersDeps struct {
+	DB          *gorm.DB
+	Logger      *zap.Logger
+	IDGenerator utilities.SnowflakeIDGenerator
+	Authorizer  *middlewares.Authorizer
+}
+
+type approvalHandlers struct {
+	db                 *gorm.DB
+	logger             *zap.Logger
+	idGenerator        utilities.SnowflakeIDGenerator
+	authorizer         *middlewares.Authorizer
+	moneyMovement      *moneyMovement
+	approvalRepository repositories.ApprovalRepositoryI
+}
+
+func NewApprovalHandlers(deps *ApprovalHandlersDeps) ApprovalHandlers {
+	if deps == nil {
+		return nil
+	}
+
+	return &approvalHandlers{
+		db:                 deps.DB,
+		logger:             deps.Logger,
+		idGenerator:        deps.IDGenerator,
+		authorizer:         deps.Authorizer,
+		moneyMovement:      newMoneyMovement(deps.IDGenerator),
+		approvalRepository: repositories.NewApprovalRepository(),
+	}
+}
+
+func (u *approvalHandlers) RouteGroup(rg *gin.Engine) {
+	rg.GET("/approvals", u.authorizer.Roles(middlewares.ReadRoles...), u.GetApprovalsHandler)
+	rg.GET("/approvals/:approvalID", u.authorizer.Roles(middlewares.ReadRoles...), u.GetApprovalHandler)
+	rg.POST("/approvals/:approvalID/approve", u.authorizer.Roles(middlewares.StaffRoles...), u.ApproveHandler)
+	rg.POST("/approvals/:approvalID/reject", u.authorizer.Roles(middlewares.StaffRoles...), u.RejectHandler)
+}
+
+func (u *approvalHandlers) GetApprovalsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	limitStr := c.Query("limit")
+	cursorStr := c.Query("cursor")
+	status := c.Query("status")
+
+	var (
+		limit int
+		err   error
+	)
+	if limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+			return
+		}
+	}
+	if status != "" {
+		if _, ok := enums.ParseApprovalStatus(status); !ok {
+			c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, fmt.Sprintf("invalid status %q", status)))
+			return
+		}
+	}
+
+	approvals, err := u.approvalRepository.GetApprovals(ctx, u.db, &repositories.GetApprovalsArgs{
+		Status:    status,
+		AccountID: c.Query("account_id"),
+		Cursor:    cursorStr,
+		Limit:     limit,
+	})
+	if err != nil {
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	approvalsResp := make([]*domains.Approval, 0, len(approvals))
+	for _, approval := range approvals {
+		approvalsResp = append(approvalsResp, toApprovalResp(approval))
+	}
+
+	var nextCursor string
+	if len(approvals) != 0 {
+		nextCursor = approvals[len(approvals)-1].ApprovalID
+	}
+	c.JSON(http.StatusOK, &domains.GetApprovalsResponse{
+		Approvals:  approvalsResp,
+		NextCursor: nextCursor,
+	})
+}
+
+func (u *approvalHandlers) GetApprovalHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	approvalID := c.Param("approvalID")
+
+	approval, err := u.approvalRepository.GetApproval(ctx, u.db, &repositories.GetApprovalArgs{
+		ApprovalID: approvalID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, domains.NewErrorResp(c, fmt.Sprintf("approval_id %s not found", approvalID)))
+			return
+		}
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toApprovalResp(approval))
+}
+
+// ApproveHandler executes the stored request through the same code path as
+// the original endpoint. The approver must be a different principal than the
+// requester. If execution fails, for instance on insufficient balance, the
+// approval stays pending.
+func (u *approvalHandlers) ApproveHandler(c *gin.Context) {
+	u.decide(c, enums.ApprovalApproved)
+}
+
+func (u *approvalHandlers) RejectHandler(c *gin.Context) {
+	u.decide(c, enums.ApprovalRejected)
+}
+
+func (u *approvalHandlers) decide(c *gin.Context, decision enums.ApprovalStatus) {
+	ctx := c.Request.Context()
+	approvalID := c.Param("approvalID")
+	principal := domains.GetPrincipal(c)
+
+	var (
+		req      domains.DecideApprovalRequest
+		approval *models.Approval
+		expired  bool
+		// executed is set once the stored request ran, for metrics
+		executed bool
+	)
+	// the body is optional when approving
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+	if decision == enums.ApprovalRejected && req.Reason == "" {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, "missing reason"))
+		return
+	}
+
+	err := u.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		approval, err = u.approvalRepository.GetApproval(ctx, tx, &repositories.GetApprovalArgs{
+			ApprovalID: approvalID,
+			ForUpdate:  true,
+		})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domains.NewXError(fmt.Errorf("approval_id %s not found", approvalID), enums.NotFound)
+			}
+			return domains.NewXError(err, enums.InternalError)
+		}
+
+		if approval.Status != enums.ApprovalPending.String() {
+			return domains.NewXError(fmt.Errorf("approval_id %s is %s", approvalID, approval.Status), enums.BadRequest)
+		}
+		// another credential of the same person is not a second person
+		if approval.RequestedBy == principal.ID || (approval.RequestedByOwner != "" && approval.RequestedByOwner == principal.Owner) {
+			return domains.NewXError(errors.New("an approval must be decided by someone other than its requester"), enums.Forbidden)
+		}
+
+		now := time.Now()
+		if !approval.ExpiresAt.After(now) {
+			// commit the expiry instead of rolling it back with an error
+			expired = true
+			approval.Status = enums.ApprovalExpired.String()
+			approval.UpdatedAt = now
+			if err := u.approvalRepository.Update(ctx, tx, approval); err != nil {
+				return domains.NewXError(err, enums.InternalError)
+			}
+			return writeAudit(ctx, tx, http.StatusBadRequest)
+		}
+
+		if decision == enums.ApprovalApproved {
+			executed = true
+			transactionID, err := u.execute(c, tx, approval)
+			if err != nil {
+				return err
+			}
+			approval.TransactionID = &transactionID
+		}
+
+		approval.Status = decision.String()
+		approval.DecidedBy = &principal.ID
+		approval.DecisionReason = req.Reason
+		approval.DecidedAt = &now
+		approval.UpdatedAt = now
+		if err := u.approvalRepository.Update(ctx, tx, approval); err != nil {
+			return domains.NewXError(err, enums.InternalError)
+		}
+
+		return writeAudit(ctx, tx, http.StatusOK)
+	})
+	if executed {
+		observeMoneyMovement(approvalTransactionType(approval), approval.Amount, err)
+	}
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+	if expired {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, fmt.Sprintf("approval_id %s has expired", approvalID)))
+		return
+	}
+
+	c.JSON(http.StatusOK, toApprovalResp(approval))
+}
+
+// approvalTransactionType is the transaction an approval executes.
+func approvalTransactionType(approval *models.Approval) enums.TransactionType {
+	if approval.Type == enums.AdjustmentApproval.String() {
+		return enums.Adjustment
+	}
+
+	return enums.Transfer
+}
+
+func (u *approvalHandlers) execute(c *gin.Context, tx *gorm.DB, approval *models.Approval) (string, error) {
+	ctx := c.Request.Context()
+
+	switch approval.Type {
+	case enums.TransferApproval.String():
+		var req domains.TransferAccountRequest
+		if err := json.Unmarshal([]byte(approval.Payload), &req); err != nil {
+			return "", domains.NewXError(err, enums.InternalError)
+		}
+		return u.moneyMovement.transfer(ctx, tx, approval.AccountID, &req, approval.ApprovalID)
+	case enums.AdjustmentApproval.String():
+		var req domains.AdjustAccountRequest
+		if err := json.Unmarshal([]byte(approval.Payload), &req); err != nil {
+			return "", domains.NewXError(err, enums.InternalError)
+		}
+		return u.moneyMovement.adjust(ctx, tx, approval.AccountID, &req, approval.ApprovalID)
+	default:
+		return "", domains.NewXError(fmt.Errorf("unknown approval type %q", approval.Type), enums.InternalError)
+	}
+}
+
+// approvalRequester stores requests above the approval threshold instead of
+// executing them.
+type approvalRequester struct {
+	db                 *gorm.DB
+	idGenerator        utilities.SnowflakeIDGenerator
+	ttl                time.Duration
+	accountRepository  repositories.AccountRepositoryI
+	approvalRepository repositories.ApprovalRepositoryI
+}
+
+func newApprovalRequester(db *gorm.DB, idGenerator utilities.SnowflakeIDGenerator, ttl time.Duration) *approvalRequester {
+	return &approvalRequester{
+		db:                 db,
+		idGenerator:        idGenerator,
+		ttl:                ttl,
+		accountRepository:  repositories.NewAccountRepository(),
+		approvalRepository: repositories.NewApprovalRepository(),
+	}
+}
+
+func (r *approvalRequester) request(ctx context.Context, principal *domains.Principal, approvalType enums.ApprovalType, accountID string, amount float64, payload interface{}) (*models.Approval, error) {
+	if _, err := r.accountRepository.GetAccount(ctx, r.db, &repositories.GetAccountArgs{
+		AccountID: accountID,
+	}); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domains.NewXError(fmt.Errorf("account_id %s not found", accountID), enums.BadRequest)
+		}
+		return nil, domains.NewXError(err, enums.InternalError)
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, domains.NewXError(err, enums.InternalError)
+	}
+
+	now := time.Now()
+	approval := &models.Approval{
+		ApprovalID:       r.idGenerator.Next().String(),
+		Type:             approvalType.String(),
+		AccountID:        accountID,
+		Amount:           amount,
+		Payload:          string(payloadBytes),
+		Status:           enums.ApprovalPending.String(),
+		RequestedBy:      principal.ID,
+		RequestedByOwner: principal.Owner,
+		ExpiresAt:        now.Add(r.ttl),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if err := r.approvalRepository.Create(ctx, r.db, approval); err != nil {
+		return nil, domains.NewXError(err, enums.InternalError)
+	}
+
+	return approval, nil
+}
+
+func toApprovalResp(approval *models.Approval) *domains.Approval {
+	approvalResp := &domains.Approval{
+		ApprovalID:     approval.ApprovalID,
+		Type:           approval.Type,
+		AccountID:      approval.AccountID,
+		Amount:         approval.Amount,
+		Payload:        json.RawMessage(approval.Payload),
+		Status:         approval.Status,
+		RequestedBy:    approval.RequestedBy,
+		DecisionReason: approval.DecisionReason,
+		ExpiresAt:      approval.ExpiresAt,
+		DecidedAt:      approval.DecidedAt,
+		CreatedAt:      approval.CreatedAt,
+		UpdatedAt:      approval.UpdatedAt,
+	}
+	if approval.DecidedBy != nil {
+		approvalResp.DecidedBy = *approval.DecidedBy
+	}
+	if approval.TransactionID != nil {
+		approvalResp.TransactionID = *approval.TransactionID
+	}
+
+	return approvalResp
+}
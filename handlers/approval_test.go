@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/middlewares"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	testApprovalID           = "1719286237483253762"
+	testDestinationAccountID = "3c4f8a4e-1d2b-4e6f-9a7b-5c8d0e1f2a3b"
+	testDestinationUserID    = "1719286237483253763"
+)
+
+var (
+	approvalColumns = []string{"approval_id", "type", "account_id", "amount", "payload", "status", "requested_by", "requested_by_owner", "expires_at", "created_at", "updated_at"}
+	accountColumns  = []string{"account_id", "user_id", "name", "currency", "balance", "created_at", "updated_at"}
+	userColumns     = []string{"user_id", "name", "status", "kyc_level", "created_at", "updated_at"}
+)
+
+// newApprovalRouter serves the approval handlers to a checker other than the
+// maker of the test approvals.
+func newApprovalRouter(t *testing.T, db *gorm.DB, principal *domains.Principal) *gin.Engine {
+	t.Helper()
+
+	if principal == nil {
+		principal = &domains.Principal{
+			Kind:  enums.APIKeyPrincipal,
+			ID:    "checker",
+			Role:  enums.Operator,
+			Owner: "checker-team",
+		}
+	}
+	router := newStaffRouter(principal)
+	NewApprovalHandlers(&ApprovalHandlersDeps{
+		DB:          db,
+		Logger:      zap.NewNop(),
+		IDGenerator: newTestIDGenerator(t),
+		Authorizer:  middlewares.NewAuthorizer(nil),
+	}).RouteGroup(router)
+
+	return router
+}
+
+// transferApprovalRow is a pending transfer of amount from testAccountID,
+// requested by maker of maker-team.
+func transferApprovalRow(amount float64, expiresAt time.Time) *sqlmock.Rows {
+	now := time.Now()
+	payload := `{"to_account_id":"` + testDestinationAccountID + `","amount":` + strconv.FormatFloat(amount, 'f', -1, 64) + `}`
+
+	return sqlmock.NewRows(approvalColumns).AddRow(testApprovalID, enums.TransferApproval.String(), testAccountID, amount, payload,
+		enums.ApprovalPending.String(), "maker", "maker-team", expiresAt, now, now)
+}
+
+func TestDecideRejectsRequester(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *domains.Principal
+		path      string
+		body      string
+	}{
+		{
+			name:      "approved by the maker",
+			principal: &domains.Principal{Kind: enums.APIKeyPrincipal, ID: "maker", Role: enums.Operator, Owner: "maker-team"},
+			path:      "/approvals/" + testApprovalID + "/approve",
+		},
+		{
+			name:      "approved with another key of the maker",
+			principal: &domains.Principal{Kind: enums.APIKeyPrincipal, ID: "maker-rotated", Role: enums.Admin, Owner: "maker-team"},
+			path:      "/approvals/" + testApprovalID + "/approve",
+		},
+		{
+			name:      "rejected by the maker",
+			principal: &domains.Principal{Kind: enums.APIKeyPrincipal, ID: "maker", Role: enums.Operator, Owner: "someone-else"},
+			path:      "/approvals/" + testApprovalID + "/reject",
+			body:      `{"reason":"changed my mind"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			router := newApprovalRouter(t, db, tt.principal)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT \* FROM "approvals"`).
+				WithArgs(testApprovalID).
+				WillReturnRows(transferApprovalRow(100, time.Now().Add(time.Hour)))
+			mock.ExpectRollback()
+
+			rec := serve(router, http.MethodPost, tt.path, tt.body)
+			if rec.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want %d, body %s", rec.Code, http.StatusForbidden, rec.Body.String())
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestDecideExpiredApproval(t *testing.T) {
+	db, mock := newMockDB(t)
+	router := newApprovalRouter(t, db, nil)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "approvals"`).
+		WithArgs(testApprovalID).
+		WillReturnRows(transferApprovalRow(100, time.Now().Add(-time.Minute)))
+	// the expiry is committed although the request fails
+	mock.ExpectExec(`UPDATE "approvals" SET .*"status"=\$5`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), enums.ApprovalExpired.String(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), testApprovalID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rec := serve(router, http.MethodPost, "/approvals/"+testApprovalID+"/approve", "")
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "has expired") {
+		t.Fatalf("status = %d, body %s, want %d for an expired approval", rec.Code, rec.Body.String(), http.StatusBadRequest)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestApproveTransferRechecksAtExecution approves transfers that were valid
+// when requested but no longer are. Nothing is written and the approval stays
+// pending.
+func TestApproveTransferRechecksAtExecution(t *testing.T) {
+	tests := []struct {
+		name                string
+		amount              float64
+		balance             float64
+		destinationCurrency string
+		ownerStatus         string
+		ownerKYCLevel       string
+		// checked is true when the execution reaches the KYC checks
+		checked bool
+		message string
+	}{
+		{
+			name:                "balance spent since the request",
+			amount:              100,
+			balance:             50,
+			destinationCurrency: "USD",
+			ownerStatus:         enums.UserVerified.String(),
+			ownerKYCLevel:       enums.KYCFull.String(),
+			checked:             true,
+			message:             "insufficient balance",
+		},
+		{
+			name:                "destination in another currency",
+			amount:              100,
+			balance:             500,
+			destinationCurrency: "EUR",
+			ownerStatus:         enums.UserVerified.String(),
+			ownerKYCLevel:       enums.KYCFull.String(),
+			message:             "cannot transfer from a USD account to a EUR account",
+		},
+		{
+			name:                "KYC level lowered since the request",
+			amount:              1500,
+			balance:             5000,
+			destinationCurrency: "USD",
+			ownerStatus:         enums.UserVerified.String(),
+			ownerKYCLevel:       enums.KYCBasic.String(),
+			checked:             true,
+			message:             "Transfer exceeds the Basic KYC limit of 1000",
+		},
+		{
+			name:                "owner suspended since the request",
+			amount:              100,
+			balance:             500,
+			destinationCurrency: "USD",
+			ownerStatus:         enums.UserSuspended.String(),
+			ownerKYCLevel:       enums.KYCFull.String(),
+			checked:             true,
+			message:             "user is Suspended and cannot move funds out",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			router := newApprovalRouter(t, db, nil)
+			now := time.Now()
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT \* FROM "approvals"`).
+				WithArgs(testApprovalID).
+				WillReturnRows(transferApprovalRow(tt.amount, now.Add(time.Hour)))
+			mock.ExpectQuery(`SELECT \* FROM "accounts"`).
+				WithArgs(testAccountID).
+				WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(testAccountID, testUserID, "checking", "USD", tt.balance, now, now))
+			mock.ExpectQuery(`SELECT \* FROM "accounts"`).
+				WithArgs(testDestinationAccountID).
+				WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(testDestinationAccountID, testDestinationUserID, "savings", tt.destinationCurrency, 0.0, now, now))
+			if tt.checked {
+				mock.ExpectQuery(`SELECT \* FROM "users"`).
+					WithArgs(testUserID).
+					WillReturnRows(sqlmock.NewRows(userColumns).AddRow(testUserID, "Jane Doe", tt.ownerStatus, tt.ownerKYCLevel, now, now))
+				mock.ExpectQuery(`FROM "transactions"`).
+					WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(0.0))
+				if tt.ownerStatus == enums.UserVerified.String() && tt.ownerKYCLevel == enums.KYCFull.String() {
+					mock.ExpectQuery(`SELECT \* FROM "users"`).
+						WithArgs(testDestinationUserID).
+						WillReturnRows(sqlmock.NewRows(userColumns).AddRow(testDestinationUserID, "John Roe", enums.UserVerified.String(), enums.KYCFull.String(), now, now))
+				}
+			}
+			mock.ExpectRollback()
+
+			rec := serve(router, http.MethodPost, "/approvals/"+testApprovalID+"/approve", "")
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), tt.message) {
+				t.Fatalf("status = %d, body %s, want %d with %q", rec.Code, rec.Body.String(), http.StatusBadRequest, tt.message)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
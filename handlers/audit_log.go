@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/middlewares"
+	"banking-service/models"
+	"banking-service/repositories"
+	"banking-service/utilities"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	_ AuditLogHandlers = &auditLogHandlers{}
+)
+
+type AuditLogHandlers interface {
+	RouteGroup(r *gin.Engine)
+
+	GetAuditLogsHandler(*gin.Context)
+	GetAuditLogHandler(*gin.Context)
+}
+
+type AuditLogHandlersDeps struct {
+	DB          *gorm.DB
+	Logger      *zap.Logger
+	IDGenerator utilities.SnowflakeIDGenerator
+	Authorizer  *middlewares.Authorizer
+}
+
+type auditLogHandlers struct {
+	db                 *gorm.DB
+	logger             *zap.Logger
+	idGenerator        utilities.SnowflakeIDGenerator
+	authorizer         *middlewares.Authorizer
+	auditLogRepository repositories.AuditLogRepositoryI
+}
+
+func NewAuditLogHandlers(deps *AuditLogHandlersDeps) AuditLogHandlers {
+	if deps == nil {
+		return nil
+	}
+
+	return &auditLogHandlers{
+		db:                 deps.DB,
+		logger:             deps.Logger,
+		idGenerator:        deps.IDGenerator,
+		authorizer:         deps.Authorizer,
+		auditLogRepository: repositories.NewAuditLogRepository(),
+	}
+}
+
+func (u *auditLogHandlers) RouteGroup(rg *gin.Engine) {
+	rg.GET("/audit-logs", u.authorizer.Roles(enums.Auditor), u.GetAuditLogsHandler)
+	rg.GET("/audit-logs/:auditID", u.authorizer.Roles(enums.Auditor), u.GetAuditLogHandler)
+}
+
+func (u *auditLogHandlers) GetAuditLogsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	limitStr := c.Query("limit")
+	cursorStr := c.Query("cursor")
+
+	args := &repositories.GetAuditLogsArgs{
+		ActorID:   c.Query("actor_id"),
+		RequestID: c.Query("request_id"),
+		AccountID: c.Query("account_id"),
+		Route:     c.Query("route"),
+		Cursor:    cursorStr,
+	}
+
+	var err error
+	if limitStr != "" {
+		args.Limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+			return
+		}
+	}
+	if from := c.Query("from"); from != "" {
+		args.From, err = time.Parse(time.RFC3339, from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, fmt.Sprintf("invalid from %q, expected RFC 3339", from)))
+			return
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		args.To, err = time.Parse(time.RFC3339, to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, fmt.Sprintf("invalid to %q, expected RFC 3339", to)))
+			return
+		}
+	}
+
+	auditLogs, err := u.auditLogRepository.GetAuditLogs(ctx, u.db, args)
+	if err != nil {
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	auditLogsResp := make([]*domains.AuditLog, 0, len(auditLogs))
+	for _, auditLog := range auditLogs {
+		auditLogResp, err := toAuditLogResp(auditLog)
+		if err != nil {
+			domains.NewXError(err, enums.InternalError).Response(c)
+			return
+		}
+		auditLogsResp = append(auditLogsResp, auditLogResp)
+	}
+
+	var nextCursor string
+	if len(auditLogs) != 0 {
+		nextCursor = auditLogs[len(auditLogs)-1].AuditID
+	}
+	c.JSON(http.StatusOK, &domains.GetAuditLogsResponse{
+		AuditLogs:  auditLogsResp,
+		NextCursor: nextCursor,
+	})
+}
+
+func (u *auditLogHandlers) GetAuditLogHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	auditID := c.Param("auditID")
+
+	auditLog, err := u.auditLogRepository.GetAuditLog(ctx, u.db, &repositories.GetAuditLogArgs{
+		AuditID: auditID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, domains.NewErrorResp(c, fmt.Sprintf("audit_id %s not found", auditID)))
+			return
+		}
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	auditLogResp, err := toAuditLogResp(auditLog)
+	if err != nil {
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, auditLogResp)
+}
+
+func toAuditLogResp(auditLog *models.AuditLog) (*domains.AuditLog, error) {
+	var changes []*domains.AccountChange
+	if err := json.Unmarshal([]byte(auditLog.AccountChanges), &changes); err != nil {
+		return nil, err
+	}
+
+	return &domains.AuditLog{
+		AuditID:        auditLog.AuditID,
+		RequestID:      auditLog.RequestID,
+		ActorKind:      auditLog.ActorKind,
+		ActorID:        auditLog.ActorID,
+		ActorRole:      auditLog.ActorRole,
+		ClientIP:       auditLog.ClientIP,
+		Method:         auditLog.Method,
+		Route:          auditLog.Route,
+		Path:           auditLog.Path,
+		StatusCode:     auditLog.StatusCode,
+		PayloadHash:    auditLog.PayloadHash,
+		AccountChanges: changes,
+		CreatedAt:      auditLog.CreatedAt,
+	}, nil
+}
+
+// toAccountState snapshots account for the audit log. Take it before mutating
+// the account.
+func toAccountState(account *models.Account) *domains.AccountState {
+	return &domains.AccountState{
+		UserID:    account.UserID,
+		Name:      account.Name,
+		Currency:  account.Currency,
+		Balance:   account.Balance,
+		FrozenAt:  account.FrozenAt,
+		DeletedAt: account.DeletedAt,
+	}
+}
+
+func recordAccountChange(ctx context.Context, accountID string, before *domains.AccountState, after *models.Account) {
+	domains.RecordAccountChange(ctx, accountID, before, toAccountState(after))
+}
+
+// auditedTransaction runs fn in a DB transaction and, when it succeeds,
+// writes the audit log entry of the request in the same transaction, so the
+// entry commits with the changes it records. status is the status the
+// request is answered with once the transaction committed.
+func auditedTransaction(ctx context.Context, db *gorm.DB, status int, fn func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, status)
+	})
+}
+
+// writeAudit writes the audit log entry of the request in tx. It returns
+// domains.XError.
+func writeAudit(ctx context.Context, tx *gorm.DB, status int) error {
+	if err := middlewares.WriteAudit(ctx, tx, status); err != nil {
+		return domains.NewXError(err, enums.InternalError)
+	}
+
+	return nil
+}
@@ -0,0 +1,354 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/middlewares"
+	"banking-service/models"
+	"banking-service/repositories"
+	"banking-service/utilities"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	_ AuthHandlers = &authHandlers{}
+)
+
+type AuthHandlers interface {
+	RouteGroup(r *gin.Engine)
+
+	CreateAPIKeyHandler(*gin.Context)
+	GetAPIKeysHandler(*gin.Context)
+	RotateAPIKeyHandler(*gin.Context)
+	RevokeAPIKeyHandler(*gin.Context)
+	CreateTokenHandler(*gin.Context)
+}
+
+type AuthHandlersDeps struct {
+	DB          *gorm.DB
+	Logger      *zap.Logger
+	IDGenerator utilities.SnowflakeIDGenerator
+	JWTManager  utilities.JWTManager
+	Authorizer  *middlewares.Authorizer
+}
+
+type authHandlers struct {
+	db               *gorm.DB
+	logger           *zap.Logger
+	idGenerator      utilities.SnowflakeIDGenerator
+	jwtManager       utilities.JWTManager
+	authorizer       *middlewares.Authorizer
+	apiKeyRepository repositories.APIKeyRepositoryI
+	userRepository   repositories.UserRepositoryI
+}
+
+func NewAuthHandlers(deps *AuthHandlersDeps) AuthHandlers {
+	if deps == nil {
+		return nil
+	}
+
+	return &authHandlers{
+		db:               deps.DB,
+		logger:           deps.Logger,
+		idGenerator:      deps.IDGenerator,
+		jwtManager:       deps.JWTManager,
+		authorizer:       deps.Authorizer,
+		apiKeyRepository: repositories.NewAPIKeyRepository(),
+		userRepository:   repositories.NewUserRepository(),
+	}
+}
+
+func (u *authHandlers) RouteGroup(rg *gin.Engine) {
+	admin := u.authorizer.Roles(enums.Admin)
+
+	rg.POST("/auth/api-keys", admin, u.CreateAPIKeyHandler)
+	rg.GET("/auth/api-keys", admin, u.GetAPIKeysHandler)
+	rg.POST("/auth/api-keys/:keyID/rotate", admin, u.RotateAPIKeyHandler)
+	rg.DELETE("/auth/api-keys/:keyID", admin, u.RevokeAPIKeyHandler)
+	rg.POST("/auth/tokens", u.authorizer.Roles(middlewares.StaffRoles...), u.CreateTokenHandler)
+}
+
+func (u *authHandlers) CreateAPIKeyHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req domains.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	apiKey, key, err := u.newAPIKey(req.Name, req.Owner, req.Role, nil)
+	if err != nil {
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+	if req.ExpiresInSeconds != 0 {
+		expiresAt := apiKey.CreatedAt.Add(time.Duration(req.ExpiresInSeconds) * time.Second)
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	if err := u.apiKeyRepository.Create(ctx, u.db, apiKey); err != nil {
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, &domains.CreateAPIKeyResponse{
+		APIKey: toAPIKeyResp(apiKey),
+		Key:    key,
+	})
+}
+
+func (u *authHandlers) GetAPIKeysHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	limitStr := c.Query("limit")
+	cursorStr := c.Query("cursor")
+
+	var (
+		limit int
+		err   error
+	)
+	if limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+			return
+		}
+	}
+
+	apiKeys, err := u.apiKeyRepository.GetAPIKeys(ctx, u.db, &repositories.GetAPIKeysArgs{
+		Cursor: cursorStr,
+		Limit:  limit,
+	})
+	if err != nil {
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	apiKeysResp := make([]*domains.APIKey, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		apiKeysResp = append(apiKeysResp, toAPIKeyResp(apiKey))
+	}
+
+	var nextCursor string
+	if len(apiKeys) != 0 {
+		nextCursor = apiKeys[len(apiKeys)-1].KeyID
+	}
+	c.JSON(http.StatusOK, &domains.GetAPIKeysResponse{
+		APIKeys:    apiKeysResp,
+		NextCursor: nextCursor,
+	})
+}
+
+// RotateAPIKeyHandler issues a replacement key and retires the old one,
+// either immediately or after the requested grace period.
+func (u *authHandlers) RotateAPIKeyHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	keyID := c.Param("keyID")
+
+	var (
+		req       domains.RotateAPIKeyRequest
+		newAPIKey *models.APIKey
+		key       string
+	)
+	// the body is optional
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	err := auditedTransaction(ctx, u.db, http.StatusOK, func(tx *gorm.DB) error {
+		oldAPIKey, err := u.getActiveAPIKey(c, tx, keyID)
+		if err != nil {
+			return err
+		}
+
+		newAPIKey, key, err = u.newAPIKey(oldAPIKey.Name, oldAPIKey.Owner, oldAPIKey.Role, &oldAPIKey.KeyID)
+		if err != nil {
+			return domains.NewXError(err, enums.InternalError)
+		}
+		newAPIKey.ExpiresAt = oldAPIKey.ExpiresAt
+		if err := u.apiKeyRepository.Create(ctx, tx, newAPIKey); err != nil {
+			return domains.NewXError(err, enums.InternalError)
+		}
+
+		now := time.Now()
+		if req.GracePeriodSeconds == 0 {
+			oldAPIKey.RevokedAt = &now
+		} else {
+			expiresAt := now.Add(time.Duration(req.GracePeriodSeconds) * time.Second)
+			if oldAPIKey.ExpiresAt == nil || expiresAt.Before(*oldAPIKey.ExpiresAt) {
+				oldAPIKey.ExpiresAt = &expiresAt
+			}
+		}
+		oldAPIKey.UpdatedAt = now
+		if err := u.apiKeyRepository.Update(ctx, tx, oldAPIKey); err != nil {
+			return domains.NewXError(err, enums.InternalError)
+		}
+
+		return nil
+	})
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, &domains.CreateAPIKeyResponse{
+		APIKey: toAPIKeyResp(newAPIKey),
+		Key:    key,
+	})
+}
+
+func (u *authHandlers) RevokeAPIKeyHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	keyID := c.Param("keyID")
+
+	var apiKey *models.APIKey
+	err := auditedTransaction(ctx, u.db, http.StatusOK, func(tx *gorm.DB) error {
+		var err error
+		apiKey, err = u.getActiveAPIKey(c, tx, keyID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		apiKey.RevokedAt = &now
+		apiKey.UpdatedAt = now
+		if err := u.apiKeyRepository.Update(ctx, tx, apiKey); err != nil {
+			return domains.NewXError(err, enums.InternalError)
+		}
+
+		return nil
+	})
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toAPIKeyResp(apiKey))
+}
+
+// CreateTokenHandler lets a trusted service, such as the login frontend,
+// mint a JWT for an end user it has already authenticated.
+func (u *authHandlers) CreateTokenHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req domains.CreateTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	user, err := u.userRepository.GetUser(ctx, u.db, &repositories.GetUserArgs{
+		UserID: req.UserID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, fmt.Sprintf("user_id %s not found", req.UserID)))
+			return
+		}
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+	if user.ErasedAt != nil || user.Status == enums.UserClosed.String() {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, fmt.Sprintf("user_id %s is closed", req.UserID)))
+		return
+	}
+
+	// the token acts for the user on behalf of its issuer
+	token, expiresAt, err := u.jwtManager.Sign(user.UserID, domains.GetPrincipal(c).Owner, req.TTL())
+	if err != nil {
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, &domains.CreateTokenResponse{
+		Token:     token,
+		TokenType: "Bearer",
+		ExpiresAt: expiresAt,
+	})
+}
+
+func (u *authHandlers) getActiveAPIKey(c *gin.Context, tx *gorm.DB, keyID string) (*models.APIKey, error) {
+	apiKey, err := u.apiKeyRepository.GetAPIKey(c.Request.Context(), tx, &repositories.GetAPIKeyArgs{
+		KeyID:     keyID,
+		ForUpdate: true,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domains.NewXError(fmt.Errorf("key_id %s not found", keyID), enums.NotFound)
+		}
+		return nil, domains.NewXError(err, enums.InternalError)
+	}
+
+	if apiKey.RevokedAt != nil {
+		return nil, domains.NewXError(fmt.Errorf("key_id %s is already revoked", keyID), enums.BadRequest)
+	}
+	if apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(time.Now()) {
+		return nil, domains.NewXError(fmt.Errorf("key_id %s has expired", keyID), enums.BadRequest)
+	}
+
+	return apiKey, nil
+}
+
+func (u *authHandlers) newAPIKey(name, owner, role string, rotatedFrom *string) (*models.APIKey, string, error) {
+	keyID := u.idGenerator.Next().String()
+	key, hash, err := utilities.GenerateAPIKey(keyID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &models.APIKey{
+		KeyID:       keyID,
+		Name:        name,
+		Owner:       owner,
+		Role:        role,
+		KeyHash:     hash,
+		RotatedFrom: rotatedFrom,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}, key, nil
+}
+
+func toAPIKeyResp(apiKey *models.APIKey) *domains.APIKey {
+	apiKeyResp := &domains.APIKey{
+		KeyID:      apiKey.KeyID,
+		Name:       apiKey.Name,
+		Owner:      apiKey.Owner,
+		Role:       apiKey.Role,
+		LastUsedAt: apiKey.LastUsedAt,
+		ExpiresAt:  apiKey.ExpiresAt,
+		RevokedAt:  apiKey.RevokedAt,
+		CreatedAt:  apiKey.CreatedAt,
+		UpdatedAt:  apiKey.UpdatedAt,
+	}
+	if apiKey.RotatedFrom != nil {
+		apiKeyResp.RotatedFrom = *apiKey.RotatedFrom
+	}
+
+	return apiKeyResp
+}
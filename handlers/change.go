@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/middlewares"
+	"banking-service/models"
+	"banking-service/repositories"
+	"banking-service/utilities"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// maxChangesLimit bounds a page of the change feed.
+const maxChangesLimit = 1000
+
+var (
+	_ ChangeHandlers = &changeHandlers{}
+)
+
+type ChangeHandlers interface {
+	RouteGroup(r *gin.Engine)
+
+	GetChangesHandler(c *gin.Context)
+}
+
+type ChangeHandlersDeps struct {
+	DB          *gorm.DB
+	Logger      *zap.Logger
+	IDGenerator utilities.SnowflakeIDGenerator
+	Authorizer  *middlewares.Authorizer
+}
+
+type changeHandlers struct {
+	db                    *gorm.DB
+	logger                *zap.Logger
+	idGenerator           utilities.SnowflakeIDGenerator
+	authorizer            *middlewares.Authorizer
+	changeRepository      repositories.ChangeRepositoryI
+	userRepositiory       repositories.UserRepositoryI
+	accountRepository     repositories.AccountRepositoryI
+	transactionRepository repositories.TransactionRepositoryI
+}
+
+func NewChangeHandlers(deps *ChangeHandlersDeps) ChangeHandlers {
+	if deps == nil {
+		return nil
+	}
+
+	return &changeHandlers{
+		db:                    deps.DB,
+		logger:                deps.Logger,
+		idGenerator:           deps.IDGenerator,
+		authorizer:            deps.Authorizer,
+		changeRepository:      repositories.NewChangeRepository(),
+		userRepositiory:       repositories.NewUserRepository(),
+		accountRepository:     repositories.NewAccountRepository(),
+		transactionRepository: repositories.NewTransactionRepository(),
+	}
+}
+
+func (u *changeHandlers) RouteGroup(rg *gin.Engine) {
+	rg.GET("/changes", u.authorizer.Roles(middlewares.ReadRoles...), u.GetChangesHandler)
+}
+
+// GetChangesHandler serves the change feed over users, accounts and
+// transactions in commit order. Consumers store next_cursor only after
+// applying a page, so a crash replays it: delivery is at-least-once.
+func (u *changeHandlers) GetChangesHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	afterStr := c.Query("after")
+	limitStr := c.Query("limit")
+
+	var (
+		after int64
+		limit int
+		err   error
+	)
+	if afterStr != "" {
+		after, err = strconv.ParseInt(afterStr, 10, 64)
+		if err != nil || after < 0 {
+			c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, fmt.Sprintf("invalid after %q", afterStr)))
+			return
+		}
+	}
+	if limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxChangesLimit {
+			c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, fmt.Sprintf("limit must be between 1 and %d", maxChangesLimit)))
+			return
+		}
+	}
+
+	changes, err := u.changeRepository.GetChanges(ctx, u.db, &repositories.GetChangesArgs{
+		After: after,
+		Limit: limit,
+	})
+	if err != nil {
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	changesResp, err := u.toChangesResp(ctx, changes)
+	if err != nil {
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	nextCursor := strconv.FormatInt(after, 10)
+	if len(changesResp) != 0 {
+		nextCursor = strconv.FormatInt(changesResp[len(changesResp)-1].Position, 10)
+	}
+	c.JSON(http.StatusOK, &domains.GetChangesResponse{
+		Changes:    changesResp,
+		NextCursor: nextCursor,
+	})
+}
+
+// toChangesResp loads the current state of the changed entities with one
+// query per entity type.
+func (u *changeHandlers) toChangesResp(ctx context.Context, changes models.Changes) ([]*domains.Change, error) {
+	ids := make(map[enums.ChangeEntityType][]string)
+	for _, change := range changes {
+		entityType, ok := enums.ParseChangeEntityType(change.EntityType)
+		if !ok {
+			return nil, fmt.Errorf("unknown entity_type %q in change %d", change.EntityType, change.ChangeID)
+		}
+		ids[entityType] = append(ids[entityType], change.EntityID)
+	}
+
+	data := make(map[enums.ChangeEntityType]map[string]interface{})
+	deletedAccounts := make(map[string]bool)
+
+	if userIDs := ids[enums.UserEntity]; len(userIDs) != 0 {
+		users, err := u.userRepositiory.GetUsers(ctx, u.db, &repositories.GetUsersArgs{
+			UserIDs: userIDs,
+			Limit:   len(userIDs),
+		})
+		if err != nil {
+			return nil, err
+		}
+		data[enums.UserEntity] = make(map[string]interface{}, len(users))
+		for _, user := range users {
+			data[enums.UserEntity][user.UserID] = toUserResp(user)
+		}
+	}
+	if accountIDs := ids[enums.AccountEntity]; len(accountIDs) != 0 {
+		accounts, err := u.accountRepository.GetAccounts(ctx, u.db, &repositories.GetAccountsArgs{
+			AccountIDs:  accountIDs,
+			WithDeleted: true,
+			Limit:       len(accountIDs),
+		})
+		if err != nil {
+			return nil, err
+		}
+		data[enums.AccountEntity] = make(map[string]interface{}, len(accounts))
+		for _, account := range accounts {
+			data[enums.AccountEntity][account.AccountID] = toAccountResp(account)
+			if account.DeletedAt != nil {
+				deletedAccounts[account.AccountID] = true
+			}
+		}
+	}
+	if transactionIDs := ids[enums.TransactionEntity]; len(transactionIDs) != 0 {
+		transactions, err := u.transactionRepository.GetTransactions(ctx, u.db, &repositories.GetTransactionsArgs{
+			TransactionIDs: transactionIDs,
+			Limit:          len(transactionIDs),
+		})
+		if err != nil {
+			return nil, err
+		}
+		data[enums.TransactionEntity] = make(map[string]interface{}, len(transactions))
+		for _, transaction := range transactions {
+			data[enums.TransactionEntity][transaction.TransactionID] = toTransactionResp(transaction)
+		}
+	}
+
+	changesResp := make([]*domains.Change, 0, len(changes))
+	for _, change := range changes {
+		if change.Position == nil {
+			return nil, errors.New("change without position")
+		}
+		entityType, _ := enums.ParseChangeEntityType(change.EntityType)
+		entity, ok := data[entityType][change.EntityID]
+
+		changesResp = append(changesResp, &domains.Change{
+			Position:   *change.Position,
+			EntityType: change.EntityType,
+			EntityID:   change.EntityID,
+			Operation:  change.Operation,
+			ChangedAt:  change.ChangedAt,
+			Deleted:    !ok || (entityType == enums.AccountEntity && deletedAccounts[change.EntityID]),
+			Data:       entity,
+		})
+	}
+
+	return changesResp, nil
+}
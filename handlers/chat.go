@@ -0,0 +1,766 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/hub"
+	"banking-service/middlewares"
+	"banking-service/models"
+	"banking-service/repositories"
+	"banking-service/utilities"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// chatPushTimeout bounds loading a message to push it to connected clients.
+	chatPushTimeout = 5 * time.Second
+	// chatPushBuffer is the number of chat events waiting to be pushed before
+	// new ones are dropped; clients catch up from the history.
+	chatPushBuffer = 1024
+)
+
+var (
+	_ ChatHandlers = &chatHandlers{}
+)
+
+// ChatHandlers serve support conversations over REST and the WebSocket hub.
+// Messages are pushed to connected participants from the outbox, so every
+// instance delivers to its own connections.
+type ChatHandlers interface {
+	RouteGroup(r *gin.Engine)
+	OnEvent(event *domains.Event)
+	// Run pushes the events queued by OnEvent and blocks until ctx is done.
+	Run(ctx context.Context)
+
+	CreateConversationHandler(*gin.Context)
+	GetConversationsHandler(*gin.Context)
+	GetConversationHandler(*gin.Context)
+	GetChatMessagesHandler(*gin.Context)
+	SendChatMessageHandler(*gin.Context)
+	MarkConversationReadHandler(*gin.Context)
+}
+
+type ChatHandlersDeps struct {
+	DB          *gorm.DB
+	Logger      *zap.Logger
+	IDGenerator utilities.SnowflakeIDGenerator
+	Authorizer  *middlewares.Authorizer
+	Hub         *hub.Hub
+}
+
+type chatHandlers struct {
+	db                     *gorm.DB
+	logger                 *zap.Logger
+	idGenerator            utilities.SnowflakeIDGenerator
+	authorizer             *middlewares.Authorizer
+	hub                    *hub.Hub
+	events                 *eventEmitter
+	pushes                 chan *domains.Event
+	userRepository         repositories.UserRepositoryI
+	accountRepository      repositories.AccountRepositoryI
+	conversationRepository repositories.ConversationRepositoryI
+	chatMessageRepository  repositories.ChatMessageRepositoryI
+}
+
+// NewChatHandlers also registers the chat message types on the hub.
+func NewChatHandlers(deps *ChatHandlersDeps) ChatHandlers {
+	if deps == nil {
+		return nil
+	}
+
+	u := &chatHandlers{
+		db:                     deps.DB,
+		logger:                 deps.Logger,
+		idGenerator:            deps.IDGenerator,
+		authorizer:             deps.Authorizer,
+		hub:                    deps.Hub,
+		events:                 newEventEmitter(deps.IDGenerator),
+		pushes:                 make(chan *domains.Event, chatPushBuffer),
+		userRepository:         repositories.NewUserRepository(),
+		accountRepository:      repositories.NewAccountRepository(),
+		conversationRepository: repositories.NewConversationRepository(),
+		chatMessageRepository:  repositories.NewChatMessageRepository(),
+	}
+	u.hub.HandleFunc(hub.TextMessage, u.handleTextMessage)
+	u.hub.HandleFunc(hub.ReadMessage, u.handleReadMessage)
+
+	return u
+}
+
+func (u *chatHandlers) RouteGroup(rg *gin.Engine) {
+	writeRoles := append([]enums.Role{enums.Customer}, middlewares.StaffRoles...)
+	readRoles := append([]enums.Role{enums.Customer}, middlewares.ReadRoles...)
+
+	rg.POST("/conversations", u.authorizer.Roles(writeRoles...), u.CreateConversationHandler)
+	rg.GET("/conversations", u.authorizer.Roles(readRoles...), u.GetConversationsHandler)
+	rg.GET("/conversations/:conversationID", u.authorizer.Roles(readRoles...), u.GetConversationHandler)
+	rg.GET("/conversations/:conversationID/messages", u.authorizer.Roles(readRoles...), u.GetChatMessagesHandler)
+	rg.POST("/conversations/:conversationID/messages", u.authorizer.Roles(writeRoles...), u.SendChatMessageHandler)
+	rg.POST("/conversations/:conversationID/read", u.authorizer.Roles(writeRoles...), u.MarkConversationReadHandler)
+}
+
+func (u *chatHandlers) CreateConversationHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	principal := domains.GetPrincipal(c)
+
+	var req domains.CreateConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	if principal.IsUser() {
+		for _, userID := range req.UserIDs {
+			if userID != principal.ID {
+				middlewares.Forbid(c)
+				return
+			}
+		}
+		req.UserIDs = []string{principal.ID}
+	}
+	if len(req.UserIDs) == 0 {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, "missing user_ids"))
+		return
+	}
+
+	var (
+		conversation *models.Conversation
+		participants models.ConversationParticipants
+	)
+	err := auditedTransaction(ctx, u.db, http.StatusCreated, func(tx *gorm.DB) error {
+		if err := u.ensureConversationSubjects(ctx, tx, principal, req.UserIDs, req.AccountID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		conversation = &models.Conversation{
+			ConversationID: u.idGenerator.Next().String(),
+			Subject:        req.Subject,
+			AccountID:      req.AccountID,
+			CreatedBy:      principal.ID,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+		if err := u.conversationRepository.Create(ctx, tx, conversation); err != nil {
+			return domains.NewXError(err, enums.InternalError)
+		}
+
+		for _, userID := range req.UserIDs {
+			participants = append(participants, &models.ConversationParticipant{
+				ConversationID:  conversation.ConversationID,
+				ParticipantID:   userID,
+				ParticipantKind: enums.UserPrincipal.String(),
+				JoinedAt:        now,
+			})
+		}
+		if !principal.IsUser() {
+			participants = append(participants, &models.ConversationParticipant{
+				ConversationID:  conversation.ConversationID,
+				ParticipantID:   principal.ID,
+				ParticipantKind: principal.Kind.String(),
+				JoinedAt:        now,
+			})
+		}
+		for _, participant := range participants {
+			if err := u.conversationRepository.AddParticipant(ctx, tx, participant); err != nil {
+				return domains.NewXError(err, enums.InternalError)
+			}
+		}
+
+		if req.Text != "" {
+			message, err := u.sendMessage(ctx, tx, principal, conversation.ConversationID, req.Text)
+			if err != nil {
+				return err
+			}
+			conversation.LastMessageID = message.MessageID
+		}
+
+		// the first message moved the read receipt of the creator
+		var err error
+		participants, err = u.conversationRepository.GetParticipants(ctx, tx, &repositories.GetParticipantsArgs{
+			ConversationID: conversation.ConversationID,
+		})
+		if err != nil {
+			return domains.NewXError(err, enums.InternalError)
+		}
+
+		return nil
+	})
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toConversationResp(conversation, participants))
+}
+
+func (u *chatHandlers) GetConversationsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	principal := domains.GetPrincipal(c)
+	limitStr := c.Query("limit")
+	cursorStr := c.Query("cursor")
+
+	args := &repositories.GetConversationsArgs{
+		ParticipantID: c.Query("user_id"),
+		AccountID:     c.Query("account_id"),
+		Cursor:        cursorStr,
+	}
+	if principal.IsUser() {
+		args.ParticipantID = principal.ID
+	}
+	if limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+			return
+		}
+		args.Limit = limit
+	}
+
+	conversations, err := u.conversationRepository.GetConversations(ctx, u.db, args)
+	if err != nil {
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	conversationsResp := make([]*domains.Conversation, 0, len(conversations))
+	for _, conversation := range conversations {
+		conversationsResp = append(conversationsResp, toConversationResp(conversation, nil))
+	}
+
+	var nextCursor string
+	if len(conversations) != 0 {
+		nextCursor = conversations[len(conversations)-1].ConversationID
+	}
+	c.JSON(http.StatusOK, &domains.GetConversationsResponse{
+		Conversations: conversationsResp,
+		NextCursor:    nextCursor,
+	})
+}
+
+// GetConversationHandler returns the conversation with its participants and
+// their read receipts.
+func (u *chatHandlers) GetConversationHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	principal := domains.GetPrincipal(c)
+
+	conversation, _, err := u.getConversation(ctx, u.db, principal, c.Param("conversationID"), false)
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+
+	participants, err := u.conversationRepository.GetParticipants(ctx, u.db, &repositories.GetParticipantsArgs{
+		ConversationID: conversation.ConversationID,
+	})
+	if err != nil {
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toConversationResp(conversation, participants))
+}
+
+// GetChatMessagesHandler returns the history, newest first, for clients that
+// were offline.
+func (u *chatHandlers) GetChatMessagesHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	principal := domains.GetPrincipal(c)
+	limitStr := c.Query("limit")
+	cursorStr := c.Query("cursor")
+
+	conversation, _, err := u.getConversation(ctx, u.db, principal, c.Param("conversationID"), false)
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+
+	var limit int
+	if limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+			return
+		}
+	}
+
+	messages, err := u.chatMessageRepository.GetChatMessages(ctx, u.db, &repositories.GetChatMessagesArgs{
+		ConversationID: conversation.ConversationID,
+		Cursor:         cursorStr,
+		Limit:          limit,
+	})
+	if err != nil {
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	messagesResp := make([]*domains.ChatMessage, 0, len(messages))
+	for _, message := range messages {
+		messagesResp = append(messagesResp, toChatMessageResp(message))
+	}
+
+	var nextCursor string
+	if len(messages) != 0 {
+		nextCursor = messages[len(messages)-1].MessageID
+	}
+	c.JSON(http.StatusOK, &domains.GetChatMessagesResponse{
+		Messages:   messagesResp,
+		NextCursor: nextCursor,
+	})
+}
+
+func (u *chatHandlers) SendChatMessageHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	principal := domains.GetPrincipal(c)
+
+	var req domains.SendChatMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	var message *models.ChatMessage
+	err := auditedTransaction(ctx, u.db, http.StatusCreated, func(tx *gorm.DB) error {
+		var err error
+		message, err = u.sendMessage(ctx, tx, principal, c.Param("conversationID"), req.Text)
+		return err
+	})
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toChatMessageResp(message))
+}
+
+func (u *chatHandlers) MarkConversationReadHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	principal := domains.GetPrincipal(c)
+
+	var req domains.MarkConversationReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	var participant *models.ConversationParticipant
+	err := auditedTransaction(ctx, u.db, http.StatusOK, func(tx *gorm.DB) error {
+		var err error
+		participant, err = u.markRead(ctx, tx, principal, c.Param("conversationID"), req.MessageID)
+		return err
+	})
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toParticipantResp(participant))
+}
+
+// handleTextMessage sends {"type": 2, "data": {"to_group_id": <conversation
+// ID>, "text": ...}} from a WebSocket client. The sender gets the stored
+// message back like every other participant.
+func (u *chatHandlers) handleTextMessage(ctx context.Context, infos *hub.ConnectionInfos, msg *hub.Message) error {
+	if msg.Data == nil || msg.Data.ToGroupID == "" {
+		return errors.New("missing to_group_id")
+	}
+	text, err := domains.NormalizeChatText(msg.Data.Text)
+	if err != nil {
+		return err
+	}
+
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		_, err := u.sendMessage(ctx, tx, infos.Principal, string(msg.Data.ToGroupID), text)
+		return err
+	})
+}
+
+// handleReadMessage records {"type": 7, "data": {"to_group_id": <conversation
+// ID>, "message_id": ...}} from a WebSocket client.
+func (u *chatHandlers) handleReadMessage(ctx context.Context, infos *hub.ConnectionInfos, msg *hub.Message) error {
+	if msg.Data == nil || msg.Data.ToGroupID == "" || msg.Data.MessageID == "" {
+		return errors.New("missing to_group_id or message_id")
+	}
+
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		_, err := u.markRead(ctx, tx, infos.Principal, string(msg.Data.ToGroupID), msg.Data.MessageID)
+		return err
+	})
+}
+
+// OnEvent implements workers.EventListener and queues chat events for Run,
+// so loading messages does not hold up the broadcaster.
+func (u *chatHandlers) OnEvent(event *domains.Event) {
+	if event.Type != enums.ChatMessageSent.String() && event.Type != enums.ChatMessageRead.String() {
+		return
+	}
+
+	select {
+	case u.pushes <- event:
+	default:
+		u.logger.Warn("chat push queue full, dropping event", zap.String("event_id", event.EventID), zap.String("type", event.Type))
+	}
+}
+
+func (u *chatHandlers) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-u.pushes:
+			u.push(ctx, event)
+		}
+	}
+}
+
+// push sends a chat event to the participants connected to this instance.
+func (u *chatHandlers) push(ctx context.Context, event *domains.Event) {
+	switch event.Type {
+	case enums.ChatMessageSent.String():
+		var payload domains.ChatMessageSentEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return
+		}
+		recipients := u.connectedRecipients(payload.RecipientIDs)
+		if len(recipients) == 0 {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, chatPushTimeout)
+		defer cancel()
+		message, err := u.chatMessageRepository.GetChatMessage(ctx, u.db, &repositories.GetChatMessageArgs{
+			MessageID: payload.MessageID,
+		})
+		if err != nil {
+			return
+		}
+
+		for _, recipient := range recipients {
+			u.hub.SendToUser(recipient, &hub.Message{
+				Type: hub.TextMessage,
+				Data: &hub.MessageData{
+					FromUserID: hub.UserID(message.SenderID),
+					ToGroupID:  hub.GroupID(message.ConversationID),
+					Text:       message.Text,
+					MessageID:  message.MessageID,
+					SentAt:     &message.CreatedAt,
+				},
+			})
+		}
+	case enums.ChatMessageRead.String():
+		var payload domains.ChatMessageReadEvent
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return
+		}
+
+		for _, recipient := range u.connectedRecipients(payload.RecipientIDs) {
+			u.hub.SendToUser(recipient, &hub.Message{
+				Type: hub.ReadMessage,
+				Data: &hub.MessageData{
+					FromUserID: hub.UserID(payload.ReaderID),
+					ToGroupID:  hub.GroupID(payload.ConversationID),
+					MessageID:  payload.MessageID,
+				},
+			})
+		}
+	}
+}
+
+func (u *chatHandlers) connectedRecipients(recipientIDs []string) []hub.UserID {
+	var recipients []hub.UserID
+	for _, recipientID := range recipientIDs {
+		if u.hub.IsConnected(hub.UserID(recipientID)) {
+			recipients = append(recipients, hub.UserID(recipientID))
+		}
+	}
+
+	return recipients
+}
+
+// sendMessage stores a message and emits ChatMessageSent. Staff join the
+// conversation when they first write to it. It returns domains.XError.
+func (u *chatHandlers) sendMessage(ctx context.Context, tx *gorm.DB, principal *domains.Principal, conversationID, text string) (*models.ChatMessage, error) {
+	// the lock orders the messages of a conversation
+	conversation, participant, err := u.getConversation(ctx, tx, principal, conversationID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if participant == nil {
+		if !principal.HasRole(middlewares.StaffRoles...) {
+			return nil, domains.NewXError(errors.New("forbidden"), enums.Forbidden)
+		}
+		participant = &models.ConversationParticipant{
+			ConversationID:  conversation.ConversationID,
+			ParticipantID:   principal.ID,
+			ParticipantKind: principal.Kind.String(),
+			JoinedAt:        now,
+		}
+		if err := u.conversationRepository.AddParticipant(ctx, tx, participant); err != nil {
+			return nil, domains.NewXError(err, enums.InternalError)
+		}
+	}
+
+	message := &models.ChatMessage{
+		MessageID:      u.idGenerator.Next().String(),
+		ConversationID: conversation.ConversationID,
+		SenderID:       principal.ID,
+		SenderKind:     principal.Kind.String(),
+		Text:           text,
+		CreatedAt:      now,
+	}
+	if err := u.chatMessageRepository.Create(ctx, tx, message); err != nil {
+		return nil, domains.NewXError(err, enums.InternalError)
+	}
+
+	conversation.LastMessageID = message.MessageID
+	conversation.UpdatedAt = now
+	if err := u.conversationRepository.Update(ctx, tx, conversation); err != nil {
+		return nil, domains.NewXError(err, enums.InternalError)
+	}
+
+	// senders have read their own message
+	participant.LastReadMessageID = message.MessageID
+	participant.LastReadAt = &now
+	if err := u.conversationRepository.UpdateParticipant(ctx, tx, participant); err != nil {
+		return nil, domains.NewXError(err, enums.InternalError)
+	}
+
+	recipientIDs, err := u.participantIDs(ctx, tx, conversation.ConversationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.events.emit(ctx, tx, enums.ChatMessageSent, conversation.ConversationID, &domains.ChatMessageSentEvent{
+		ConversationID: conversation.ConversationID,
+		MessageID:      message.MessageID,
+		SenderID:       principal.ID,
+		RecipientIDs:   recipientIDs,
+	}); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// markRead moves the read receipt of principal forward to messageID and
+// emits ChatMessageRead. It returns domains.XError.
+func (u *chatHandlers) markRead(ctx context.Context, tx *gorm.DB, principal *domains.Principal, conversationID, messageID string) (*models.ConversationParticipant, error) {
+	conversation, participant, err := u.getConversation(ctx, tx, principal, conversationID, false)
+	if err != nil {
+		return nil, err
+	}
+	if participant == nil {
+		return nil, domains.NewXError(fmt.Errorf("not a participant of conversation_id %s", conversationID), enums.BadRequest)
+	}
+
+	participant, err = u.conversationRepository.GetParticipant(ctx, tx, &repositories.GetParticipantArgs{
+		ConversationID: conversation.ConversationID,
+		ParticipantID:  principal.ID,
+		ForUpdate:      true,
+	})
+	if err != nil {
+		return nil, domains.NewXError(err, enums.InternalError)
+	}
+
+	if _, err := u.chatMessageRepository.GetChatMessage(ctx, tx, &repositories.GetChatMessageArgs{
+		MessageID:      messageID,
+		ConversationID: conversation.ConversationID,
+	}); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domains.NewXError(fmt.Errorf("message_id %s not found", messageID), enums.BadRequest)
+		}
+		return nil, domains.NewXError(err, enums.InternalError)
+	}
+
+	// receipts only move forward
+	if !chatMessageAfter(messageID, participant.LastReadMessageID) {
+		return participant, nil
+	}
+
+	now := time.Now()
+	participant.LastReadMessageID = messageID
+	participant.LastReadAt = &now
+	if err := u.conversationRepository.UpdateParticipant(ctx, tx, participant); err != nil {
+		return nil, domains.NewXError(err, enums.InternalError)
+	}
+
+	recipientIDs, err := u.participantIDs(ctx, tx, conversation.ConversationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.events.emit(ctx, tx, enums.ChatMessageRead, conversation.ConversationID, &domains.ChatMessageReadEvent{
+		ConversationID: conversation.ConversationID,
+		MessageID:      messageID,
+		ReaderID:       principal.ID,
+		RecipientIDs:   recipientIDs,
+	}); err != nil {
+		return nil, err
+	}
+
+	return participant, nil
+}
+
+// getConversation returns the conversation and the participant entry of
+// principal, nil for staff outside the conversation. Customers get 403 for
+// conversations they are not in or that do not exist. It returns
+// domains.XError.
+func (u *chatHandlers) getConversation(ctx context.Context, db *gorm.DB, principal *domains.Principal, conversationID string, forUpdate bool) (*models.Conversation, *models.ConversationParticipant, error) {
+	conversation, err := u.conversationRepository.GetConversation(ctx, db, &repositories.GetConversationArgs{
+		ConversationID: conversationID,
+		ForUpdate:      forUpdate,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if principal.IsUser() {
+				return nil, nil, domains.NewXError(errors.New("forbidden"), enums.Forbidden)
+			}
+			return nil, nil, domains.NewXError(fmt.Errorf("conversation_id %s not found", conversationID), enums.NotFound)
+		}
+		return nil, nil, domains.NewXError(err, enums.InternalError)
+	}
+
+	participant, err := u.conversationRepository.GetParticipant(ctx, db, &repositories.GetParticipantArgs{
+		ConversationID: conversationID,
+		ParticipantID:  principal.ID,
+	})
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, domains.NewXError(err, enums.InternalError)
+		}
+		if !principal.HasRole(middlewares.ReadRoles...) {
+			return nil, nil, domains.NewXError(errors.New("forbidden"), enums.Forbidden)
+		}
+		participant = nil
+	}
+
+	return conversation, participant, nil
+}
+
+// ensureConversationSubjects checks that the users exist and that the
+// account, if any, belongs to one of them. It returns domains.XError.
+func (u *chatHandlers) ensureConversationSubjects(ctx context.Context, tx *gorm.DB, principal *domains.Principal, userIDs []string, accountID string) error {
+	for _, userID := range userIDs {
+		if _, err := u.userRepository.GetUser(ctx, tx, &repositories.GetUserArgs{
+			UserID: userID,
+		}); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domains.NewXError(fmt.Errorf("user_id %s not found", userID), enums.BadRequest)
+			}
+			return domains.NewXError(err, enums.InternalError)
+		}
+	}
+
+	if accountID == "" {
+		return nil
+	}
+	// an account has a single owner, who must be the only user
+	if len(userIDs) > 1 {
+		return domains.NewXError(errors.New("account_id is only allowed in conversations with a single user"), enums.BadRequest)
+	}
+	account, err := u.accountRepository.GetAccount(ctx, tx, &repositories.GetAccountArgs{
+		AccountID: accountID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domains.NewXError(fmt.Errorf("account_id %s not found", accountID), enums.BadRequest)
+		}
+		return domains.NewXError(err, enums.InternalError)
+	}
+	if account.UserID == userIDs[0] {
+		return nil
+	}
+	if principal.IsUser() {
+		return domains.NewXError(fmt.Errorf("account_id %s not found", accountID), enums.BadRequest)
+	}
+
+	return domains.NewXError(fmt.Errorf("account_id %s does not belong to user_ids", accountID), enums.BadRequest)
+}
+
+// participantIDs returns domains.XError.
+func (u *chatHandlers) participantIDs(ctx context.Context, tx *gorm.DB, conversationID string) ([]string, error) {
+	participants, err := u.conversationRepository.GetParticipants(ctx, tx, &repositories.GetParticipantsArgs{
+		ConversationID: conversationID,
+	})
+	if err != nil {
+		return nil, domains.NewXError(err, enums.InternalError)
+	}
+
+	participantIDs := make([]string, 0, len(participants))
+	for _, participant := range participants {
+		participantIDs = append(participantIDs, participant.ParticipantID)
+	}
+
+	return participantIDs, nil
+}
+
+// chatMessageAfter reports whether message ID a is newer than b. Snowflake
+// IDs are time ordered but their decimal form can grow in length.
+func chatMessageAfter(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) > len(b)
+	}
+
+	return a > b
+}
+
+func toConversationResp(conversation *models.Conversation, participants models.ConversationParticipants) *domains.Conversation {
+	conversationResp := &domains.Conversation{
+		ConversationID: conversation.ConversationID,
+		Subject:        conversation.Subject,
+		AccountID:      conversation.AccountID,
+		CreatedBy:      conversation.CreatedBy,
+		LastMessageID:  conversation.LastMessageID,
+		CreatedAt:      conversation.CreatedAt,
+		UpdatedAt:      conversation.UpdatedAt,
+	}
+	for _, participant := range participants {
+		conversationResp.Participants = append(conversationResp.Participants, toParticipantResp(participant))
+	}
+
+	return conversationResp
+}
+
+func toParticipantResp(participant *models.ConversationParticipant) *domains.ConversationParticipant {
+	return &domains.ConversationParticipant{
+		ParticipantID:     participant.ParticipantID,
+		ParticipantKind:   participant.ParticipantKind,
+		LastReadMessageID: participant.LastReadMessageID,
+		LastReadAt:        participant.LastReadAt,
+		JoinedAt:          participant.JoinedAt,
+	}
+}
+
+func toChatMessageResp(message *models.ChatMessage) *domains.ChatMessage {
+	return &domains.ChatMessage{
+		MessageID:      message.MessageID,
+		ConversationID: message.ConversationID,
+		SenderID:       message.SenderID,
+		SenderKind:     message.SenderKind,
+		Text:           message.Text,
+		CreatedAt:      message.CreatedAt,
+	}
+}
@@ -0,0 +1,501 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/middlewares"
+	"banking-service/models"
+	"banking-service/proto/bankingpb"
+	"banking-service/repositories"
+	"banking-service/utilities"
+
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+var (
+	_ BankingService = &bankingService{}
+)
+
+// BankingService serves the gRPC API with the handlers of the REST API, so
+// both apply the same business rules, authorization and errors.
+type BankingService interface {
+	bankingpb.BankingServiceServer
+	OnEvent(event *domains.Event)
+	Close()
+}
+
+type BankingServiceDeps struct {
+	DB                *gorm.DB
+	Logger            *zap.Logger
+	IDGenerator       utilities.SnowflakeIDGenerator
+	Authorizer        *middlewares.Authorizer
+	ApprovalThreshold float64
+	ApprovalTTL       time.Duration
+	HeartbeatInterval time.Duration
+	MaxStreamAge      time.Duration
+	SequenceInterval  time.Duration
+	// MaxPageSize is the largest limit accepted by the list methods, as for
+	// the REST list endpoints.
+	MaxPageSize int
+}
+
+type bankingService struct {
+	bankingpb.UnimplementedBankingServiceServer
+
+	authorizer   *middlewares.Authorizer
+	maxPageSize  int
+	users        *userHandlers
+	accounts     *accountHandlers
+	transactions *transactionHandlers
+}
+
+func NewBankingService(deps *BankingServiceDeps) BankingService {
+	if deps == nil {
+		return nil
+	}
+
+	return &bankingService{
+		authorizer:  deps.Authorizer,
+		maxPageSize: deps.MaxPageSize,
+		users: NewUserHandlers(&UserHandlersDeps{
+			DB:          deps.DB,
+			Logger:      deps.Logger,
+			IDGenerator: deps.IDGenerator,
+			Authorizer:  deps.Authorizer,
+		}).(*userHandlers),
+		accounts: NewAccountHandlers(&AccountHandlersDeps{
+			DB:                deps.DB,
+			Logger:            deps.Logger,
+			IDGenerator:       deps.IDGenerator,
+			Authorizer:        deps.Authorizer,
+			ApprovalThreshold: deps.ApprovalThreshold,
+			ApprovalTTL:       deps.ApprovalTTL,
+		}).(*accountHandlers),
+		transactions: NewTransactionHandlers(&TransactionHandlersDeps{
+			DB:                deps.DB,
+			Logger:            deps.Logger,
+			IDGenerator:       deps.IDGenerator,
+			Authorizer:        deps.Authorizer,
+			HeartbeatInterval: deps.HeartbeatInterval,
+			MaxStreamAge:      deps.MaxStreamAge,
+			SequenceInterval:  deps.SequenceInterval,
+		}).(*transactionHandlers),
+	}
+}
+
+// pageLimit checks the limit of a list request. Zero selects the repository
+// default.
+func (u *bankingService) pageLimit(limit int32) (int, error) {
+	if limit < 0 || int(limit) > u.maxPageSize {
+		return 0, domains.NewXError(fmt.Errorf("limit must be between 0 and %d, got %d", u.maxPageSize, limit), enums.BadRequest)
+	}
+
+	return int(limit), nil
+}
+
+// Close ends the StreamAccountEvents streams, for shutdown.
+func (u *bankingService) Close() {
+	u.transactions.Close()
+}
+
+// OnEvent implements workers.EventListener for StreamAccountEvents.
+func (u *bankingService) OnEvent(event *domains.Event) {
+	u.transactions.OnEvent(event)
+}
+
+func (u *bankingService) CreateUser(ctx context.Context, req *bankingpb.CreateUserRequest) (*bankingpb.User, error) {
+	if err := middlewares.CheckRoles(domains.PrincipalFromContext(ctx), middlewares.StaffRoles...); err != nil {
+		return nil, err
+	}
+
+	createReq := &domains.CreateUserRequest{
+		Name:              req.GetName(),
+		Email:             req.GetEmail(),
+		Phone:             req.GetPhone(),
+		DateOfBirth:       req.GetDateOfBirth(),
+		PreferredLanguage: req.GetPreferredLanguage(),
+	}
+	if address := req.GetAddress(); address != nil {
+		createReq.Address = &domains.Address{
+			Line1:      address.GetLine1(),
+			Line2:      address.GetLine2(),
+			City:       address.GetCity(),
+			PostalCode: address.GetPostalCode(),
+			Country:    address.GetCountry(),
+		}
+	}
+	if err := createReq.Validate(); err != nil {
+		return nil, domains.NewXError(err, enums.BadRequest)
+	}
+
+	user, err := u.users.createUser(ctx, createReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return toUserPB(toUserResp(user)), nil
+}
+
+func (u *bankingService) GetUser(ctx context.Context, req *bankingpb.GetUserRequest) (*bankingpb.User, error) {
+	if !middlewares.CanActOnUser(domains.PrincipalFromContext(ctx), req.GetUserId(), middlewares.ReadRoles...) {
+		return nil, domains.NewXError(errors.New("forbidden"), enums.Forbidden)
+	}
+
+	user, err := u.users.getUser(ctx, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	return toUserPB(user), nil
+}
+
+func (u *bankingService) ListUsers(ctx context.Context, req *bankingpb.ListUsersRequest) (*bankingpb.ListUsersResponse, error) {
+	if err := middlewares.CheckRoles(domains.PrincipalFromContext(ctx), middlewares.ReadRoles...); err != nil {
+		return nil, err
+	}
+
+	limit, err := u.pageLimit(req.GetLimit())
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := u.users.userRepositiory.GetUsers(ctx, u.users.db, &repositories.GetUsersArgs{
+		Cursor: req.GetCursor(),
+		Limit:  limit,
+	})
+	if err != nil {
+		return nil, domains.NewXError(err, enums.InternalError)
+	}
+
+	resp := &bankingpb.ListUsersResponse{}
+	for _, user := range users {
+		resp.Users = append(resp.Users, toUserPB(toUserResp(user)))
+	}
+	if len(users) != 0 {
+		resp.NextCursor = users[len(users)-1].UserID
+	}
+
+	return resp, nil
+}
+
+func (u *bankingService) CreateAccount(ctx context.Context, req *bankingpb.CreateAccountRequest) (*bankingpb.Account, error) {
+	principal := domains.PrincipalFromContext(ctx)
+	if err := middlewares.CheckRoles(principal, enums.Customer, enums.Operator, enums.Admin); err != nil {
+		return nil, err
+	}
+
+	createReq := &domains.CreateAccountRequest{
+		UserID:   req.GetUserId(),
+		Name:     req.GetName(),
+		Currency: req.GetCurrency(),
+	}
+	if err := createReq.Validate(); err != nil {
+		return nil, domains.NewXError(err, enums.BadRequest)
+	}
+
+	account, err := u.accounts.createAccount(ctx, principal, createReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return toAccountPB(account), nil
+}
+
+func (u *bankingService) GetAccount(ctx context.Context, req *bankingpb.GetAccountRequest) (*bankingpb.Account, error) {
+	if err := u.authorizer.CheckAccountOwnerOr(ctx, domains.PrincipalFromContext(ctx), req.GetAccountId(), middlewares.ReadRoles...); err != nil {
+		return nil, err
+	}
+
+	account, err := u.accounts.getAccount(ctx, req.GetAccountId())
+	if err != nil {
+		return nil, err
+	}
+
+	return toAccountPB(account), nil
+}
+
+func (u *bankingService) ListAccounts(ctx context.Context, req *bankingpb.ListAccountsRequest) (*bankingpb.ListAccountsResponse, error) {
+	principal := domains.PrincipalFromContext(ctx)
+	if req.GetUserId() == "" {
+		if err := middlewares.CheckRoles(principal, middlewares.ReadRoles...); err != nil {
+			return nil, err
+		}
+	} else if !middlewares.CanActOnUser(principal, req.GetUserId(), middlewares.ReadRoles...) {
+		return nil, domains.NewXError(errors.New("forbidden"), enums.Forbidden)
+	}
+
+	limit, err := u.pageLimit(req.GetLimit())
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := u.accounts.accountRepository.GetAccounts(ctx, u.accounts.db, &repositories.GetAccountsArgs{
+		UserID: req.GetUserId(),
+		Cursor: req.GetCursor(),
+		Limit:  limit,
+	})
+	if err != nil {
+		return nil, domains.NewXError(err, enums.InternalError)
+	}
+
+	resp := &bankingpb.ListAccountsResponse{}
+	for _, account := range accounts {
+		resp.Accounts = append(resp.Accounts, toAccountPB(account))
+	}
+	if len(accounts) != 0 {
+		resp.NextCursor = accounts[len(accounts)-1].AccountID
+	}
+
+	return resp, nil
+}
+
+func (u *bankingService) Deposit(ctx context.Context, req *bankingpb.DepositRequest) (*bankingpb.MoneyMovementResponse, error) {
+	if err := u.authorizer.CheckAccountOwnerOr(ctx, domains.PrincipalFromContext(ctx), req.GetAccountId(), middlewares.StaffRoles...); err != nil {
+		return nil, err
+	}
+
+	depositReq := &domains.DepositAccountRequest{
+		Amount:             req.GetAmount(),
+		TransactionDetails: toTransactionDetails(req.GetDetails()),
+	}
+	if err := depositReq.Validate(); err != nil {
+		return nil, domains.NewXError(err, enums.BadRequest)
+	}
+
+	var transactionID string
+	err := auditedTransaction(ctx, u.accounts.db, http.StatusOK, func(tx *gorm.DB) (err error) {
+		transactionID, err = u.accounts.moneyMovement.deposit(ctx, tx, req.GetAccountId(), depositReq)
+		return err
+	})
+	observeMoneyMovement(enums.Deposit, depositReq.Amount, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bankingpb.MoneyMovementResponse{
+		TransactionId: transactionID,
+		Status:        enums.Completed.String(),
+	}, nil
+}
+
+func (u *bankingService) Withdraw(ctx context.Context, req *bankingpb.WithdrawRequest) (*bankingpb.MoneyMovementResponse, error) {
+	if err := u.authorizer.CheckAccountOwnerOr(ctx, domains.PrincipalFromContext(ctx), req.GetAccountId(), middlewares.StaffRoles...); err != nil {
+		return nil, err
+	}
+
+	withdrawReq := &domains.WithdrawAccountRequest{
+		Amount:             req.GetAmount(),
+		TransactionDetails: toTransactionDetails(req.GetDetails()),
+	}
+	if err := withdrawReq.Validate(); err != nil {
+		return nil, domains.NewXError(err, enums.BadRequest)
+	}
+
+	var transactionID string
+	err := auditedTransaction(ctx, u.accounts.db, http.StatusOK, func(tx *gorm.DB) (err error) {
+		transactionID, err = u.accounts.moneyMovement.withdraw(ctx, tx, req.GetAccountId(), withdrawReq)
+		return err
+	})
+	observeMoneyMovement(enums.Withdrawal, withdrawReq.Amount, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bankingpb.MoneyMovementResponse{
+		TransactionId: transactionID,
+		Status:        enums.Completed.String(),
+	}, nil
+}
+
+func (u *bankingService) Transfer(ctx context.Context, req *bankingpb.TransferRequest) (*bankingpb.MoneyMovementResponse, error) {
+	principal := domains.PrincipalFromContext(ctx)
+	if err := u.authorizer.CheckAccountOwnerOr(ctx, principal, req.GetAccountId(), middlewares.StaffRoles...); err != nil {
+		return nil, err
+	}
+
+	transferReq := &domains.TransferAccountRequest{
+		ToAccountID:        req.GetToAccountId(),
+		Amount:             req.GetAmount(),
+		TransactionDetails: toTransactionDetails(req.GetDetails()),
+	}
+	if err := transferReq.Validate(); err != nil {
+		return nil, domains.NewXError(err, enums.BadRequest)
+	}
+
+	resp, err := u.accounts.transfer(ctx, principal, req.GetAccountId(), transferReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bankingpb.MoneyMovementResponse{
+		TransactionId: resp.TransactionID,
+		ApprovalId:    resp.ApprovalID,
+		Status:        resp.Status,
+	}, nil
+}
+
+func (u *bankingService) ListTransactions(ctx context.Context, req *bankingpb.ListTransactionsRequest) (*bankingpb.ListTransactionsResponse, error) {
+	if err := u.authorizer.CheckAccountOwnerOr(ctx, domains.PrincipalFromContext(ctx), req.GetAccountId(), middlewares.ReadRoles...); err != nil {
+		return nil, err
+	}
+
+	limit, err := u.pageLimit(req.GetLimit())
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := u.transactions.transactionRepository.GetTransactions(ctx, u.transactions.db, &repositories.GetTransactionsArgs{
+		AccountID:   req.GetAccountId(),
+		Reference:   req.GetReference(),
+		EndToEndID:  req.GetEndToEndId(),
+		Description: req.GetQuery(),
+		Tags:        req.GetTags(),
+		Cursor:      req.GetCursor(),
+		Limit:       limit,
+	})
+	if err != nil {
+		return nil, domains.NewXError(err, enums.BadRequest)
+	}
+
+	resp := &bankingpb.ListTransactionsResponse{}
+	for _, transaction := range transactions {
+		resp.Transactions = append(resp.Transactions, toTransactionPB(transaction))
+	}
+	if len(transactions) != 0 {
+		resp.NextCursor = transactions[len(transactions)-1].TransactionID
+	}
+
+	return resp, nil
+}
+
+// StreamAccountEvents follows the account like the Server-Sent Events
+// endpoint; gRPC keepalives replace its heartbeat comments.
+func (u *bankingService) StreamAccountEvents(req *bankingpb.StreamAccountEventsRequest, stream bankingpb.BankingService_StreamAccountEventsServer) error {
+	ctx := stream.Context()
+	accountID := req.GetAccountId()
+
+	if err := u.authorizer.CheckAccountOwnerOr(ctx, domains.PrincipalFromContext(ctx), accountID, middlewares.ReadRoles...); err != nil {
+		return err
+	}
+
+	afterTransactionID := req.GetAfterTransactionId()
+	if afterTransactionID != "" && !transactionIDRegexp.MatchString(afterTransactionID) {
+		return domains.NewXError(fmt.Errorf("invalid after_transaction_id %q", afterTransactionID), enums.BadRequest)
+	}
+
+	wake := u.transactions.streams.add(accountID)
+	defer u.transactions.streams.remove(accountID, wake)
+
+	// the stream follows commit order, so it resumes after the change feed
+	// position of the transaction rather than after its ID
+	var (
+		cursor int64
+		err    error
+	)
+	if afterTransactionID == "" {
+		cursor, err = u.transactions.changeRepository.GetLatestPosition(ctx, u.transactions.db)
+	} else {
+		cursor, err = u.transactions.transactionPosition(ctx, afterTransactionID)
+	}
+	if errors.Is(err, errUnknownCursor) {
+		return domains.NewXError(fmt.Errorf("unknown after_transaction_id %q", afterTransactionID), enums.BadRequest)
+	}
+	if err != nil {
+		return domains.NewXError(err, enums.InternalError)
+	}
+
+	err = u.transactions.followTransactions(ctx, accountID, cursor, wake, func(transactions []*streamedTransaction) error {
+		for _, transaction := range transactions {
+			if err := stream.Send(toTransactionPB(transaction.transaction)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func() error {
+		return nil
+	})
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+
+	return err
+}
+
+func toTransactionDetails(details *bankingpb.TransactionDetails) domains.TransactionDetails {
+	return domains.TransactionDetails{
+		Description: details.GetDescription(),
+		Reference:   details.GetReference(),
+		EndToEndID:  details.GetEndToEndId(),
+		Tags:        details.GetTags(),
+	}
+}
+
+func toUserPB(user *domains.User) *bankingpb.User {
+	userPB := &bankingpb.User{
+		Id:                user.ID,
+		Name:              user.Name,
+		Email:             user.Email,
+		Phone:             user.Phone,
+		DateOfBirth:       user.DateOfBirth,
+		PreferredLanguage: user.PreferredLanguage,
+		Status:            user.Status,
+		KycLevel:          user.KYCLevel,
+		AccountIds:        user.AccountIDs,
+		CreatedAt:         timestamppb.New(user.CreatedAt),
+		UpdatedAt:         timestamppb.New(user.UpdatedAt),
+	}
+	if user.Address != nil {
+		userPB.Address = &bankingpb.Address{
+			Line1:      user.Address.Line1,
+			Line2:      user.Address.Line2,
+			City:       user.Address.City,
+			PostalCode: user.Address.PostalCode,
+			Country:    user.Address.Country,
+		}
+	}
+	if user.ErasedAt != nil {
+		userPB.ErasedAt = timestamppb.New(*user.ErasedAt)
+	}
+
+	return userPB
+}
+
+func toAccountPB(account *models.Account) *bankingpb.Account {
+	return &bankingpb.Account{
+		AccountId: account.AccountID,
+		UserId:    account.UserID,
+		Name:      account.Name,
+		Currency:  account.Currency,
+		Balance:   account.Balance,
+		CreatedAt: timestamppb.New(account.CreatedAt),
+		UpdatedAt: timestamppb.New(account.UpdatedAt),
+	}
+}
+
+func toTransactionPB(transaction *models.Transaction) *bankingpb.Transaction {
+	return &bankingpb.Transaction{
+		TransactionId: transaction.TransactionID,
+		AccountId:     transaction.AccountID,
+		UserId:        transaction.UserID,
+		Amount:        transaction.Amount,
+		Balance:       transaction.Balance,
+		Type:          transaction.Type,
+		Status:        transaction.Status,
+		Description:   transaction.Description,
+		Reference:     transaction.Reference,
+		EndToEndId:    transaction.EndToEndID,
+		Tags:          transaction.Tags,
+		Metadata:      transaction.Metadata,
+		CreatedAt:     timestamppb.New(transaction.CreatedAt),
+		UpdatedAt:     timestamppb.New(transaction.UpdatedAt),
+	}
+}
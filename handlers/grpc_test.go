@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/middlewares"
+	"banking-service/proto/bankingpb"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBankingServiceRejectsUnboundedLimits(t *testing.T) {
+	service := NewBankingService(&BankingServiceDeps{
+		Logger:      zap.NewNop(),
+		Authorizer:  middlewares.NewAuthorizer(nil),
+		MaxPageSize: 500,
+	})
+	ctx := domains.WithPrincipal(context.Background(), &domains.Principal{
+		Kind:  enums.APIKeyPrincipal,
+		ID:    "test",
+		Role:  enums.Admin,
+		Owner: "test",
+	})
+
+	for _, limit := range []int32{-1, 501} {
+		calls := map[string]func() error{
+			"ListUsers": func() error {
+				_, err := service.ListUsers(ctx, &bankingpb.ListUsersRequest{Limit: limit})
+				return err
+			},
+			"ListAccounts": func() error {
+				_, err := service.ListAccounts(ctx, &bankingpb.ListAccountsRequest{Limit: limit})
+				return err
+			},
+			"ListTransactions": func() error {
+				_, err := service.ListTransactions(ctx, &bankingpb.ListTransactionsRequest{AccountId: testAccountID, Limit: limit})
+				return err
+			},
+		}
+		for name, call := range calls {
+			if code := status.Code(call()); code != codes.InvalidArgument {
+				t.Errorf("%s(limit %d) code = %s, want %s", name, limit, code, codes.InvalidArgument)
+			}
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/migrations"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	LivezPath  = "/livez"
+	ReadyzPath = "/readyz"
+)
+
+const (
+	healthOK          = "ok"
+	healthUnavailable = "unavailable"
+)
+
+var (
+	_ HealthHandlers = &healthHandlers{}
+)
+
+// WorkerHealth is implemented by the background workers.
+type WorkerHealth interface {
+	Health() *domains.WorkerStatus
+}
+
+type HealthHandlers interface {
+	RouteGroup(r *gin.Engine)
+
+	LivezHandler(*gin.Context)
+	ReadyzHandler(*gin.Context)
+	// Drain makes readiness fail from now on so load balancers stop sending
+	// requests before the server shuts down.
+	Drain()
+}
+
+type HealthHandlersDeps struct {
+	DB       *gorm.DB
+	Logger   *zap.Logger
+	Migrator *migrations.Migrator
+	Workers  []WorkerHealth
+	// Timeout bounds the dependency checks of a readiness request.
+	Timeout time.Duration
+}
+
+type healthHandlers struct {
+	db       *gorm.DB
+	logger   *zap.Logger
+	migrator *migrations.Migrator
+	workers  []WorkerHealth
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+func NewHealthHandlers(deps *HealthHandlersDeps) HealthHandlers {
+	if deps == nil {
+		return nil
+	}
+
+	return &healthHandlers{
+		db:       deps.DB,
+		logger:   deps.Logger,
+		migrator: deps.Migrator,
+		workers:  deps.Workers,
+		timeout:  deps.Timeout,
+	}
+}
+
+// RouteGroup registers public routes; add LivezPath and ReadyzPath to the
+// public paths of the authentication middleware.
+func (u *healthHandlers) RouteGroup(rg *gin.Engine) {
+	rg.GET(LivezPath, u.LivezHandler)
+	rg.GET(ReadyzPath, u.ReadyzHandler)
+}
+
+// LivezHandler reports that the process is serving requests. It checks no
+// dependency, so a database outage does not get the instance restarted.
+func (u *healthHandlers) LivezHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, &domains.HealthResponse{
+		Status: healthOK,
+	})
+}
+
+// ReadyzHandler reports whether the instance should receive traffic: it is
+// not draining, the database answers and its schema is at the version of
+// the binary. Worker statuses are included for information.
+func (u *healthHandlers) ReadyzHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), u.timeout)
+	defer cancel()
+
+	resp := &domains.HealthResponse{
+		Status: healthOK,
+		Checks: map[string]*domains.HealthCheck{
+			"shutdown":   u.checkDraining(),
+			"database":   u.checkDatabase(ctx),
+			"migrations": u.checkMigrations(ctx),
+		},
+		Workers: make([]*domains.WorkerStatus, 0, len(u.workers)),
+	}
+	for _, worker := range u.workers {
+		resp.Workers = append(resp.Workers, worker.Health())
+	}
+
+	for name, check := range resp.Checks {
+		if check.Status != healthOK {
+			u.logger.Warn("not ready", zap.String("check", name), zap.String("error", check.Error))
+			resp.Status = healthUnavailable
+			c.JSON(http.StatusServiceUnavailable, resp)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (u *healthHandlers) Drain() {
+	u.draining.Store(true)
+}
+
+func (u *healthHandlers) checkDraining() *domains.HealthCheck {
+	if u.draining.Load() {
+		return &domains.HealthCheck{Status: healthUnavailable, Error: "shutting down"}
+	}
+
+	return &domains.HealthCheck{Status: healthOK}
+}
+
+func (u *healthHandlers) checkDatabase(ctx context.Context) *domains.HealthCheck {
+	sqlDB, err := u.db.DB()
+	if err == nil {
+		err = sqlDB.PingContext(ctx)
+	}
+	if err != nil {
+		return &domains.HealthCheck{Status: healthUnavailable, Error: err.Error()}
+	}
+
+	return &domains.HealthCheck{Status: healthOK}
+}
+
+func (u *healthHandlers) checkMigrations(ctx context.Context) *domains.HealthCheck {
+	pending, err := u.migrator.Pending(ctx)
+	if err != nil {
+		return &domains.HealthCheck{Status: healthUnavailable, Error: err.Error()}
+	}
+	if len(pending) != 0 {
+		return &domains.HealthCheck{
+			Status: healthUnavailable,
+			Error:  fmt.Sprintf("%d pending migrations, first %d %s", len(pending), pending[0].Version, pending[0].Name),
+		}
+	}
+
+	return &domains.HealthCheck{Status: healthOK}
+}
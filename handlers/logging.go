@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"context"
+
+	"banking-service/domains"
+
+	"go.uber.org/zap"
+)
+
+// requestLogger returns logger with the request ID of ctx, to match the
+// access log entry of the request.
+func requestLogger(logger *zap.Logger, ctx context.Context) *zap.Logger {
+	return logger.With(zap.String("request_id", domains.RequestIDFromContext(ctx)))
+}
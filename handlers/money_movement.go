@@ -0,0 +1,466 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/metrics"
+	"banking-service/models"
+	"banking-service/repositories"
+	"banking-service/utilities"
+
+	"gorm.io/gorm"
+)
+
+// moneyMovement holds the balance changing operations shared by the account
+// handlers and the approval workflow. Every method runs inside the caller's
+// DB transaction and returns domains.XError.
+type moneyMovement struct {
+	idGenerator           utilities.SnowflakeIDGenerator
+	events                *eventEmitter
+	userRepository        repositories.UserRepositoryI
+	accountRepository     repositories.AccountRepositoryI
+	transactionRepository repositories.TransactionRepositoryI
+}
+
+func newMoneyMovement(idGenerator utilities.SnowflakeIDGenerator) *moneyMovement {
+	return &moneyMovement{
+		idGenerator:           idGenerator,
+		events:                newEventEmitter(idGenerator),
+		userRepository:        repositories.NewUserRepository(),
+		accountRepository:     repositories.NewAccountRepository(),
+		transactionRepository: repositories.NewTransactionRepository(),
+	}
+}
+
+// observeMoneyMovement counts a money movement once its DB transaction has
+// committed or rolled back; err is the error of the transaction.
+func observeMoneyMovement(txType enums.TransactionType, amount float64, err error) {
+	outcome := metrics.OutcomeCompleted
+	if err != nil {
+		outcome = metrics.OutcomeFailed
+		var xErr domains.XError
+		if errors.As(err, &xErr) && xErr.HTTPStatus() < http.StatusInternalServerError {
+			outcome = metrics.OutcomeRejected
+		}
+	}
+	observeMoneyMovementOutcome(txType, outcome, amount)
+}
+
+func observeMoneyMovementOutcome(txType enums.TransactionType, outcome string, amount float64) {
+	metrics.MoneyMovementsTotal.WithLabelValues(txType.String(), outcome).Inc()
+	metrics.MoneyMovementAmountTotal.WithLabelValues(txType.String(), outcome).Add(math.Abs(amount))
+}
+
+// deposit credits req.Amount to accountID and returns the transaction ID.
+func (m *moneyMovement) deposit(ctx context.Context, tx *gorm.DB, accountID string, req *domains.DepositAccountRequest) (string, error) {
+	account, err := m.accountRepository.GetAccount(ctx, tx, &repositories.GetAccountArgs{
+		AccountID: accountID,
+		ForUpdate: true,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", domains.NewXError(fmt.Errorf("account_id %s not found", accountID), enums.BadRequest)
+		}
+		return "", domains.NewXError(err, enums.InternalError)
+	}
+
+	if err := m.checkIncoming(ctx, tx, account, enums.Deposit, req.Amount); err != nil {
+		return "", err
+	}
+
+	before := toAccountState(account)
+	account.Balance += req.Amount
+	if err := m.accountRepository.Update(ctx, tx, account); err != nil {
+		return "", domains.NewXError(err, enums.InternalError)
+	}
+	recordAccountChange(ctx, account.AccountID, before, account)
+
+	transactionID := m.idGenerator.Next().String()
+	transaction := &models.Transaction{
+		TransactionID: transactionID,
+		UserID:        account.UserID,
+		AccountID:     account.AccountID,
+		Amount:        req.Amount,
+		Balance:       account.Balance,
+		Type:          enums.Deposit.String(),
+		Status:        enums.Completed.String(),
+		Description:   req.Description,
+		Reference:     req.Reference,
+		EndToEndID:    req.EndToEndID,
+		Tags:          req.Tags,
+		Metadata:      "{}",
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	if err := m.transactionRepository.Create(ctx, tx, transaction); err != nil {
+		return "", domains.NewXError(err, enums.InternalError)
+	}
+
+	if err := m.events.emit(ctx, tx, enums.FundsDeposited, account.AccountID, &domains.FundsMovedEvent{
+		TransactionID: transactionID,
+		AccountID:     account.AccountID,
+		UserID:        account.UserID,
+		Amount:        req.Amount,
+		Balance:       account.Balance,
+		Currency:      account.Currency,
+		Description:   req.Description,
+		Reference:     req.Reference,
+		EndToEndID:    req.EndToEndID,
+		Tags:          req.Tags,
+	}); err != nil {
+		return "", err
+	}
+
+	return transactionID, nil
+}
+
+// withdraw debits req.Amount from accountID and returns the transaction ID.
+func (m *moneyMovement) withdraw(ctx context.Context, tx *gorm.DB, accountID string, req *domains.WithdrawAccountRequest) (string, error) {
+	account, err := m.accountRepository.GetAccount(ctx, tx, &repositories.GetAccountArgs{
+		AccountID: accountID,
+		ForUpdate: true,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", domains.NewXError(fmt.Errorf("account_id %s not found", accountID), enums.BadRequest)
+		}
+		return "", domains.NewXError(err, enums.InternalError)
+	}
+
+	if err := m.checkOutgoing(ctx, tx, account, enums.Withdrawal, req.Amount); err != nil {
+		return "", err
+	}
+
+	if account.Balance-req.Amount < 0 {
+		return "", domains.NewXError(errors.New("insufficient balance"), enums.BadRequest)
+	}
+
+	before := toAccountState(account)
+	account.Balance = account.Balance - req.Amount
+	if err := m.accountRepository.Update(ctx, tx, account); err != nil {
+		return "", domains.NewXError(err, enums.InternalError)
+	}
+	recordAccountChange(ctx, account.AccountID, before, account)
+
+	transactionID := m.idGenerator.Next().String()
+	transaction := &models.Transaction{
+		TransactionID: transactionID,
+		UserID:        account.UserID,
+		AccountID:     account.AccountID,
+		Amount:        -req.Amount,
+		Balance:       account.Balance,
+		Type:          enums.Withdrawal.String(),
+		Status:        enums.Completed.String(),
+		Description:   req.Description,
+		Reference:     req.Reference,
+		EndToEndID:    req.EndToEndID,
+		Tags:          req.Tags,
+		Metadata:      "{}",
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	if err := m.transactionRepository.Create(ctx, tx, transaction); err != nil {
+		return "", domains.NewXError(err, enums.InternalError)
+	}
+
+	if err := m.events.emit(ctx, tx, enums.FundsWithdrawn, account.AccountID, &domains.FundsMovedEvent{
+		TransactionID: transactionID,
+		AccountID:     account.AccountID,
+		UserID:        account.UserID,
+		Amount:        -req.Amount,
+		Balance:       account.Balance,
+		Currency:      account.Currency,
+		Description:   req.Description,
+		Reference:     req.Reference,
+		EndToEndID:    req.EndToEndID,
+		Tags:          req.Tags,
+	}); err != nil {
+		return "", err
+	}
+
+	return transactionID, nil
+}
+
+var errSelfTransfer = errors.New("cannot transfer to the same account")
+
+// transfer moves req.Amount from accountID to req.ToAccountID and returns the
+// ID of the debit transaction. approvalID is set when the transfer runs after
+// a maker-checker approval.
+func (m *moneyMovement) transfer(ctx context.Context, tx *gorm.DB, accountID string, req *domains.TransferAccountRequest, approvalID string) (string, error) {
+	// both sides would be read with the same balance and the credit would
+	// overwrite the debit
+	if req.ToAccountID == accountID {
+		return "", domains.NewXError(errSelfTransfer, enums.BadRequest)
+	}
+
+	account, err := m.accountRepository.GetAccount(ctx, tx, &repositories.GetAccountArgs{
+		AccountID: accountID,
+		ForUpdate: true,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", domains.NewXError(fmt.Errorf("account_id %s not found", accountID), enums.BadRequest)
+		}
+		return "", domains.NewXError(err, enums.InternalError)
+	}
+
+	destinationAccount, err := m.accountRepository.GetAccount(ctx, tx, &repositories.GetAccountArgs{
+		AccountID: req.ToAccountID,
+		ForUpdate: true,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", domains.NewXError(fmt.Errorf("destination account_id %s not found", req.ToAccountID), enums.BadRequest)
+		}
+		return "", domains.NewXError(err, enums.InternalError)
+	}
+
+	// balances are plain numbers in the account currency; there is no
+	// conversion
+	if account.Currency != destinationAccount.Currency {
+		return "", domains.NewXError(fmt.Errorf("cannot transfer from a %s account to a %s account", account.Currency, destinationAccount.Currency), enums.BadRequest)
+	}
+
+	if err := m.checkOutgoing(ctx, tx, account, enums.Transfer, req.Amount); err != nil {
+		return "", err
+	}
+	if err := m.checkIncoming(ctx, tx, destinationAccount, enums.Transfer, req.Amount); err != nil {
+		return "", err
+	}
+
+	if account.Balance-req.Amount < 0 {
+		return "", domains.NewXError(errors.New("insufficient balance"), enums.BadRequest)
+	}
+
+	accountBefore := toAccountState(account)
+	account.Balance = account.Balance - req.Amount
+	if err := m.accountRepository.Update(ctx, tx, account); err != nil {
+		return "", domains.NewXError(err, enums.InternalError)
+	}
+	recordAccountChange(ctx, account.AccountID, accountBefore, account)
+
+	destinationBefore := toAccountState(destinationAccount)
+	destinationAccount.Balance = destinationAccount.Balance + req.Amount
+	if err := m.accountRepository.Update(ctx, tx, destinationAccount); err != nil {
+		return "", domains.NewXError(err, enums.InternalError)
+	}
+	recordAccountChange(ctx, destinationAccount.AccountID, destinationBefore, destinationAccount)
+
+	metadata := models.TransactionMetadata{
+		FromAccountID: account.AccountID,
+		ToAccountID:   destinationAccount.AccountID,
+		ApprovalID:    approvalID,
+	}
+
+	metadataBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return "", domains.NewXError(err, enums.InternalError)
+	}
+
+	transactionID := m.idGenerator.Next().String()
+	transaction := &models.Transaction{
+		TransactionID: transactionID,
+		UserID:        account.UserID,
+		AccountID:     accountID,
+		Amount:        -req.Amount,
+		Balance:       account.Balance,
+		Type:          enums.Transfer.String(),
+		Status:        enums.Completed.String(),
+		Description:   req.Description,
+		Reference:     req.Reference,
+		EndToEndID:    req.EndToEndID,
+		Tags:          req.Tags,
+		Metadata:      string(metadataBytes),
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	if err := m.transactionRepository.Create(ctx, tx, transaction); err != nil {
+		return "", domains.NewXError(err, enums.InternalError)
+	}
+
+	transactionDestination := &models.Transaction{
+		TransactionID: m.idGenerator.Next().String(),
+		UserID:        destinationAccount.UserID,
+		AccountID:     destinationAccount.AccountID,
+		Amount:        req.Amount,
+		Balance:       destinationAccount.Balance,
+		Type:          enums.Transfer.String(),
+		Status:        enums.Completed.String(),
+		Description:   req.Description,
+		Reference:     req.Reference,
+		EndToEndID:    req.EndToEndID,
+		Tags:          req.Tags,
+		Metadata:      string(metadataBytes),
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	if err := m.transactionRepository.Create(ctx, tx, transactionDestination); err != nil {
+		return "", domains.NewXError(err, enums.InternalError)
+	}
+
+	if err := m.events.emit(ctx, tx, enums.TransferCompleted, account.AccountID, &domains.TransferCompletedEvent{
+		TransactionID:            transactionID,
+		DestinationTransactionID: transactionDestination.TransactionID,
+		FromAccountID:            account.AccountID,
+		ToAccountID:              destinationAccount.AccountID,
+		Amount:                   req.Amount,
+		FromBalance:              account.Balance,
+		ToBalance:                destinationAccount.Balance,
+		Currency:                 account.Currency,
+		ApprovalID:               approvalID,
+		Description:              req.Description,
+		Reference:                req.Reference,
+		EndToEndID:               req.EndToEndID,
+		Tags:                     req.Tags,
+	}); err != nil {
+		return "", err
+	}
+
+	return transactionID, nil
+}
+
+// adjust posts a manual credit or debit on accountID. Adjustments are back
+// office corrections, so the KYC limits of the owner do not apply, but the
+// balance can still not go negative.
+func (m *moneyMovement) adjust(ctx context.Context, tx *gorm.DB, accountID string, req *domains.AdjustAccountRequest, approvalID string) (string, error) {
+	account, err := m.accountRepository.GetAccount(ctx, tx, &repositories.GetAccountArgs{
+		AccountID: accountID,
+		ForUpdate: true,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", domains.NewXError(fmt.Errorf("account_id %s not found", accountID), enums.BadRequest)
+		}
+		return "", domains.NewXError(err, enums.InternalError)
+	}
+
+	if account.Balance+req.Amount < 0 {
+		return "", domains.NewXError(errors.New("insufficient balance"), enums.BadRequest)
+	}
+
+	before := toAccountState(account)
+	account.Balance += req.Amount
+	if err := m.accountRepository.Update(ctx, tx, account); err != nil {
+		return "", domains.NewXError(err, enums.InternalError)
+	}
+	recordAccountChange(ctx, account.AccountID, before, account)
+
+	metadataBytes, err := json.Marshal(models.TransactionMetadata{
+		ReasonCode: req.ReasonCode,
+		ApprovalID: approvalID,
+	})
+	if err != nil {
+		return "", domains.NewXError(err, enums.InternalError)
+	}
+
+	transactionID := m.idGenerator.Next().String()
+	transaction := &models.Transaction{
+		TransactionID: transactionID,
+		UserID:        account.UserID,
+		AccountID:     account.AccountID,
+		Amount:        req.Amount,
+		Balance:       account.Balance,
+		Type:          enums.Adjustment.String(),
+		Status:        enums.Completed.String(),
+		Description:   req.Description,
+		Reference:     req.Reference,
+		EndToEndID:    req.EndToEndID,
+		Tags:          req.Tags,
+		Metadata:      string(metadataBytes),
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	if err := m.transactionRepository.Create(ctx, tx, transaction); err != nil {
+		return "", domains.NewXError(err, enums.InternalError)
+	}
+
+	if err := m.events.emit(ctx, tx, enums.AdjustmentPosted, account.AccountID, &domains.AdjustmentPostedEvent{
+		TransactionID: transactionID,
+		AccountID:     account.AccountID,
+		UserID:        account.UserID,
+		Amount:        req.Amount,
+		Balance:       account.Balance,
+		Currency:      account.Currency,
+		ReasonCode:    req.ReasonCode,
+		ApprovalID:    approvalID,
+		Description:   req.Description,
+		Reference:     req.Reference,
+		EndToEndID:    req.EndToEndID,
+		Tags:          req.Tags,
+	}); err != nil {
+		return "", err
+	}
+
+	return transactionID, nil
+}
+
+// checkIncoming rejects frozen accounts and applies the onboarding status
+// and KYC tier of the owner of account to money coming in.
+func (m *moneyMovement) checkIncoming(ctx context.Context, tx *gorm.DB, account *models.Account, txType enums.TransactionType, amount float64) error {
+	if err := checkNotFrozen(account); err != nil {
+		return err
+	}
+
+	owner, err := m.userRepository.GetUser(ctx, tx, &repositories.GetUserArgs{
+		UserID: account.UserID,
+	})
+	if err != nil {
+		return domains.NewXError(err, enums.InternalError)
+	}
+
+	if err := domains.CheckIncoming(owner.Status, owner.KYCLevel, txType, amount); err != nil {
+		return domains.NewXError(err, enums.BadRequest)
+	}
+
+	return nil
+}
+
+// checkOutgoing rejects frozen accounts and applies the onboarding status
+// and KYC tier of the owner of account to money going out. The owner row is
+// locked so concurrent withdrawals from different accounts cannot exceed the
+// daily limit together.
+func (m *moneyMovement) checkOutgoing(ctx context.Context, tx *gorm.DB, account *models.Account, txType enums.TransactionType, amount float64) error {
+	if err := checkNotFrozen(account); err != nil {
+		return err
+	}
+
+	owner, err := m.userRepository.GetUser(ctx, tx, &repositories.GetUserArgs{
+		UserID:    account.UserID,
+		ForUpdate: true,
+	})
+	if err != nil {
+		return domains.NewXError(err, enums.InternalError)
+	}
+
+	outflowToday, err := m.transactionRepository.GetOutflowTotal(ctx, tx, &repositories.GetOutflowTotalArgs{
+		UserID: owner.UserID,
+		Since:  time.Now().UTC().Truncate(24 * time.Hour),
+	})
+	if err != nil {
+		return domains.NewXError(err, enums.InternalError)
+	}
+
+	if err := domains.CheckOutgoing(owner.Status, owner.KYCLevel, txType, amount, outflowToday); err != nil {
+		return domains.NewXError(err, enums.BadRequest)
+	}
+
+	return nil
+}
+
+// checkNotFrozen returns domains.XError for frozen accounts. Adjustments skip
+// it so the back office can still correct a frozen account.
+func checkNotFrozen(account *models.Account) error {
+	if account.FrozenAt != nil {
+		return domains.NewXError(fmt.Errorf("account_id %s is frozen", account.AccountID), enums.BadRequest)
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"banking-service/enums"
+	"banking-service/models"
+	"banking-service/repositories"
+
+	"gorm.io/gorm"
+)
+
+// fakeOwner serves the owner of the tested account.
+type fakeOwner struct {
+	repositories.UserRepositoryI
+
+	user *models.User
+}
+
+func (f *fakeOwner) GetUser(context.Context, *gorm.DB, *repositories.GetUserArgs) (*models.User, error) {
+	return f.user, nil
+}
+
+// fakeOutflow serves what left the accounts of the owner today.
+type fakeOutflow struct {
+	repositories.TransactionRepositoryI
+
+	outflow float64
+	// since records the start of the day of the last outflow query.
+	since time.Time
+}
+
+func (f *fakeOutflow) GetOutflowTotal(_ context.Context, _ *gorm.DB, args *repositories.GetOutflowTotalArgs) (float64, error) {
+	f.since = args.Since
+	return f.outflow, nil
+}
+
+func newCheckedMoneyMovement(status, kycLevel string, outflow float64) (*moneyMovement, *fakeOutflow) {
+	owner := &fakeOwner{
+		user: &models.User{
+			UserID:   testUserID,
+			Status:   status,
+			KYCLevel: kycLevel,
+		},
+	}
+	outflows := &fakeOutflow{outflow: outflow}
+
+	return &moneyMovement{
+		userRepository:        owner,
+		transactionRepository: outflows,
+	}, outflows
+}
+
+func TestCheckOutgoing(t *testing.T) {
+	var (
+		verified  = enums.UserVerified.String()
+		none      = enums.KYCNone.String()
+		basic     = enums.KYCBasic.String()
+		full      = enums.KYCFull.String()
+		withdraw  = enums.Withdrawal
+		transfer  = enums.Transfer
+		allowed   = true
+		forbidden = false
+	)
+	tests := []struct {
+		name     string
+		status   string
+		kycLevel string
+		txType   enums.TransactionType
+		amount   float64
+		outflow  float64
+		frozen   bool
+		allowed  bool
+	}{
+		// only verified users move money out
+		{name: "pending", status: enums.UserPending.String(), kycLevel: full, txType: withdraw, amount: 1, allowed: forbidden},
+		{name: "verified", status: verified, kycLevel: full, txType: withdraw, amount: 1, allowed: allowed},
+		{name: "suspended", status: enums.UserSuspended.String(), kycLevel: full, txType: withdraw, amount: 1, allowed: forbidden},
+		{name: "closed", status: enums.UserClosed.String(), kycLevel: full, txType: transfer, amount: 1, allowed: forbidden},
+		{name: "unknown status", status: "Active", kycLevel: full, txType: withdraw, amount: 1, allowed: forbidden},
+		{name: "unknown kyc level", status: verified, kycLevel: "Partial", txType: withdraw, amount: 1, allowed: forbidden},
+		{name: "frozen account", status: verified, kycLevel: full, txType: withdraw, amount: 1, frozen: true, allowed: forbidden},
+
+		// no KYC forbids money going out
+		{name: "none withdrawal", status: verified, kycLevel: none, txType: withdraw, amount: 1, allowed: forbidden},
+		{name: "none transfer", status: verified, kycLevel: none, txType: transfer, amount: 1, allowed: forbidden},
+
+		// per transaction limits of basic KYC
+		{name: "basic withdrawal below the limit", status: verified, kycLevel: basic, txType: withdraw, amount: 999.99, allowed: allowed},
+		{name: "basic withdrawal at the limit", status: verified, kycLevel: basic, txType: withdraw, amount: 1000, allowed: allowed},
+		{name: "basic withdrawal above the limit", status: verified, kycLevel: basic, txType: withdraw, amount: 1000.01, allowed: forbidden},
+		{name: "basic transfer below the limit", status: verified, kycLevel: basic, txType: transfer, amount: 999.99, allowed: allowed},
+		{name: "basic transfer at the limit", status: verified, kycLevel: basic, txType: transfer, amount: 1000, allowed: allowed},
+		{name: "basic transfer above the limit", status: verified, kycLevel: basic, txType: transfer, amount: 1000.01, allowed: forbidden},
+
+		// the daily outflow of basic KYC counts what already left today
+		{name: "basic withdrawal below the daily outflow", status: verified, kycLevel: basic, txType: withdraw, amount: 1000, outflow: 999.99, allowed: allowed},
+		{name: "basic withdrawal at the daily outflow", status: verified, kycLevel: basic, txType: withdraw, amount: 1000, outflow: 1000, allowed: allowed},
+		{name: "basic withdrawal above the daily outflow", status: verified, kycLevel: basic, txType: withdraw, amount: 1000, outflow: 1000.01, allowed: forbidden},
+		{name: "basic transfer below the daily outflow", status: verified, kycLevel: basic, txType: transfer, amount: 500, outflow: 1499.99, allowed: allowed},
+		{name: "basic transfer at the daily outflow", status: verified, kycLevel: basic, txType: transfer, amount: 500, outflow: 1500, allowed: allowed},
+		{name: "basic transfer above the daily outflow", status: verified, kycLevel: basic, txType: transfer, amount: 500, outflow: 1500.01, allowed: forbidden},
+		{name: "basic daily outflow already used up", status: verified, kycLevel: basic, txType: transfer, amount: 0.01, outflow: 2000, allowed: forbidden},
+
+		// full KYC has no limit
+		{name: "full withdrawal", status: verified, kycLevel: full, txType: withdraw, amount: 1e9, outflow: 1e12, allowed: allowed},
+		{name: "full transfer", status: verified, kycLevel: full, txType: transfer, amount: 1e9, outflow: 1e12, allowed: allowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, outflows := newCheckedMoneyMovement(tt.status, tt.kycLevel, tt.outflow)
+			account := &models.Account{AccountID: testAccountID, UserID: testUserID}
+			if tt.frozen {
+				frozenAt := time.Now()
+				account.FrozenAt = &frozenAt
+			}
+
+			err := m.checkOutgoing(context.Background(), nil, account, tt.txType, tt.amount)
+			if (err == nil) != tt.allowed {
+				t.Fatalf("checkOutgoing(%s %v after %v) error = %v, want allowed %v", tt.txType, tt.amount, tt.outflow, err, tt.allowed)
+			}
+			if tt.frozen {
+				return
+			}
+			if midnight := time.Now().UTC().Truncate(24 * time.Hour); !outflows.since.Equal(midnight) {
+				t.Fatalf("outflow counted since %s, want %s", outflows.since, midnight)
+			}
+		})
+	}
+}
+
+func TestCheckIncoming(t *testing.T) {
+	var (
+		verified  = enums.UserVerified.String()
+		none      = enums.KYCNone.String()
+		basic     = enums.KYCBasic.String()
+		full      = enums.KYCFull.String()
+		deposit   = enums.Deposit
+		transfer  = enums.Transfer
+		allowed   = true
+		forbidden = false
+	)
+	tests := []struct {
+		name     string
+		status   string
+		kycLevel string
+		txType   enums.TransactionType
+		amount   float64
+		frozen   bool
+		allowed  bool
+	}{
+		// pending users may be funded before verification
+		{name: "pending", status: enums.UserPending.String(), kycLevel: none, txType: deposit, amount: 1, allowed: allowed},
+		{name: "verified", status: verified, kycLevel: none, txType: deposit, amount: 1, allowed: allowed},
+		{name: "suspended", status: enums.UserSuspended.String(), kycLevel: full, txType: deposit, amount: 1, allowed: forbidden},
+		{name: "closed", status: enums.UserClosed.String(), kycLevel: full, txType: transfer, amount: 1, allowed: forbidden},
+		{name: "unknown status", status: "Active", kycLevel: full, txType: deposit, amount: 1, allowed: forbidden},
+		{name: "unknown kyc level", status: verified, kycLevel: "Partial", txType: deposit, amount: 1, allowed: forbidden},
+		{name: "frozen account", status: verified, kycLevel: full, txType: transfer, amount: 1, frozen: true, allowed: forbidden},
+
+		{name: "none deposit below the limit", status: verified, kycLevel: none, txType: deposit, amount: 999.99, allowed: allowed},
+		{name: "none deposit at the limit", status: verified, kycLevel: none, txType: deposit, amount: 1000, allowed: allowed},
+		{name: "none deposit above the limit", status: verified, kycLevel: none, txType: deposit, amount: 1000.01, allowed: forbidden},
+		{name: "basic deposit below the limit", status: verified, kycLevel: basic, txType: deposit, amount: 9999.99, allowed: allowed},
+		{name: "basic deposit at the limit", status: verified, kycLevel: basic, txType: deposit, amount: 10000, allowed: allowed},
+		{name: "basic deposit above the limit", status: verified, kycLevel: basic, txType: deposit, amount: 10000.01, allowed: forbidden},
+		{name: "full deposit", status: verified, kycLevel: full, txType: deposit, amount: 1e9, allowed: allowed},
+
+		// incoming transfers are not limited by the deposit limit
+		{name: "none transfer above the deposit limit", status: verified, kycLevel: none, txType: transfer, amount: 1000.01, allowed: allowed},
+		{name: "basic transfer above the deposit limit", status: verified, kycLevel: basic, txType: transfer, amount: 10000.01, allowed: allowed},
+		{name: "pending transfer", status: enums.UserPending.String(), kycLevel: basic, txType: transfer, amount: 1, allowed: allowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newCheckedMoneyMovement(tt.status, tt.kycLevel, 0)
+			account := &models.Account{AccountID: testAccountID, UserID: testUserID}
+			if tt.frozen {
+				frozenAt := time.Now()
+				account.FrozenAt = &frozenAt
+			}
+
+			err := m.checkIncoming(context.Background(), nil, account, tt.txType, tt.amount)
+			if (err == nil) != tt.allowed {
+				t.Fatalf("checkIncoming(%s %v) error = %v, want allowed %v", tt.txType, tt.amount, err, tt.allowed)
+			}
+		})
+	}
+}
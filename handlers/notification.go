@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/hub"
+	"banking-service/middlewares"
+	"banking-service/repositories"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	_ NotificationHandlers = &notificationHandlers{}
+)
+
+type NotificationHandlers interface {
+	RouteGroup(r *gin.Engine)
+
+	ConnectHandler(*gin.Context)
+}
+
+type NotificationHandlersDeps struct {
+	DB         *gorm.DB
+	Logger     *zap.Logger
+	Hub        *hub.Hub
+	Authorizer *middlewares.Authorizer
+}
+
+type notificationHandlers struct {
+	db                *gorm.DB
+	logger            *zap.Logger
+	hub               *hub.Hub
+	authorizer        *middlewares.Authorizer
+	upgrader          websocket.Upgrader
+	accountRepository repositories.AccountRepositoryI
+}
+
+func NewNotificationHandlers(deps *NotificationHandlersDeps) NotificationHandlers {
+	if deps == nil {
+		return nil
+	}
+
+	return &notificationHandlers{
+		db:         deps.DB,
+		logger:     deps.Logger,
+		hub:        deps.Hub,
+		authorizer: deps.Authorizer,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			// credentials never come from cookies, so a cross-origin page
+			// cannot connect on behalf of a user
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+		accountRepository: repositories.NewAccountRepository(),
+	}
+}
+
+func (u *notificationHandlers) RouteGroup(rg *gin.Engine) {
+	rg.GET("/ws", u.authorizer.Roles(append([]enums.Role{enums.Customer}, middlewares.ReadRoles...)...), u.ConnectHandler)
+}
+
+// ConnectHandler upgrades to a WebSocket on which the caller subscribes to
+// accounts and receives their events; see the hub package for the messages.
+func (u *notificationHandlers) ConnectHandler(c *gin.Context) {
+	principal := domains.GetPrincipal(c)
+
+	infos, err := u.hub.Reserve(principal)
+	if err != nil {
+		c.JSON(http.StatusTooManyRequests, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	ws, err := u.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// the upgrader already wrote the error response
+		requestLogger(u.logger, c.Request.Context()).Debug("websocket upgrade error", zap.Error(err))
+		u.hub.Release(infos)
+		return
+	}
+
+	u.hub.Serve(c.Request.Context(), ws, infos, func(ctx context.Context, accountIDs []string) error {
+		return u.authorizeSubscription(ctx, principal, accountIDs)
+	})
+}
+
+// authorizeSubscription lets customers subscribe to their own accounts and
+// the read roles to any account.
+func (u *notificationHandlers) authorizeSubscription(ctx context.Context, principal *domains.Principal, accountIDs []string) error {
+	for _, accountID := range accountIDs {
+		account, err := u.accountRepository.GetAccount(ctx, u.db, &repositories.GetAccountArgs{
+			AccountID: accountID,
+		})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				if principal.HasRole(middlewares.ReadRoles...) {
+					return fmt.Errorf("account_id %s not found", accountID)
+				}
+				return errors.New("forbidden")
+			}
+			return err
+		}
+		if !middlewares.CanActOnUser(principal, account.UserID, middlewares.ReadRoles...) {
+			return errors.New("forbidden")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	OpenAPIPath = "/openapi.json"
+	DocsPath    = "/docs"
+)
+
+// docsPage renders the specification with Swagger UI.
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Banking Service API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "` + OpenAPIPath + `", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>`
+
+var (
+	_ OpenAPIHandlers = &openAPIHandlers{}
+)
+
+type OpenAPIHandlers interface {
+	RouteGroup(r *gin.Engine)
+
+	GetSpecHandler(c *gin.Context)
+	DocsHandler(c *gin.Context)
+}
+
+type OpenAPIHandlersDeps struct {
+	Spec   *openapi3.T
+	Logger *zap.Logger
+}
+
+type openAPIHandlers struct {
+	spec   *openapi3.T
+	logger *zap.Logger
+}
+
+func NewOpenAPIHandlers(deps *OpenAPIHandlersDeps) OpenAPIHandlers {
+	if deps == nil {
+		return nil
+	}
+
+	return &openAPIHandlers{
+		spec:   deps.Spec,
+		logger: deps.Logger,
+	}
+}
+
+// RouteGroup registers public routes; add OpenAPIPath and DocsPath to the
+// public paths of the authentication middleware.
+func (u *openAPIHandlers) RouteGroup(rg *gin.Engine) {
+	rg.GET(OpenAPIPath, u.GetSpecHandler)
+	rg.GET(DocsPath, u.DocsHandler)
+}
+
+func (u *openAPIHandlers) GetSpecHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, u.spec)
+}
+
+func (u *openAPIHandlers) DocsHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/hub"
+	"banking-service/middlewares"
+	"banking-service/openapi"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+const testAccountID = "fde7f07a-fd12-493c-83a9-7bec2644c4c2"
+
+// undocumentedPaths serve the specification itself.
+var undocumentedPaths = map[string]bool{
+	OpenAPIPath: true,
+	DocsPath:    true,
+}
+
+var ginParamRegexp = regexp.MustCompile(`:([A-Za-z]+)`)
+
+// newTestRouter registers every REST route as main does, behind the OpenAPI
+// validator with response validation. Requests run as an admin and must not
+// reach the database.
+func newTestRouter(t *testing.T, logger *zap.Logger) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	validateOpenAPI, err := middlewares.ValidateOpenAPI(&middlewares.OpenAPIDeps{
+		Spec:              spec,
+		Logger:            logger,
+		ValidateResponses: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		domains.SetPrincipal(c, &domains.Principal{
+			Kind:  enums.APIKeyPrincipal,
+			ID:    "test",
+			Role:  enums.Admin,
+			Owner: "test",
+		})
+	})
+	router.Use(validateOpenAPI)
+
+	authorizer := middlewares.NewAuthorizer(nil)
+	notificationHub := hub.NewHub(&hub.HubDeps{Logger: logger})
+	NewOpenAPIHandlers(&OpenAPIHandlersDeps{Spec: spec, Logger: logger}).RouteGroup(router)
+	NewAuthHandlers(&AuthHandlersDeps{Logger: logger, Authorizer: authorizer}).RouteGroup(router)
+	NewAccountHandlers(&AccountHandlersDeps{Logger: logger, Authorizer: authorizer, ApprovalThreshold: 10000}).RouteGroup(router)
+	NewUserHandlers(&UserHandlersDeps{Logger: logger, Authorizer: authorizer}).RouteGroup(router)
+	NewTransactionHandlers(&TransactionHandlersDeps{Logger: logger, Authorizer: authorizer}).RouteGroup(router)
+	NewApprovalHandlers(&ApprovalHandlersDeps{Logger: logger, Authorizer: authorizer}).RouteGroup(router)
+	NewAuditLogHandlers(&AuditLogHandlersDeps{Logger: logger, Authorizer: authorizer}).RouteGroup(router)
+	NewWebhookHandlers(&WebhookHandlersDeps{Logger: logger, Authorizer: authorizer}).RouteGroup(router)
+	NewNotificationHandlers(&NotificationHandlersDeps{Logger: logger, Hub: notificationHub, Authorizer: authorizer}).RouteGroup(router)
+	NewChangeHandlers(&ChangeHandlersDeps{Logger: logger, Authorizer: authorizer}).RouteGroup(router)
+	NewChatHandlers(&ChatHandlersDeps{Logger: logger, Authorizer: authorizer, Hub: notificationHub}).RouteGroup(router)
+	NewHealthHandlers(&HealthHandlersDeps{Logger: logger}).RouteGroup(router)
+
+	return router
+}
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	spec, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := newTestRouter(t, zap.NewNop())
+
+	routes := make(map[string]bool)
+	for _, route := range router.Routes() {
+		if undocumentedPaths[route.Path] {
+			continue
+		}
+		key := route.Method + " " + ginParamRegexp.ReplaceAllString(route.Path, "{$1}")
+		routes[key] = true
+		if path := spec.Paths.Find(ginParamRegexp.ReplaceAllString(route.Path, "{$1}")); path == nil || path.GetOperation(route.Method) == nil {
+			t.Errorf("route %s is not documented", key)
+		}
+	}
+
+	for path, item := range spec.Paths.Map() {
+		for method := range item.Operations() {
+			if !routes[method+" "+path] {
+				t.Errorf("documented operation %s %s has no route", method, path)
+			}
+		}
+	}
+}
+
+func TestOpenAPIValidatesResponses(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header map[string]string
+		body   string
+		status int
+	}{
+		{
+			name:   "liveness",
+			method: http.MethodGet,
+			path:   LivezPath,
+			status: http.StatusOK,
+		},
+		{
+			name:   "self transfer",
+			method: http.MethodPost,
+			path:   "/accounts/" + testAccountID + "/transfer",
+			header: map[string]string{"Content-Type": "application/json"},
+			body:   `{"to_account_id":"` + testAccountID + `","amount":10}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid Last-Event-ID",
+			method: http.MethodGet,
+			path:   "/accounts/" + testAccountID + "/events",
+			header: map[string]string{"Last-Event-ID": "abc"},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "request rejected by the specification",
+			method: http.MethodPost,
+			path:   "/accounts/" + testAccountID + "/transfer",
+			header: map[string]string{"Content-Type": "application/json"},
+			body:   `{"amount":10}`,
+			status: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.ErrorLevel)
+			router := newTestRouter(t, zap.New(core))
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body %s", rec.Code, tt.status, rec.Body.String())
+			}
+			for _, entry := range logs.All() {
+				t.Errorf("%s: %v", entry.Message, entry.ContextMap())
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/middlewares"
+	"banking-service/models"
+	"banking-service/utilities"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	_ Operations = &operations{}
+)
+
+// Operations exposes the back office operations of the handlers outside of
+// HTTP, for bankctl. Every method acts as the principal of ctx, see
+// domains.WithPrincipal, and returns domains.XError.
+type Operations interface {
+	CreateUser(ctx context.Context, req *domains.CreateUserRequest) (*domains.User, error)
+	CreateAccount(ctx context.Context, req *domains.CreateAccountRequest) (*domains.Account, error)
+	AdjustAccount(ctx context.Context, accountID string, req *domains.AdjustAccountRequest) (*domains.AdjustAccountResponse, error)
+	FreezeAccount(ctx context.Context, accountID, reason string) (*domains.Account, error)
+	UnfreezeAccount(ctx context.Context, accountID string) (*domains.Account, error)
+}
+
+type OperationsDeps struct {
+	DB                *gorm.DB
+	Logger            *zap.Logger
+	IDGenerator       utilities.SnowflakeIDGenerator
+	ApprovalThreshold float64
+	ApprovalTTL       time.Duration
+}
+
+type operations struct {
+	users    *userHandlers
+	accounts *accountHandlers
+}
+
+func NewOperations(deps *OperationsDeps) Operations {
+	if deps == nil {
+		return nil
+	}
+
+	return &operations{
+		users: NewUserHandlers(&UserHandlersDeps{
+			DB:          deps.DB,
+			Logger:      deps.Logger,
+			IDGenerator: deps.IDGenerator,
+		}).(*userHandlers),
+		accounts: NewAccountHandlers(&AccountHandlersDeps{
+			DB:                deps.DB,
+			Logger:            deps.Logger,
+			IDGenerator:       deps.IDGenerator,
+			ApprovalThreshold: deps.ApprovalThreshold,
+			ApprovalTTL:       deps.ApprovalTTL,
+		}).(*accountHandlers),
+	}
+}
+
+func (u *operations) CreateUser(ctx context.Context, req *domains.CreateUserRequest) (*domains.User, error) {
+	if err := middlewares.CheckRoles(domains.PrincipalFromContext(ctx), middlewares.StaffRoles...); err != nil {
+		return nil, err
+	}
+	if err := req.Validate(); err != nil {
+		return nil, domains.NewXError(err, enums.BadRequest)
+	}
+
+	user, err := u.users.createUser(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return toUserResp(user), nil
+}
+
+func (u *operations) CreateAccount(ctx context.Context, req *domains.CreateAccountRequest) (*domains.Account, error) {
+	if err := req.Validate(); err != nil {
+		return nil, domains.NewXError(err, enums.BadRequest)
+	}
+
+	account, err := u.accounts.createAccount(ctx, domains.PrincipalFromContext(ctx), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return toAccountResp(account), nil
+}
+
+func (u *operations) AdjustAccount(ctx context.Context, accountID string, req *domains.AdjustAccountRequest) (*domains.AdjustAccountResponse, error) {
+	principal := domains.PrincipalFromContext(ctx)
+	if err := middlewares.CheckRoles(principal, middlewares.StaffRoles...); err != nil {
+		return nil, err
+	}
+	if err := req.Validate(); err != nil {
+		return nil, domains.NewXError(err, enums.BadRequest)
+	}
+
+	return u.accounts.adjust(ctx, principal, accountID, req)
+}
+
+func (u *operations) FreezeAccount(ctx context.Context, accountID, reason string) (*domains.Account, error) {
+	if err := middlewares.CheckRoles(domains.PrincipalFromContext(ctx), middlewares.StaffRoles...); err != nil {
+		return nil, err
+	}
+	if reason == "" {
+		return nil, domains.NewXError(errors.New("missing reason"), enums.BadRequest)
+	}
+
+	account, err := u.accounts.freeze(ctx, accountID, true, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	return toAccountResp(account), nil
+}
+
+func (u *operations) UnfreezeAccount(ctx context.Context, accountID string) (*domains.Account, error) {
+	if err := middlewares.CheckRoles(domains.PrincipalFromContext(ctx), middlewares.StaffRoles...); err != nil {
+		return nil, err
+	}
+
+	account, err := u.accounts.freeze(ctx, accountID, false, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return toAccountResp(account), nil
+}
+
+// TransactionResponse returns the API representation of transaction.
+func TransactionResponse(transaction *models.Transaction) *domains.Transaction {
+	return toTransactionResp(transaction)
+}
+
+// TransactionsResponse returns the API representation of a page of
+// transactions.
+func TransactionsResponse(transactions models.Transactions) *domains.GetTransactionsResp {
+	return toGetTransactionsResp(transactions)
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/models"
+	"banking-service/repositories"
+	"banking-service/utilities"
+
+	"gorm.io/gorm"
+)
+
+// eventEmitter writes domain events to the outbox. emit must be called with
+// the DB transaction that makes the change so the event is stored if and only
+// if the change commits; the outbox relay delivers it afterwards.
+type eventEmitter struct {
+	idGenerator           utilities.SnowflakeIDGenerator
+	outboxEventRepository repositories.OutboxEventRepositoryI
+}
+
+func newEventEmitter(idGenerator utilities.SnowflakeIDGenerator) *eventEmitter {
+	return &eventEmitter{
+		idGenerator:           idGenerator,
+		outboxEventRepository: repositories.NewOutboxEventRepository(),
+	}
+}
+
+// emit returns domains.XError.
+func (e *eventEmitter) emit(ctx context.Context, tx *gorm.DB, eventType enums.EventType, aggregateID string, payload interface{}) error {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return domains.NewXError(err, enums.InternalError)
+	}
+
+	if err := e.outboxEventRepository.Create(ctx, tx, &models.OutboxEvent{
+		EventID:     e.idGenerator.Next().String(),
+		EventType:   eventType.String(),
+		AggregateID: aggregateID,
+		Payload:     string(payloadBytes),
+		CreatedAt:   time.Now(),
+	}); err != nil {
+		return domains.NewXError(err, enums.InternalError)
+	}
+
+	return nil
+}
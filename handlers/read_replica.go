@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"banking-service/metrics"
+	"banking-service/utilities"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ConsistencyTokenHeader carries the log position of the primary after a
+// write. Sending it back on a read keeps the read off replicas that have not
+// replayed the write yet.
+const ConsistencyTokenHeader = "X-Consistency-Token"
+
+const currentLSNQuery = "SELECT pg_current_wal_lsn()::text"
+
+// ReplicaStatus is implemented by workers.ReplicaMonitor.
+type ReplicaStatus interface {
+	// Fresh reports whether the replica is within the allowed lag.
+	Fresh() bool
+	// CaughtUp reports whether the replica is fresh and has replayed the
+	// primary up to lsn.
+	CaughtUp(lsn uint64) bool
+}
+
+// readRouter picks the database serving a read: the replica when one is
+// configured and fresh enough for the request, the primary otherwise.
+type readRouter struct {
+	primary *gorm.DB
+	replica *gorm.DB
+	status  ReplicaStatus
+	logger  *zap.Logger
+}
+
+func newReadRouter(primary *gorm.DB, replica *gorm.DB, status ReplicaStatus, logger *zap.Logger) *readRouter {
+	// a replica nobody watches could be arbitrarily stale
+	if status == nil {
+		replica = nil
+	}
+
+	return &readRouter{
+		primary: primary,
+		replica: replica,
+		status:  status,
+		logger:  logger,
+	}
+}
+
+func (r *readRouter) db(c *gin.Context) *gorm.DB {
+	if r.replica == nil {
+		return r.primary
+	}
+
+	token := c.GetHeader(ConsistencyTokenHeader)
+	if token == "" {
+		if !r.status.Fresh() {
+			return r.usePrimary(metrics.ReasonReplicaLagging)
+		}
+		return r.useReplica()
+	}
+
+	lsn, ok := utilities.ParseLSN(token)
+	if !ok {
+		return r.usePrimary(metrics.ReasonInvalidToken)
+	}
+	if !r.status.CaughtUp(lsn) {
+		return r.usePrimary(metrics.ReasonReplicaBehindToken)
+	}
+
+	return r.useReplica()
+}
+
+func (r *readRouter) useReplica() *gorm.DB {
+	metrics.ReadsTotal.WithLabelValues(metrics.TargetReplica, "").Inc()
+	return r.replica
+}
+
+func (r *readRouter) usePrimary(reason string) *gorm.DB {
+	metrics.ReadsTotal.WithLabelValues(metrics.TargetPrimary, reason).Inc()
+	return r.primary
+}
+
+// markWrite sets the consistency token of a response to a committed write.
+// Without a replica every read sees the write, so no token is needed.
+func (r *readRouter) markWrite(c *gin.Context) {
+	if r.replica == nil {
+		return
+	}
+
+	var lsn string
+	if err := r.primary.WithContext(c.Request.Context()).Raw(currentLSNQuery).Scan(&lsn).Error; err != nil {
+		requestLogger(r.logger, c.Request.Context()).Warn("get consistency token error", zap.Error(err))
+		return
+	}
+	c.Header(ConsistencyTokenHeader, lsn)
+}
@@ -3,12 +3,16 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"banking-service/domains"
+	"banking-service/middlewares"
+	"banking-service/models"
 	"banking-service/repositories"
 	"banking-service/utilities"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
@@ -18,21 +22,44 @@ var (
 
 type TransactionHandlers interface {
 	RouteGroup(r *gin.Engine)
+	OnEvent(event *domains.Event)
+	Close()
 
 	GetAccountTransactionsHandler(c *gin.Context)
+	StreamAccountEventsHandler(c *gin.Context)
 }
 
 type TransactionHandlersDeps struct {
 	DB          *gorm.DB
+	Logger      *zap.Logger
 	IDGenerator utilities.SnowflakeIDGenerator
+	Authorizer  *middlewares.Authorizer
+	// HeartbeatInterval and MaxStreamAge apply to the event streams.
+	HeartbeatInterval time.Duration
+	MaxStreamAge      time.Duration
+	// SequenceInterval is the change sequencer interval, after which a
+	// streamed transaction has its position.
+	SequenceInterval time.Duration
+	// ReadDB and Replica route the transaction list to a read replica, as for
+	// accounts.
+	ReadDB  *gorm.DB
+	Replica ReplicaStatus
 }
 
 type transactionHandlers struct {
 	db                    *gorm.DB
+	logger                *zap.Logger
+	reads                 *readRouter
 	idGenerator           utilities.SnowflakeIDGenerator
+	authorizer            *middlewares.Authorizer
+	heartbeatInterval     time.Duration
+	maxStreamAge          time.Duration
+	sequenceInterval      time.Duration
+	streams               *accountStreams
 	userRepositiory       repositories.UserRepositoryI
 	accountRepository     repositories.AccountRepositoryI
 	transactionRepository repositories.TransactionRepositoryI
+	changeRepository      repositories.ChangeRepositoryI
 }
 
 func NewTransactionHandlers(deps *TransactionHandlersDeps) TransactionHandlers {
@@ -42,22 +69,49 @@ func NewTransactionHandlers(deps *TransactionHandlersDeps) TransactionHandlers {
 
 	return &transactionHandlers{
 		db:                    deps.DB,
+		logger:                deps.Logger,
+		reads:                 newReadRouter(deps.DB, deps.ReadDB, deps.Replica, deps.Logger),
 		idGenerator:           deps.IDGenerator,
+		authorizer:            deps.Authorizer,
+		heartbeatInterval:     deps.HeartbeatInterval,
+		maxStreamAge:          deps.MaxStreamAge,
+		sequenceInterval:      deps.SequenceInterval,
+		streams:               newAccountStreams(),
 		userRepositiory:       repositories.NewUserRepository(),
 		accountRepository:     repositories.NewAccountRepository(),
 		transactionRepository: repositories.NewTransactionRepository(),
+		changeRepository:      repositories.NewChangeRepository(),
 	}
 }
 
 func (u *transactionHandlers) RouteGroup(rg *gin.Engine) {
-	rg.GET("/accounts/:accountID/transactions", u.GetAccountTransactionsHandler)
+	rg.GET("/accounts/:accountID/transactions", u.authorizer.AccountOwnerOr(middlewares.ReadRoles...), u.GetAccountTransactionsHandler)
+	rg.GET("/accounts/:accountID/events", u.authorizer.AccountOwnerOr(middlewares.ReadRoles...), u.StreamAccountEventsHandler)
 }
 
 func (u *transactionHandlers) GetAccountTransactionsHandler(c *gin.Context) {
 	ctx := c.Request.Context()
-	accountIDStr := c.Param("accountID")
+
+	args, err := getTransactionsArgsFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+	args.AccountID = c.Param("accountID")
+
+	transactions, err := u.transactionRepository.GetTransactions(ctx, u.reads.db(c), args)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, toGetTransactionsResp(transactions))
+}
+
+// getTransactionsArgsFromQuery parses the pagination and search parameters
+// shared by every transaction listing endpoint.
+func getTransactionsArgsFromQuery(c *gin.Context) (*repositories.GetTransactionsArgs, error) {
 	limitStr := c.Query("limit")
-	cursorStr := c.Query("cursor")
 
 	var (
 		limit int
@@ -66,47 +120,52 @@ func (u *transactionHandlers) GetAccountTransactionsHandler(c *gin.Context) {
 	if limitStr != "" {
 		limit, err = strconv.Atoi(limitStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, domains.ErrorResp{
-				Message: err.Error(),
-			})
-			return
+			return nil, err
 		}
 	}
 
-	transactions, err := u.transactionRepository.GetTransactions(ctx, u.db, &repositories.GetTransactionsArgs{
-		AccountID: accountIDStr,
-		Cursor:    cursorStr,
-		Limit:     limit,
-	})
-	if err != nil {
-		c.JSON(http.StatusBadRequest, domains.ErrorResp{
-			Message: err.Error(),
-		})
-		return
-	}
+	return &repositories.GetTransactionsArgs{
+		Reference:   c.Query("reference"),
+		EndToEndID:  c.Query("end_to_end_id"),
+		Description: c.Query("q"),
+		Tags:        c.QueryArray("tag"),
+		Cursor:      c.Query("cursor"),
+		Limit:       limit,
+	}, nil
+}
 
+func toGetTransactionsResp(transactions models.Transactions) *domains.GetTransactionsResp {
 	transactionsResp := make([]*domains.Transaction, 0, len(transactions))
 	for _, transaction := range transactions {
-		transactionsResp = append(transactionsResp, &domains.Transaction{
-			TransactionID: transaction.TransactionID,
-			UserID:        transaction.UserID,
-			AccountID:     transaction.AccountID,
-			Amount:        transaction.Amount,
-			Balance:       transaction.Balance,
-			Type:          transaction.Type,
-			Status:        transaction.Status,
-			Metadata:      transaction.Metadata,
-			CreatedAt:     transaction.CreatedAt,
-			UpdatedAt:     transaction.UpdatedAt,
-		})
+		transactionsResp = append(transactionsResp, toTransactionResp(transaction))
 	}
 
 	var nextCursor string
 	if len(transactions) != 0 {
 		nextCursor = transactions[len(transactions)-1].TransactionID
 	}
-	c.JSON(http.StatusOK, &domains.GetTransactionsResp{
+
+	return &domains.GetTransactionsResp{
 		Transactions: transactionsResp,
 		NextCursor:   nextCursor,
-	})
+	}
+}
+
+func toTransactionResp(transaction *models.Transaction) *domains.Transaction {
+	return &domains.Transaction{
+		TransactionID: transaction.TransactionID,
+		UserID:        transaction.UserID,
+		AccountID:     transaction.AccountID,
+		Amount:        transaction.Amount,
+		Balance:       transaction.Balance,
+		Type:          transaction.Type,
+		Status:        transaction.Status,
+		Description:   transaction.Description,
+		Reference:     transaction.Reference,
+		EndToEndID:    transaction.EndToEndID,
+		Tags:          transaction.Tags,
+		Metadata:      transaction.Metadata,
+		CreatedAt:     transaction.CreatedAt,
+		UpdatedAt:     transaction.UpdatedAt,
+	}
 }
@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/models"
+	"banking-service/repositories"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// lastEventIDParam resumes a stream for clients that cannot set the
+	// Last-Event-ID header on their first request.
+	lastEventIDParam = "last_event_id"
+	// streamBatchSize bounds the transactions read per query while catching up.
+	streamBatchSize = 100
+	// streamRetry is the reconnection delay suggested to clients, in milliseconds.
+	streamRetry = 3000
+
+	// streamWakeRetries bounds the re-reads after a wake-up that found no
+	// sequenced transaction yet.
+	streamWakeRetries = 3
+
+	transactionEvent = "transaction"
+	// changeInsert is the change feed operation of a new row.
+	changeInsert = "INSERT"
+)
+
+var (
+	transactionIDRegexp = regexp.MustCompile(`^[0-9]{1,20}$`)
+	positionRegexp      = regexp.MustCompile(`^[0-9]{1,19}$`)
+
+	errUnknownCursor = errors.New("unknown stream cursor")
+)
+
+// accountStreams wakes up the event streams of an account when the outbox
+// reports activity on it, and ends every stream on shutdown.
+type accountStreams struct {
+	mu      sync.Mutex
+	streams map[string]map[chan struct{}]struct{}
+
+	// done is closed by close.
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newAccountStreams() *accountStreams {
+	return &accountStreams{
+		streams: make(map[string]map[chan struct{}]struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+func (s *accountStreams) close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+func (s *accountStreams) add(accountID string) chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wake := make(chan struct{}, 1)
+	if s.streams[accountID] == nil {
+		s.streams[accountID] = make(map[chan struct{}]struct{})
+	}
+	s.streams[accountID][wake] = struct{}{}
+
+	return wake
+}
+
+func (s *accountStreams) remove(accountID string, wake chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.streams[accountID], wake)
+	if len(s.streams[accountID]) == 0 {
+		delete(s.streams, accountID)
+	}
+}
+
+func (s *accountStreams) notify(accountID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for wake := range s.streams[accountID] {
+		select {
+		case wake <- struct{}{}:
+		default:
+			// a wake-up is already pending
+		}
+	}
+}
+
+// Close ends the event streams, for shutdown; http.Server.Shutdown does not
+// cancel the requests it waits for. Clients reconnect with their last
+// position.
+func (u *transactionHandlers) Close() {
+	u.streams.close()
+}
+
+// OnEvent implements workers.EventListener.
+func (u *transactionHandlers) OnEvent(event *domains.Event) {
+	for _, accountID := range event.AccountIDs() {
+		u.streams.notify(accountID)
+	}
+}
+
+// StreamAccountEventsHandler streams the transactions of an account as
+// Server-Sent Events. Each event ID is the change feed position of the
+// transaction, which follows commit order, so a client reconnecting with
+// Last-Event-ID gets every transaction it missed, even one that committed
+// after a later transaction ID. Without it the stream starts with the next
+// transaction.
+func (u *transactionHandlers) StreamAccountEventsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	accountID := c.Param("accountID")
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query(lastEventIDParam)
+	}
+	if lastEventID != "" && !positionRegexp.MatchString(lastEventID) {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, fmt.Sprintf("invalid Last-Event-ID %q", lastEventID)))
+		return
+	}
+
+	// registered before reading the cursor so no wake-up is lost
+	wake := u.streams.add(accountID)
+	defer u.streams.remove(accountID, wake)
+
+	cursor, err := u.eventCursor(ctx, lastEventID)
+	if err != nil {
+		if errors.Is(err, errUnknownCursor) {
+			c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, fmt.Sprintf("unknown Last-Event-ID %q", lastEventID)))
+			return
+		}
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// keeps reverse proxies from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", streamRetry)
+	c.Writer.Flush()
+
+	err = u.followTransactions(ctx, accountID, cursor, wake, func(transactions []*streamedTransaction) error {
+		for _, transaction := range transactions {
+			if err := sse.Encode(c.Writer, sse.Event{
+				Id:    strconv.FormatInt(transaction.position, 10),
+				Event: transactionEvent,
+				Data:  toTransactionResp(transaction.transaction),
+			}); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+
+		return nil
+	}, func() error {
+		if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+
+		return nil
+	})
+	// the stream ends when the client goes away; anything else is logged
+	if err != nil && ctx.Err() == nil {
+		requestLogger(u.logger, ctx).Warn("stream account events error", zap.String("account_id", accountID), zap.Error(err))
+	}
+}
+
+// eventCursor returns the position a stream resumes after. Without
+// lastEventID the stream starts at the latest position. Event IDs sent before
+// streams used positions are transaction IDs, far above any position; they
+// resume after the position of that transaction.
+func (u *transactionHandlers) eventCursor(ctx context.Context, lastEventID string) (int64, error) {
+	latest, err := u.changeRepository.GetLatestPosition(ctx, u.db)
+	if err != nil || lastEventID == "" {
+		return latest, err
+	}
+
+	position, err := strconv.ParseInt(lastEventID, 10, 64)
+	if err == nil && position <= latest {
+		return position, nil
+	}
+
+	return u.transactionPosition(ctx, lastEventID)
+}
+
+// transactionPosition returns the change feed position of a transaction, or
+// errUnknownCursor when it has none yet.
+func (u *transactionHandlers) transactionPosition(ctx context.Context, transactionID string) (int64, error) {
+	changes, err := u.changeRepository.GetChanges(ctx, u.db, &repositories.GetChangesArgs{
+		EntityType: enums.TransactionEntity.String(),
+		EntityID:   transactionID,
+		Operation:  changeInsert,
+		Limit:      1,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(changes) == 0 {
+		return 0, errUnknownCursor
+	}
+
+	return *changes[0].Position, nil
+}
+
+// followTransactions sends the transactions of accountID after the cursor
+// position, in commit order, until ctx is done, the stream is older than
+// maxStreamAge, the handlers are closed or a callback fails. wake must be registered in u.streams
+// before the cursor was read. A transaction gets its position from the change
+// sequencer shortly after the outbox notification, so a wake-up that finds
+// nothing is retried a few times; the transactions are also re-read on every
+// heartbeat in case a notification was missed.
+func (u *transactionHandlers) followTransactions(ctx context.Context, accountID string, cursor int64, wake chan struct{}, send func([]*streamedTransaction) error, heartbeat func() error) error {
+	ticker := time.NewTicker(u.heartbeatInterval)
+	defer ticker.Stop()
+	expire := time.NewTimer(u.maxStreamAge)
+	defer expire.Stop()
+
+	var (
+		retry   <-chan time.Time
+		retries int
+	)
+	for {
+		var (
+			sent int
+			err  error
+		)
+		if cursor, sent, err = u.sendTransactions(ctx, accountID, cursor, send); err != nil {
+			return err
+		}
+		if sent != 0 {
+			retries = 0
+		}
+		if retries == 0 {
+			retry = nil
+		} else {
+			retry = time.After(u.sequenceInterval)
+			retries--
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-expire.C:
+			// clients reconnect with their last position
+			return nil
+		case <-u.streams.done:
+			return nil
+		case <-wake:
+			retries = streamWakeRetries
+		case <-retry:
+		case <-ticker.C:
+			if err := heartbeat(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// streamedTransaction is a transaction with its change feed position.
+type streamedTransaction struct {
+	position    int64
+	transaction *models.Transaction
+}
+
+// sendTransactions sends the transactions after the cursor position in
+// batches and returns the new cursor and how many were sent. Transactions are
+// read through the change feed so a late commit is never skipped. Positions
+// are assigned in commit order, so once the feed has been read up to the
+// latest position the cursor moves there even when none of the changes
+// belonged to the account, and idle streams do not re-scan them.
+func (u *transactionHandlers) sendTransactions(ctx context.Context, accountID string, cursor int64, send func([]*streamedTransaction) error) (int64, int, error) {
+	var sent int
+	for {
+		// read first: every change at or below it is visible to the query
+		latest, err := u.changeRepository.GetLatestPosition(ctx, u.db)
+		if err != nil {
+			return cursor, sent, err
+		}
+		changes, err := u.changeRepository.GetChanges(ctx, u.db, &repositories.GetChangesArgs{
+			After:      cursor,
+			EntityType: enums.TransactionEntity.String(),
+			Operation:  changeInsert,
+			AccountID:  accountID,
+			Limit:      streamBatchSize,
+		})
+		if err != nil {
+			return cursor, sent, err
+		}
+		if len(changes) == 0 {
+			if latest > cursor {
+				cursor = latest
+			}
+			return cursor, sent, nil
+		}
+
+		transactionIDs := make([]string, 0, len(changes))
+		for _, change := range changes {
+			transactionIDs = append(transactionIDs, change.EntityID)
+		}
+		transactions, err := u.transactionRepository.GetTransactions(ctx, u.db, &repositories.GetTransactionsArgs{
+			TransactionIDs: transactionIDs,
+			Limit:          len(transactionIDs),
+		})
+		if err != nil {
+			return cursor, sent, err
+		}
+		byID := make(map[string]*models.Transaction, len(transactions))
+		for _, transaction := range transactions {
+			byID[transaction.TransactionID] = transaction
+		}
+
+		streamed := make([]*streamedTransaction, 0, len(changes))
+		for _, change := range changes {
+			// transactions are never deleted, but a missing one must not stall the stream
+			if transaction, ok := byID[change.EntityID]; ok {
+				streamed = append(streamed, &streamedTransaction{
+					position:    *change.Position,
+					transaction: transaction,
+				})
+			}
+		}
+		if err := send(streamed); err != nil {
+			return cursor, sent, err
+		}
+		sent += len(streamed)
+		cursor = *changes[len(changes)-1].Position
+
+		if len(changes) < streamBatchSize {
+			if latest > cursor {
+				cursor = latest
+			}
+			return cursor, sent, nil
+		}
+	}
+}
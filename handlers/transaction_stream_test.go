@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"banking-service/models"
+	"banking-service/repositories"
+
+	"gorm.io/gorm"
+)
+
+// fakeChangeFeed serves the changes of the transactions of accounts by
+// position.
+type fakeChangeFeed struct {
+	repositories.ChangeRepositoryI
+	repositories.TransactionRepositoryI
+
+	changes  []*models.Change
+	accounts map[string]string
+	// after records the cursor of every GetChanges call.
+	after []int64
+}
+
+func (f *fakeChangeFeed) add(position int64, transactionID, accountID string) {
+	f.changes = append(f.changes, &models.Change{
+		Position: &position,
+		EntityID: transactionID,
+	})
+	f.accounts[transactionID] = accountID
+}
+
+func (f *fakeChangeFeed) GetLatestPosition(context.Context, *gorm.DB) (int64, error) {
+	var latest int64
+	for _, change := range f.changes {
+		if *change.Position > latest {
+			latest = *change.Position
+		}
+	}
+
+	return latest, nil
+}
+
+func (f *fakeChangeFeed) GetChanges(_ context.Context, _ *gorm.DB, args *repositories.GetChangesArgs) (models.Changes, error) {
+	f.after = append(f.after, args.After)
+
+	var changes models.Changes
+	for _, change := range f.changes {
+		if *change.Position > args.After && f.accounts[change.EntityID] == args.AccountID && len(changes) < args.Limit {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+func (f *fakeChangeFeed) GetTransactions(_ context.Context, _ *gorm.DB, args *repositories.GetTransactionsArgs) (models.Transactions, error) {
+	var transactions models.Transactions
+	for _, transactionID := range args.TransactionIDs {
+		transactions = append(transactions, &models.Transaction{
+			TransactionID: transactionID,
+			AccountID:     f.accounts[transactionID],
+		})
+	}
+
+	return transactions, nil
+}
+
+func TestSendTransactionsAdvancesPastOtherAccounts(t *testing.T) {
+	const otherAccountID = "0b7b5a4e-7d7a-4b8e-9a43-2f1b8e0c9d11"
+
+	feed := &fakeChangeFeed{accounts: map[string]string{}}
+	feed.add(10, "1", testAccountID)
+	for position := int64(11); position <= 42; position++ {
+		feed.add(position, "other", otherAccountID)
+	}
+	u := &transactionHandlers{
+		changeRepository:      feed,
+		transactionRepository: feed,
+	}
+
+	var streamed []*streamedTransaction
+	send := func(transactions []*streamedTransaction) error {
+		streamed = append(streamed, transactions...)
+		return nil
+	}
+
+	cursor, sent, err := u.sendTransactions(context.Background(), testAccountID, 0, send)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 1 || len(streamed) != 1 || streamed[0].position != 10 {
+		t.Fatalf("sendTransactions() sent %d, want the transaction at position 10", sent)
+	}
+	if cursor != 42 {
+		t.Fatalf("cursor = %d, want the latest position 42", cursor)
+	}
+
+	// an idle stream resumes from the latest position
+	if cursor, sent, err = u.sendTransactions(context.Background(), testAccountID, cursor, send); err != nil {
+		t.Fatal(err)
+	}
+	if sent != 0 || cursor != 42 {
+		t.Fatalf("sendTransactions() = cursor %d, sent %d, want 42 and 0", cursor, sent)
+	}
+	if got := feed.after[len(feed.after)-1]; got != 42 {
+		t.Fatalf("GetChanges after %d, want 42", got)
+	}
+}
+
+func TestFollowTransactionsEndsOnClose(t *testing.T) {
+	feed := &fakeChangeFeed{accounts: map[string]string{}}
+	u := &transactionHandlers{
+		heartbeatInterval:     time.Hour,
+		maxStreamAge:          time.Hour,
+		sequenceInterval:      time.Second,
+		streams:               newAccountStreams(),
+		changeRepository:      feed,
+		transactionRepository: feed,
+	}
+	wake := u.streams.add(testAccountID)
+	defer u.streams.remove(testAccountID, wake)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- u.followTransactions(context.Background(), testAccountID, 0, wake, func([]*streamedTransaction) error {
+			return nil
+		}, func() error {
+			return nil
+		})
+	}()
+
+	u.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("followTransactions() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("followTransactions() did not end after Close")
+	}
+}
@@ -1,16 +1,23 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/middlewares"
 	"banking-service/models"
 	"banking-service/repositories"
 	"banking-service/utilities"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
@@ -24,18 +31,38 @@ type UserHandlers interface {
 	CreateUserHandler(*gin.Context)
 	GetUsersHandler(*gin.Context)
 	GetUserHandler(*gin.Context)
+	LookupUserHandler(*gin.Context)
+	GetUserAccountsHandler(*gin.Context)
+	GetUserTransactionsHandler(*gin.Context)
+	UpdateUserHandler(*gin.Context)
+	EraseUserHandler(*gin.Context)
+	UpdateUserStatusHandler(*gin.Context)
+	GetUserAuditsHandler(*gin.Context)
 }
 
 type UserHandlersDeps struct {
 	DB          *gorm.DB
+	Logger      *zap.Logger
 	IDGenerator utilities.SnowflakeIDGenerator
+	Authorizer  *middlewares.Authorizer
+	// ReadDB and Replica route the user list to a read replica, as for
+	// accounts.
+	ReadDB  *gorm.DB
+	Replica ReplicaStatus
 }
 
 type userHandlers struct {
-	db                *gorm.DB
-	idGenerator       utilities.SnowflakeIDGenerator
-	userRepositiory   repositories.UserRepositoryI
-	accountRepository repositories.AccountRepositoryI
+	db                    *gorm.DB
+	logger                *zap.Logger
+	reads                 *readRouter
+	idGenerator           utilities.SnowflakeIDGenerator
+	authorizer            *middlewares.Authorizer
+	events                *eventEmitter
+	userRepositiory       repositories.UserRepositoryI
+	accountRepository     repositories.AccountRepositoryI
+	transactionRepository repositories.TransactionRepositoryI
+	userAuditRepository   repositories.UserAuditRepositoryI
+	chatMessageRepository repositories.ChatMessageRepositoryI
 }
 
 func NewUserHandlers(deps *UserHandlersDeps) UserHandlers {
@@ -44,17 +71,31 @@ func NewUserHandlers(deps *UserHandlersDeps) UserHandlers {
 	}
 
 	return &userHandlers{
-		db:                deps.DB,
-		idGenerator:       deps.IDGenerator,
-		userRepositiory:   repositories.NewUserRepository(),
-		accountRepository: repositories.NewAccountRepository(),
+		db:                    deps.DB,
+		logger:                deps.Logger,
+		reads:                 newReadRouter(deps.DB, deps.ReadDB, deps.Replica, deps.Logger),
+		idGenerator:           deps.IDGenerator,
+		authorizer:            deps.Authorizer,
+		events:                newEventEmitter(deps.IDGenerator),
+		userRepositiory:       repositories.NewUserRepository(),
+		accountRepository:     repositories.NewAccountRepository(),
+		transactionRepository: repositories.NewTransactionRepository(),
+		userAuditRepository:   repositories.NewUserAuditRepository(),
+		chatMessageRepository: repositories.NewChatMessageRepository(),
 	}
 }
 
 func (u *userHandlers) RouteGroup(rg *gin.Engine) {
-	rg.POST("/users", u.CreateUserHandler)
-	rg.GET("/users", u.GetUsersHandler)
-	rg.GET("/users/:userID", u.GetUserHandler)
+	rg.POST("/users", u.authorizer.Roles(middlewares.StaffRoles...), u.CreateUserHandler)
+	rg.GET("/users", u.authorizer.Roles(middlewares.ReadRoles...), u.GetUsersHandler)
+	rg.GET("/users/lookup", u.authorizer.Roles(middlewares.ReadRoles...), u.LookupUserHandler)
+	rg.GET("/users/:userID", u.authorizer.UserOwnerOr(middlewares.ReadRoles...), u.GetUserHandler)
+	rg.PATCH("/users/:userID", u.authorizer.UserOwnerOr(middlewares.StaffRoles...), u.UpdateUserHandler)
+	rg.POST("/users/:userID/erasure", u.authorizer.Roles(middlewares.StaffRoles...), u.EraseUserHandler)
+	rg.GET("/users/:userID/audits", u.authorizer.UserOwnerOr(middlewares.ReadRoles...), u.GetUserAuditsHandler)
+	rg.GET("/users/:userID/accounts", u.authorizer.UserOwnerOr(middlewares.ReadRoles...), u.GetUserAccountsHandler)
+	rg.GET("/users/:userID/transactions", u.authorizer.UserOwnerOr(middlewares.ReadRoles...), u.GetUserTransactionsHandler)
+	rg.POST("/admin/users/:userID/status", u.authorizer.Roles(enums.Admin), u.UpdateUserStatusHandler)
 }
 
 func (u *userHandlers) CreateUserHandler(c *gin.Context) {
@@ -62,37 +103,55 @@ func (u *userHandlers) CreateUserHandler(c *gin.Context) {
 
 	var req domains.CreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, domains.ErrorResp{
-			Message: err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
 		return
 	}
 
-	if req.Name == "" {
-		c.JSON(http.StatusBadRequest, domains.ErrorResp{
-			Message: "missing name",
-		})
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
 	}
 
+	user, err := u.createUser(ctx, &req)
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+	u.reads.markWrite(c)
+
+	c.JSON(http.StatusOK, toUserResp(user))
+}
+
+// createUser registers a validated user. It returns domains.XError.
+func (u *userHandlers) createUser(ctx context.Context, req *domains.CreateUserRequest) (*models.User, error) {
 	user := &models.User{
-		UserID:    u.idGenerator.Next().String(),
-		Name:      req.Name,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		UserID:            u.idGenerator.Next().String(),
+		Name:              req.Name,
+		Email:             nullableString(req.Email),
+		Phone:             nullableString(req.Phone),
+		DateOfBirth:       parseDate(req.DateOfBirth),
+		PreferredLanguage: req.PreferredLanguage,
+		Status:            enums.UserPending.String(),
+		KYCLevel:          enums.KYCNone.String(),
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	}
+	if req.Address != nil {
+		user.Address = toAddressModel(req.Address)
 	}
+
+	if err := u.ensureContactDetailsUnique(ctx, u.db, user); err != nil {
+		return nil, err
+	}
+
 	if err := u.userRepositiory.Create(ctx, u.db, user); err != nil {
-		c.JSON(http.StatusInternalServerError, domains.ErrorResp{
-			Message: err.Error(),
-		})
-		return
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, domains.NewXError(errors.New("email or phone is already in use"), enums.Conflict)
+		}
+		return nil, domains.NewXError(err, enums.InternalError)
 	}
 
-	c.JSON(http.StatusOK, domains.CreateUserResponse{
-		ID:        user.UserID,
-		Name:      user.Name,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-	})
+	return user, nil
 }
 
 func (u *userHandlers) GetUsersHandler(c *gin.Context) {
@@ -107,32 +166,23 @@ func (u *userHandlers) GetUsersHandler(c *gin.Context) {
 	if limitStr != "" {
 		limit, err = strconv.Atoi(limitStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, domains.ErrorResp{
-				Message: err.Error(),
-			})
+			c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
 			return
 		}
 	}
 
-	users, err := u.userRepositiory.GetUsers(ctx, u.db, &repositories.GetUsersArgs{
+	users, err := u.userRepositiory.GetUsers(ctx, u.reads.db(c), &repositories.GetUsersArgs{
 		Cursor: cursorStr,
 		Limit:  limit,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domains.ErrorResp{
-			Message: err.Error(),
-		})
+		domains.NewXError(err, enums.InternalError).Response(c)
 		return
 	}
 
 	usersResp := make([]*domains.User, 0, len(users))
 	for _, user := range users {
-		usersResp = append(usersResp, &domains.User{
-			ID:        user.UserID,
-			Name:      user.Name,
-			CreatedAt: user.CreatedAt,
-			UpdatedAt: user.UpdatedAt,
-		})
+		usersResp = append(usersResp, toUserResp(user))
 	}
 
 	var nextCursor string
@@ -146,16 +196,154 @@ func (u *userHandlers) GetUsersHandler(c *gin.Context) {
 }
 
 func (u *userHandlers) GetUserHandler(c *gin.Context) {
+	user, err := u.getUser(c.Request.Context(), c.Param("userID"))
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// getUser returns the user with the IDs of their open accounts. It returns
+// domains.XError.
+func (u *userHandlers) getUser(ctx context.Context, userID string) (*domains.User, error) {
+	user, err := u.userRepositiory.GetUser(ctx, u.db, &repositories.GetUserArgs{
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domains.NewXError(fmt.Errorf("user_id %s not found", userID), enums.NotFound)
+		}
+		return nil, domains.NewXError(err, enums.InternalError)
+	}
+
+	accountIDs, err := u.accountRepository.GetAccountIDs(ctx, u.db, &repositories.GetAccountIDsArgs{
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, domains.NewXError(err, enums.InternalError)
+	}
+
+	userResp := toUserResp(user)
+	userResp.AccountIDs = accountIDs
+
+	return userResp, nil
+}
+
+// LookupUserHandler finds a user by exact email or phone for the support team.
+func (u *userHandlers) LookupUserHandler(c *gin.Context) {
 	ctx := c.Request.Context()
-	userID := c.Param("userID")
+
+	email, err := domains.NormalizeEmail(c.Query("email"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+	phone, err := domains.NormalizePhone(c.Query("phone"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+	if (email == "") == (phone == "") {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, "exactly one of email or phone is required"))
+		return
+	}
 
 	user, err := u.userRepositiory.GetUser(ctx, u.db, &repositories.GetUserArgs{
+		Email: email,
+		Phone: phone,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, domains.NewErrorResp(c, "user not found"))
+			return
+		}
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toUserResp(user))
+}
+
+func (u *userHandlers) GetUserAccountsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.Param("userID")
+	limitStr := c.Query("limit")
+	cursorStr := c.Query("cursor")
+
+	var (
+		limit int
+		err   error
+	)
+	if limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+			return
+		}
+	}
+
+	if err := u.ensureUserExists(c, userID); err != nil {
+		err.Response(c)
+		return
+	}
+
+	accounts, err := u.accountRepository.GetAccounts(ctx, u.db, &repositories.GetAccountsArgs{
+		UserID: userID,
+		Cursor: cursorStr,
+		Limit:  limit,
+	})
+	if err != nil {
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	totals, err := u.accountRepository.GetBalanceTotals(ctx, u.db, &repositories.GetBalanceTotalsArgs{
 		UserID: userID,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domains.ErrorResp{
-			Message: err.Error(),
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	accountsResp := make([]*domains.Account, 0, len(accounts))
+	for _, account := range accounts {
+		accountsResp = append(accountsResp, toAccountResp(account))
+	}
+
+	totalsResp := make([]*domains.CurrencyTotal, 0, len(totals))
+	for _, total := range totals {
+		totalsResp = append(totalsResp, &domains.CurrencyTotal{
+			Currency:     total.Currency,
+			Balance:      total.Balance,
+			AccountCount: total.AccountCount,
 		})
+	}
+
+	var nextCursor string
+	if len(accounts) != 0 {
+		nextCursor = accounts[len(accounts)-1].AccountID
+	}
+	c.JSON(http.StatusOK, &domains.GetUserAccountsResponse{
+		Accounts:   accountsResp,
+		Totals:     totalsResp,
+		NextCursor: nextCursor,
+	})
+}
+
+func (u *userHandlers) GetUserTransactionsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.Param("userID")
+
+	args, err := getTransactionsArgsFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	if err := u.ensureUserExists(c, userID); err != nil {
+		err.Response(c)
 		return
 	}
 
@@ -163,17 +351,530 @@ func (u *userHandlers) GetUserHandler(c *gin.Context) {
 		UserID: userID,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domains.ErrorResp{
-			Message: err.Error(),
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+	if len(accountIDs) == 0 {
+		c.JSON(http.StatusOK, toGetTransactionsResp(nil))
+		return
+	}
+	args.AccountIDs = accountIDs
+
+	transactions, err := u.transactionRepository.GetTransactions(ctx, u.db, args)
+	if err != nil {
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toGetTransactionsResp(transactions))
+}
+
+func (u *userHandlers) UpdateUserHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.Param("userID")
+
+	var (
+		req  domains.UpdateUserRequest
+		user *models.User
+	)
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	err := auditedTransaction(ctx, u.db, http.StatusOK, func(tx *gorm.DB) error {
+		var err error
+		user, err = u.userRepositiory.GetUser(ctx, tx, &repositories.GetUserArgs{
+			UserID:    userID,
+			ForUpdate: true,
 		})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domains.NewXError(fmt.Errorf("user_id %s not found", userID), enums.NotFound)
+			}
+			return domains.NewXError(err, enums.InternalError)
+		}
+
+		if user.ErasedAt != nil {
+			return domains.NewXError(fmt.Errorf("user_id %s has been erased", userID), enums.BadRequest)
+		}
+
+		before := userAuditFields(user)
+		applyUpdateUserRequest(user, &req)
+		changes := diffUserAuditFields(before, userAuditFields(user))
+		if len(changes) == 0 {
+			return nil
+		}
+
+		if err := u.ensureContactDetailsUnique(ctx, tx, user); err != nil {
+			return err
+		}
+
+		user.UpdatedAt = time.Now()
+		if err := u.userRepositiory.Update(ctx, tx, user); err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return domains.NewXError(errors.New("email or phone is already in use"), enums.Conflict)
+			}
+			return domains.NewXError(err, enums.InternalError)
+		}
+
+		return u.createUserAudit(c, tx, user.UserID, enums.UserUpdated, changes, req.Reason)
+	})
+	if err != nil {
+		err.(domains.XError).Response(c)
 		return
 	}
 
-	c.JSON(http.StatusOK, domains.User{
-		ID:         user.UserID,
-		Name:       user.Name,
-		AccountIDs: accountIDs,
-		CreatedAt:  user.CreatedAt,
-		UpdatedAt:  user.UpdatedAt,
+	c.JSON(http.StatusOK, toUserResp(user))
+}
+
+// EraseUserHandler anonymizes the personal data of a user. Accounts are
+// closed and transactions are kept untouched for retention, so erasure is
+// refused while any account still holds money.
+func (u *userHandlers) EraseUserHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.Param("userID")
+
+	var (
+		req  domains.EraseUserRequest
+		user *models.User
+	)
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	err := auditedTransaction(ctx, u.db, http.StatusOK, func(tx *gorm.DB) error {
+		// lock the accounts so no deposit can land between the check and the
+		// close; accounts are locked before their owner, as in money movement
+		accounts, err := u.accountRepository.GetAccounts(ctx, tx, &repositories.GetAccountsArgs{
+			UserID:    userID,
+			ForUpdate: true,
+			Limit:     -1,
+		})
+		if err != nil {
+			return domains.NewXError(err, enums.InternalError)
+		}
+
+		user, err = u.userRepositiory.GetUser(ctx, tx, &repositories.GetUserArgs{
+			UserID:    userID,
+			ForUpdate: true,
+		})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domains.NewXError(fmt.Errorf("user_id %s not found", userID), enums.NotFound)
+			}
+			return domains.NewXError(err, enums.InternalError)
+		}
+
+		if user.ErasedAt != nil {
+			return domains.NewXError(fmt.Errorf("user_id %s has already been erased", userID), enums.BadRequest)
+		}
+
+		for _, account := range accounts {
+			if account.Balance != 0 {
+				return domains.NewXError(fmt.Errorf("account_id %s has a non-zero balance", account.AccountID), enums.BadRequest)
+			}
+		}
+
+		for _, account := range accounts {
+			before := toAccountState(account)
+			if err := u.accountRepository.Delete(ctx, tx, account); err != nil {
+				return domains.NewXError(err, enums.InternalError)
+			}
+			deletedAt := time.Now()
+			account.DeletedAt = &deletedAt
+			recordAccountChange(ctx, account.AccountID, before, account)
+
+			if err := u.events.emit(ctx, tx, enums.AccountClosed, account.AccountID, &domains.AccountClosedEvent{
+				AccountID: account.AccountID,
+				UserID:    account.UserID,
+			}); err != nil {
+				return err
+			}
+		}
+
+		// previous audit entries hold old personal data as well
+		if err := u.userAuditRepository.RedactUserAudits(ctx, tx, &repositories.RedactUserAuditsArgs{
+			UserID: userID,
+		}); err != nil {
+			return domains.NewXError(err, enums.InternalError)
+		}
+		if err := u.chatMessageRepository.RedactChatMessages(ctx, tx, &repositories.RedactChatMessagesArgs{
+			SenderID: userID,
+			Text:     domains.ErasedValue,
+		}); err != nil {
+			return domains.NewXError(err, enums.InternalError)
+		}
+
+		changes := make(map[string]models.FieldChange)
+		for field, value := range userAuditFields(user) {
+			if value != "" {
+				changes[field] = models.FieldChange{Old: "[redacted]", New: ""}
+			}
+		}
+		changes["name"] = models.FieldChange{Old: "[redacted]", New: domains.ErasedValue}
+
+		now := time.Now()
+		user.Name = domains.ErasedValue
+		user.Email = nil
+		user.Phone = nil
+		user.DateOfBirth = nil
+		user.Address = models.Address{}
+		user.PreferredLanguage = ""
+		user.Status = enums.UserClosed.String()
+		user.UpdatedAt = now
+		user.ErasedAt = &now
+		if err := u.userRepositiory.Update(ctx, tx, user); err != nil {
+			return domains.NewXError(err, enums.InternalError)
+		}
+
+		return u.createUserAudit(c, tx, user.UserID, enums.UserErased, changes, req.Reason)
 	})
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toUserResp(user))
+}
+
+// UpdateUserStatusHandler moves a user through the onboarding state machine
+// and optionally changes its KYC level. Only transitions allowed by
+// enums.UserStatus are accepted; the KYC level alone may change by passing
+// the current status.
+func (u *userHandlers) UpdateUserStatusHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.Param("userID")
+
+	var (
+		req  domains.UpdateUserStatusRequest
+		user *models.User
+	)
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	err := auditedTransaction(ctx, u.db, http.StatusOK, func(tx *gorm.DB) error {
+		var err error
+		user, err = u.userRepositiory.GetUser(ctx, tx, &repositories.GetUserArgs{
+			UserID:    userID,
+			ForUpdate: true,
+		})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domains.NewXError(fmt.Errorf("user_id %s not found", userID), enums.NotFound)
+			}
+			return domains.NewXError(err, enums.InternalError)
+		}
+
+		if user.ErasedAt != nil {
+			return domains.NewXError(fmt.Errorf("user_id %s has been erased", userID), enums.BadRequest)
+		}
+
+		changes := make(map[string]models.FieldChange)
+		if req.Status != user.Status {
+			from, _ := enums.ParseUserStatus(user.Status)
+			to, _ := enums.ParseUserStatus(req.Status)
+			if !from.CanTransitionTo(to) {
+				return domains.NewXError(fmt.Errorf("cannot change status from %s to %s", user.Status, req.Status), enums.BadRequest)
+			}
+			changes["status"] = models.FieldChange{Old: user.Status, New: req.Status}
+			user.Status = req.Status
+		}
+		if req.KYCLevel != "" && req.KYCLevel != user.KYCLevel {
+			if user.Status == enums.UserClosed.String() {
+				return domains.NewXError(fmt.Errorf("user_id %s is closed", userID), enums.BadRequest)
+			}
+			changes["kyc_level"] = models.FieldChange{Old: user.KYCLevel, New: req.KYCLevel}
+			user.KYCLevel = req.KYCLevel
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+
+		user.UpdatedAt = time.Now()
+		if err := u.userRepositiory.Update(ctx, tx, user); err != nil {
+			return domains.NewXError(err, enums.InternalError)
+		}
+
+		return u.createUserAudit(c, tx, user.UserID, enums.UserStatusChanged, changes, req.Reason)
+	})
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toUserResp(user))
+}
+
+func (u *userHandlers) GetUserAuditsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.Param("userID")
+	limitStr := c.Query("limit")
+	cursorStr := c.Query("cursor")
+
+	var (
+		limit int
+		err   error
+	)
+	if limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+			return
+		}
+	}
+
+	if err := u.ensureUserExists(c, userID); err != nil {
+		err.Response(c)
+		return
+	}
+
+	audits, err := u.userAuditRepository.GetUserAudits(ctx, u.db, &repositories.GetUserAuditsArgs{
+		UserID: userID,
+		Cursor: cursorStr,
+		Limit:  limit,
+	})
+	if err != nil {
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	auditsResp := make([]*domains.UserAudit, 0, len(audits))
+	for _, audit := range audits {
+		var changes map[string]domains.FieldChange
+		if err := json.Unmarshal([]byte(audit.Changes), &changes); err != nil {
+			domains.NewXError(err, enums.InternalError).Response(c)
+			return
+		}
+
+		auditsResp = append(auditsResp, &domains.UserAudit{
+			AuditID:   audit.AuditID,
+			UserID:    audit.UserID,
+			Action:    audit.Action,
+			Changes:   changes,
+			Reason:    audit.Reason,
+			CreatedAt: audit.CreatedAt,
+		})
+	}
+
+	var nextCursor string
+	if len(audits) != 0 {
+		nextCursor = audits[len(audits)-1].AuditID
+	}
+	c.JSON(http.StatusOK, &domains.GetUserAuditsResponse{
+		Audits:     auditsResp,
+		NextCursor: nextCursor,
+	})
+}
+
+func (u *userHandlers) createUserAudit(c *gin.Context, tx *gorm.DB, userID string, action enums.UserAuditAction, changes map[string]models.FieldChange, reason string) error {
+	changesBytes, err := json.Marshal(changes)
+	if err != nil {
+		return domains.NewXError(err, enums.InternalError)
+	}
+
+	audit := &models.UserAudit{
+		AuditID:   u.idGenerator.Next().String(),
+		UserID:    userID,
+		Action:    action.String(),
+		Changes:   string(changesBytes),
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+	if err := u.userAuditRepository.Create(c.Request.Context(), tx, audit); err != nil {
+		return domains.NewXError(err, enums.InternalError)
+	}
+
+	return nil
+}
+
+func (u *userHandlers) ensureUserExists(c *gin.Context, userID string) *domains.XError {
+	_, err := u.userRepositiory.GetUser(c.Request.Context(), u.db, &repositories.GetUserArgs{
+		UserID: userID,
+	})
+	if err != nil {
+		var xerr domains.XError
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			xerr = domains.NewXError(fmt.Errorf("user_id %s not found", userID), enums.NotFound)
+		} else {
+			xerr = domains.NewXError(err, enums.InternalError)
+		}
+		return &xerr
+	}
+
+	return nil
+}
+
+// ensureContactDetailsUnique reports a conflict when another user already
+// holds the email or phone of user. The unique indexes still guard races.
+func (u *userHandlers) ensureContactDetailsUnique(ctx context.Context, db *gorm.DB, user *models.User) error {
+	if user.Email != nil {
+		if err := u.ensureNotTaken(ctx, db, user.UserID, "email", &repositories.GetUserArgs{Email: *user.Email}); err != nil {
+			return err
+		}
+	}
+	if user.Phone != nil {
+		if err := u.ensureNotTaken(ctx, db, user.UserID, "phone", &repositories.GetUserArgs{Phone: *user.Phone}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (u *userHandlers) ensureNotTaken(ctx context.Context, db *gorm.DB, userID, field string, args *repositories.GetUserArgs) error {
+	existing, err := u.userRepositiory.GetUser(ctx, db, args)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return domains.NewXError(err, enums.InternalError)
+	}
+	if existing.UserID != userID {
+		return domains.NewXError(fmt.Errorf("%s is already in use", field), enums.Conflict)
+	}
+
+	return nil
+}
+
+func applyUpdateUserRequest(user *models.User, req *domains.UpdateUserRequest) {
+	if req.Name != nil {
+		user.Name = *req.Name
+	}
+	if req.Email != nil {
+		user.Email = nullableString(*req.Email)
+	}
+	if req.Phone != nil {
+		user.Phone = nullableString(*req.Phone)
+	}
+	if req.DateOfBirth != nil {
+		user.DateOfBirth = parseDate(*req.DateOfBirth)
+	}
+	if req.Address != nil {
+		user.Address = toAddressModel(req.Address)
+	}
+	if req.PreferredLanguage != nil {
+		user.PreferredLanguage = *req.PreferredLanguage
+	}
+}
+
+// userAuditFields flattens the editable fields of user for the audit trail.
+func userAuditFields(user *models.User) map[string]string {
+	fields := map[string]string{
+		"name":                user.Name,
+		"email":               "",
+		"phone":               "",
+		"date_of_birth":       "",
+		"address.line1":       user.Address.Line1,
+		"address.line2":       user.Address.Line2,
+		"address.city":        user.Address.City,
+		"address.postal_code": user.Address.PostalCode,
+		"address.country":     user.Address.Country,
+		"preferred_language":  user.PreferredLanguage,
+	}
+	if user.Email != nil {
+		fields["email"] = *user.Email
+	}
+	if user.Phone != nil {
+		fields["phone"] = *user.Phone
+	}
+	if user.DateOfBirth != nil {
+		fields["date_of_birth"] = user.DateOfBirth.Format(domains.DateLayout)
+	}
+
+	return fields
+}
+
+func diffUserAuditFields(before, after map[string]string) map[string]models.FieldChange {
+	changes := make(map[string]models.FieldChange)
+	for field, old := range before {
+		if after[field] != old {
+			changes[field] = models.FieldChange{Old: old, New: after[field]}
+		}
+	}
+
+	return changes
+}
+
+func toUserResp(user *models.User) *domains.User {
+	userResp := &domains.User{
+		ID:                user.UserID,
+		Name:              user.Name,
+		PreferredLanguage: user.PreferredLanguage,
+		Status:            user.Status,
+		KYCLevel:          user.KYCLevel,
+		CreatedAt:         user.CreatedAt,
+		UpdatedAt:         user.UpdatedAt,
+		ErasedAt:          user.ErasedAt,
+	}
+	if user.Email != nil {
+		userResp.Email = *user.Email
+	}
+	if user.Phone != nil {
+		userResp.Phone = *user.Phone
+	}
+	if user.DateOfBirth != nil {
+		userResp.DateOfBirth = user.DateOfBirth.Format(domains.DateLayout)
+	}
+	if user.Address != (models.Address{}) {
+		userResp.Address = &domains.Address{
+			Line1:      user.Address.Line1,
+			Line2:      user.Address.Line2,
+			City:       user.Address.City,
+			PostalCode: user.Address.PostalCode,
+			Country:    user.Address.Country,
+		}
+	}
+
+	return userResp
+}
+
+func toAddressModel(address *domains.Address) models.Address {
+	return models.Address{
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		City:       address.City,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+	}
+}
+
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
+
+// parseDate parses a date already checked by the domain validation.
+func parseDate(s string) *time.Time {
+	if s == "" {
+		return nil
+	}
+
+	t, err := time.Parse(domains.DateLayout, s)
+	if err != nil {
+		return nil
+	}
+
+	return &t
 }
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/middlewares"
+	"banking-service/utilities"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+const testUserID = "1719286237483253760"
+
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:         gormLogger.Default.LogMode(gormLogger.Silent),
+		TranslateError: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db, mock
+}
+
+func newTestIDGenerator(t *testing.T) utilities.SnowflakeIDGenerator {
+	t.Helper()
+
+	idGenerator, err := utilities.NewSnowflakeIDGenerator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return idGenerator
+}
+
+// newStaffRouter serves handlers as principal, without other middlewares.
+func newStaffRouter(principal *domains.Principal) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		domains.SetPrincipal(c, principal)
+	})
+
+	return router
+}
+
+func serve(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestEraseUserAfterWithdrawingFullBalance(t *testing.T) {
+	db, mock := newMockDB(t)
+	logger := zap.NewNop()
+	idGenerator := newTestIDGenerator(t)
+	authorizer := middlewares.NewAuthorizer(nil)
+
+	router := newStaffRouter(&domains.Principal{
+		Kind:  enums.APIKeyPrincipal,
+		ID:    "operator",
+		Role:  enums.Operator,
+		Owner: "operator",
+	})
+	NewAccountHandlers(&AccountHandlersDeps{DB: db, Logger: logger, IDGenerator: idGenerator, Authorizer: authorizer}).RouteGroup(router)
+	NewUserHandlers(&UserHandlersDeps{DB: db, Logger: logger, IDGenerator: idGenerator, Authorizer: authorizer}).RouteGroup(router)
+
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "accounts"`).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(testAccountID, testUserID, "savings", "USD", 100.0, now, now))
+	mock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(testUserID, "Jane Doe", "Verified", "Full", now, now))
+	mock.ExpectQuery(`FROM "transactions"`).
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(0.0))
+	// the drained balance must be written
+	mock.ExpectExec(`UPDATE "accounts" SET "name"=\$1,"balance"=\$2,"updated_at"=\$3`).
+		WithArgs("savings", float64(0), sqlmock.AnyArg(), testAccountID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO "transactions"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "outbox_events"`).WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+	mock.ExpectCommit()
+
+	if rec := serve(router, http.MethodPost, "/accounts/"+testAccountID+"/withdraw", `{"amount":100}`); rec.Code != http.StatusOK {
+		t.Fatalf("withdraw: status = %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	// erasure reads the balance written by the withdrawal
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "accounts"`).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(testAccountID, testUserID, "savings", "USD", 0.0, now, now))
+	mock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(testUserID, "Jane Doe", "Verified", "Full", now, now))
+	mock.ExpectExec(`UPDATE "accounts" SET "deleted_at"=NOW\(\)`).
+		WithArgs(testAccountID, testUserID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "outbox_events"`).WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(2))
+	mock.ExpectExec(`UPDATE "user_audits"`).WithArgs(testUserID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "chat_messages"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE "users"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO "user_audits"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if rec := serve(router, http.MethodPost, "/users/"+testUserID+"/erasure", `{"reason":"customer request"}`); rec.Code != http.StatusOK {
+		t.Fatalf("erase: status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,531 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/middlewares"
+	"banking-service/models"
+	"banking-service/repositories"
+	"banking-service/utilities"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	_ WebhookHandlers = &webhookHandlers{}
+)
+
+type WebhookHandlers interface {
+	RouteGroup(r *gin.Engine)
+
+	CreateWebhookSubscriptionHandler(*gin.Context)
+	GetWebhookSubscriptionsHandler(*gin.Context)
+	GetWebhookSubscriptionHandler(*gin.Context)
+	UpdateWebhookSubscriptionHandler(*gin.Context)
+	DeleteWebhookSubscriptionHandler(*gin.Context)
+	GetWebhookDeliveriesHandler(*gin.Context)
+	GetWebhookDeliveryHandler(*gin.Context)
+	RedeliverWebhookHandler(*gin.Context)
+}
+
+type WebhookHandlersDeps struct {
+	DB          *gorm.DB
+	Logger      *zap.Logger
+	IDGenerator utilities.SnowflakeIDGenerator
+	Authorizer  *middlewares.Authorizer
+	// AllowInsecureURLs accepts http URLs and internal hosts, for local
+	// development only.
+	AllowInsecureURLs bool
+}
+
+type webhookHandlers struct {
+	db                            *gorm.DB
+	logger                        *zap.Logger
+	idGenerator                   utilities.SnowflakeIDGenerator
+	authorizer                    *middlewares.Authorizer
+	allowInsecureURLs             bool
+	userRepository                repositories.UserRepositoryI
+	accountRepository             repositories.AccountRepositoryI
+	webhookSubscriptionRepository repositories.WebhookSubscriptionRepositoryI
+	webhookDeliveryRepository     repositories.WebhookDeliveryRepositoryI
+}
+
+func NewWebhookHandlers(deps *WebhookHandlersDeps) WebhookHandlers {
+	if deps == nil {
+		return nil
+	}
+
+	return &webhookHandlers{
+		db:                            deps.DB,
+		logger:                        deps.Logger,
+		idGenerator:                   deps.IDGenerator,
+		authorizer:                    deps.Authorizer,
+		allowInsecureURLs:             deps.AllowInsecureURLs,
+		userRepository:                repositories.NewUserRepository(),
+		accountRepository:             repositories.NewAccountRepository(),
+		webhookSubscriptionRepository: repositories.NewWebhookSubscriptionRepository(),
+		webhookDeliveryRepository:     repositories.NewWebhookDeliveryRepository(),
+	}
+}
+
+// RouteGroup lets customers manage subscriptions on their own user; ownership
+// of a subscription is checked in the handlers.
+func (u *webhookHandlers) RouteGroup(rg *gin.Engine) {
+	writeRoles := append([]enums.Role{enums.Customer}, middlewares.StaffRoles...)
+	readRoles := append([]enums.Role{enums.Customer}, middlewares.ReadRoles...)
+
+	rg.POST("/webhooks", u.authorizer.Roles(writeRoles...), u.CreateWebhookSubscriptionHandler)
+	rg.GET("/webhooks", u.authorizer.Roles(readRoles...), u.GetWebhookSubscriptionsHandler)
+	rg.GET("/webhooks/:subscriptionID", u.authorizer.Roles(readRoles...), u.GetWebhookSubscriptionHandler)
+	rg.PATCH("/webhooks/:subscriptionID", u.authorizer.Roles(writeRoles...), u.UpdateWebhookSubscriptionHandler)
+	rg.DELETE("/webhooks/:subscriptionID", u.authorizer.Roles(writeRoles...), u.DeleteWebhookSubscriptionHandler)
+	rg.GET("/webhooks/:subscriptionID/deliveries", u.authorizer.Roles(readRoles...), u.GetWebhookDeliveriesHandler)
+	rg.GET("/webhooks/:subscriptionID/deliveries/:deliveryID", u.authorizer.Roles(readRoles...), u.GetWebhookDeliveryHandler)
+	rg.POST("/webhooks/:subscriptionID/deliveries/:deliveryID/redeliver", u.authorizer.Roles(writeRoles...), u.RedeliverWebhookHandler)
+}
+
+func (u *webhookHandlers) CreateWebhookSubscriptionHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	principal := domains.GetPrincipal(c)
+
+	var req domains.CreateWebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+	if err := utilities.CheckWebhookURL(req.URL, u.allowInsecureURLs); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	if principal.IsUser() {
+		if req.UserID != "" && req.UserID != principal.ID {
+			middlewares.Forbid(c)
+			return
+		}
+		req.UserID = principal.ID
+	}
+
+	if req.UserID != "" {
+		if _, err := u.userRepository.GetUser(ctx, u.db, &repositories.GetUserArgs{
+			UserID: req.UserID,
+		}); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, fmt.Sprintf("user_id %s not found", req.UserID)))
+				return
+			}
+			domains.NewXError(err, enums.InternalError).Response(c)
+			return
+		}
+	}
+	if err := u.ensureAccountsVisible(c, req.UserID, req.AccountIDs); err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = utilities.GenerateWebhookSecret(); err != nil {
+			domains.NewXError(err, enums.InternalError).Response(c)
+			return
+		}
+	}
+
+	subscription := &models.WebhookSubscription{
+		SubscriptionID: u.idGenerator.Next().String(),
+		UserID:         req.UserID,
+		URL:            req.URL,
+		Secret:         secret,
+		EventTypes:     req.EventTypes,
+		AccountIDs:     req.AccountIDs,
+		Active:         true,
+		CreatedBy:      principal.ID,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+	if err := u.webhookSubscriptionRepository.Create(ctx, u.db, subscription); err != nil {
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusCreated, &domains.CreateWebhookSubscriptionResponse{
+		WebhookSubscription: toWebhookSubscriptionResp(subscription),
+		Secret:              secret,
+	})
+}
+
+func (u *webhookHandlers) GetWebhookSubscriptionsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	principal := domains.GetPrincipal(c)
+	limitStr := c.Query("limit")
+	cursorStr := c.Query("cursor")
+
+	args := &repositories.GetWebhookSubscriptionsArgs{
+		UserID: c.Query("user_id"),
+		Cursor: cursorStr,
+	}
+	if principal.IsUser() {
+		args.UserID = principal.ID
+	}
+	if limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 0 {
+			c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, fmt.Sprintf("invalid limit %q", limitStr)))
+			return
+		}
+		args.Limit = limit
+	}
+
+	subscriptions, err := u.webhookSubscriptionRepository.GetWebhookSubscriptions(ctx, u.db, args)
+	if err != nil {
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	subscriptionsResp := make([]*domains.WebhookSubscription, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		subscriptionsResp = append(subscriptionsResp, toWebhookSubscriptionResp(subscription))
+	}
+
+	var nextCursor string
+	if len(subscriptions) != 0 {
+		nextCursor = subscriptions[len(subscriptions)-1].SubscriptionID
+	}
+	c.JSON(http.StatusOK, &domains.GetWebhookSubscriptionsResponse{
+		Subscriptions: subscriptionsResp,
+		NextCursor:    nextCursor,
+	})
+}
+
+func (u *webhookHandlers) GetWebhookSubscriptionHandler(c *gin.Context) {
+	subscription, err := u.getSubscription(c, c.Param("subscriptionID"))
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toWebhookSubscriptionResp(subscription))
+}
+
+func (u *webhookHandlers) UpdateWebhookSubscriptionHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req domains.UpdateWebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+	if req.URL != nil {
+		if err := utilities.CheckWebhookURL(*req.URL, u.allowInsecureURLs); err != nil {
+			c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+			return
+		}
+	}
+
+	subscription, err := u.getSubscription(c, c.Param("subscriptionID"))
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+
+	if req.URL != nil {
+		subscription.URL = *req.URL
+	}
+	if req.Secret != nil {
+		subscription.Secret = *req.Secret
+	}
+	if req.EventTypes != nil {
+		subscription.EventTypes = *req.EventTypes
+	}
+	if req.AccountIDs != nil {
+		if err := u.ensureAccountsVisible(c, subscription.UserID, *req.AccountIDs); err != nil {
+			err.(domains.XError).Response(c)
+			return
+		}
+		subscription.AccountIDs = *req.AccountIDs
+	}
+	if req.Active != nil {
+		subscription.Active = *req.Active
+	}
+	subscription.UpdatedAt = time.Now()
+
+	if err := u.webhookSubscriptionRepository.Update(ctx, u.db, subscription); err != nil {
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, toWebhookSubscriptionResp(subscription))
+}
+
+// DeleteWebhookSubscriptionHandler keeps the row so the delivery log stays
+// readable; pending deliveries are dead-lettered by the dispatcher.
+func (u *webhookHandlers) DeleteWebhookSubscriptionHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	subscription, err := u.getSubscription(c, c.Param("subscriptionID"))
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+
+	now := time.Now()
+	subscription.Active = false
+	subscription.DeletedAt = &now
+	subscription.UpdatedAt = now
+	if err := u.webhookSubscriptionRepository.Update(ctx, u.db, subscription); err != nil {
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (u *webhookHandlers) GetWebhookDeliveriesHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	limitStr := c.Query("limit")
+	cursorStr := c.Query("cursor")
+	status := c.Query("status")
+
+	subscription, err := u.getSubscription(c, c.Param("subscriptionID"))
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+
+	var limit int
+	if limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+			return
+		}
+	}
+	if status != "" {
+		if _, ok := enums.ParseWebhookDeliveryStatus(status); !ok {
+			c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, fmt.Sprintf("invalid status %q", status)))
+			return
+		}
+	}
+
+	deliveries, err := u.webhookDeliveryRepository.GetWebhookDeliveries(ctx, u.db, &repositories.GetWebhookDeliveriesArgs{
+		SubscriptionID: subscription.SubscriptionID,
+		Status:         status,
+		Cursor:         cursorStr,
+		Limit:          limit,
+	})
+	if err != nil {
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	deliveriesResp := make([]*domains.WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		deliveriesResp = append(deliveriesResp, toWebhookDeliveryResp(delivery, nil))
+	}
+
+	var nextCursor string
+	if len(deliveries) != 0 {
+		nextCursor = deliveries[len(deliveries)-1].DeliveryID
+	}
+	c.JSON(http.StatusOK, &domains.GetWebhookDeliveriesResponse{
+		Deliveries: deliveriesResp,
+		NextCursor: nextCursor,
+	})
+}
+
+// GetWebhookDeliveryHandler returns the delivery with every attempt made.
+func (u *webhookHandlers) GetWebhookDeliveryHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	subscription, err := u.getSubscription(c, c.Param("subscriptionID"))
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+
+	delivery, err := u.getDelivery(c, u.db, subscription.SubscriptionID, c.Param("deliveryID"), false)
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+
+	attempts, err := u.webhookDeliveryRepository.GetWebhookDeliveryAttempts(ctx, u.db, &repositories.GetWebhookDeliveryAttemptsArgs{
+		DeliveryID: delivery.DeliveryID,
+	})
+	if err != nil {
+		domains.NewXError(err, enums.InternalError).Response(c)
+		return
+	}
+
+	// receiver responses may echo anything the receiver serves; only staff
+	// debugging a delivery get to read them
+	if !domains.GetPrincipal(c).HasRole(middlewares.ReadRoles...) {
+		for _, attempt := range attempts {
+			attempt.ResponseBody = ""
+		}
+	}
+
+	c.JSON(http.StatusOK, toWebhookDeliveryResp(delivery, attempts))
+}
+
+// RedeliverWebhookHandler queues a delivery again, including dead-lettered
+// and already succeeded ones. Its attempt count restarts.
+func (u *webhookHandlers) RedeliverWebhookHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	subscription, err := u.getSubscription(c, c.Param("subscriptionID"))
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+	if !subscription.Active {
+		c.JSON(http.StatusBadRequest, domains.NewErrorResp(c, fmt.Sprintf("subscription_id %s is paused", subscription.SubscriptionID)))
+		return
+	}
+
+	var delivery *models.WebhookDelivery
+	err = auditedTransaction(ctx, u.db, http.StatusAccepted, func(tx *gorm.DB) error {
+		var err error
+		delivery, err = u.getDelivery(c, tx, subscription.SubscriptionID, c.Param("deliveryID"), true)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		delivery.Status = enums.WebhookPending.String()
+		delivery.Attempts = 0
+		delivery.NextAttemptAt = now
+		delivery.UpdatedAt = now
+		if err := u.webhookDeliveryRepository.Update(ctx, tx, delivery); err != nil {
+			return domains.NewXError(err, enums.InternalError)
+		}
+
+		return nil
+	})
+	if err != nil {
+		err.(domains.XError).Response(c)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, toWebhookDeliveryResp(delivery, nil))
+}
+
+// getSubscription returns domains.XError. Customers get 403 for
+// subscriptions that are not theirs or do not exist.
+func (u *webhookHandlers) getSubscription(c *gin.Context, subscriptionID string) (*models.WebhookSubscription, error) {
+	principal := domains.GetPrincipal(c)
+
+	subscription, err := u.webhookSubscriptionRepository.GetWebhookSubscription(c.Request.Context(), u.db, &repositories.GetWebhookSubscriptionArgs{
+		SubscriptionID: subscriptionID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if principal.IsUser() {
+				return nil, domains.NewXError(errors.New("forbidden"), enums.Forbidden)
+			}
+			return nil, domains.NewXError(fmt.Errorf("subscription_id %s not found", subscriptionID), enums.NotFound)
+		}
+		return nil, domains.NewXError(err, enums.InternalError)
+	}
+	if principal.IsUser() && subscription.UserID != principal.ID {
+		return nil, domains.NewXError(errors.New("forbidden"), enums.Forbidden)
+	}
+
+	return subscription, nil
+}
+
+// getDelivery returns domains.XError.
+func (u *webhookHandlers) getDelivery(c *gin.Context, db *gorm.DB, subscriptionID, deliveryID string, forUpdate bool) (*models.WebhookDelivery, error) {
+	delivery, err := u.webhookDeliveryRepository.GetWebhookDelivery(c.Request.Context(), db, &repositories.GetWebhookDeliveryArgs{
+		DeliveryID:     deliveryID,
+		SubscriptionID: subscriptionID,
+		ForUpdate:      forUpdate,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domains.NewXError(fmt.Errorf("delivery_id %s not found", deliveryID), enums.NotFound)
+		}
+		return nil, domains.NewXError(err, enums.InternalError)
+	}
+
+	return delivery, nil
+}
+
+// ensureAccountsVisible checks that every account in the filter exists and,
+// for subscriptions scoped to a user, belongs to that user. It returns
+// domains.XError.
+func (u *webhookHandlers) ensureAccountsVisible(c *gin.Context, userID string, accountIDs []string) error {
+	for _, accountID := range accountIDs {
+		_, err := u.accountRepository.GetAccount(c.Request.Context(), u.db, &repositories.GetAccountArgs{
+			AccountID: accountID,
+			UserID:    userID,
+		})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domains.NewXError(fmt.Errorf("account_id %s not found", accountID), enums.BadRequest)
+			}
+			return domains.NewXError(err, enums.InternalError)
+		}
+	}
+
+	return nil
+}
+
+func toWebhookSubscriptionResp(subscription *models.WebhookSubscription) *domains.WebhookSubscription {
+	return &domains.WebhookSubscription{
+		SubscriptionID: subscription.SubscriptionID,
+		UserID:         subscription.UserID,
+		URL:            subscription.URL,
+		EventTypes:     subscription.EventTypes,
+		AccountIDs:     subscription.AccountIDs,
+		Active:         subscription.Active,
+		CreatedBy:      subscription.CreatedBy,
+		CreatedAt:      subscription.CreatedAt,
+		UpdatedAt:      subscription.UpdatedAt,
+	}
+}
+
+func toWebhookDeliveryResp(delivery *models.WebhookDelivery, attempts models.WebhookDeliveryAttempts) *domains.WebhookDelivery {
+	deliveryResp := &domains.WebhookDelivery{
+		DeliveryID:     delivery.DeliveryID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	}
+	for _, attempt := range attempts {
+		deliveryResp.AttemptLog = append(deliveryResp.AttemptLog, &domains.WebhookDeliveryAttempt{
+			Attempt:      attempt.Attempt,
+			StatusCode:   attempt.StatusCode,
+			ResponseBody: attempt.ResponseBody,
+			Error:        attempt.Error,
+			DurationMs:   attempt.DurationMs,
+			CreatedAt:    attempt.CreatedAt,
+		})
+	}
+
+	return deliveryResp
+}
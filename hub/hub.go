@@ -0,0 +1,383 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"banking-service/domains"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	maxMessageSize                = 4096
+	maxSubscriptionsPerConnection = 100
+)
+
+var ErrTooManyConnections = errors.New("too many connections")
+
+// SubscribeAuthorizer returns an error when the connection may not subscribe
+// to one of accountIDs.
+type SubscribeAuthorizer func(ctx context.Context, accountIDs []string) error
+
+type HubDeps struct {
+	Logger                *zap.Logger
+	MaxConnectionsPerUser int
+	// MaxConnectionAge closes connections after a while so revoked
+	// credentials stop receiving data; clients reconnect.
+	MaxConnectionAge time.Duration
+	PingInterval     time.Duration
+	// PongWait must be longer than PingInterval.
+	PongWait   time.Duration
+	WriteWait  time.Duration
+	SendBuffer int
+}
+
+// Hub tracks the WebSocket connections of this instance and pushes events to
+// the connections subscribed to the accounts they touch.
+type Hub struct {
+	logger                *zap.Logger
+	maxConnectionsPerUser int
+	maxConnectionAge      time.Duration
+	pingInterval          time.Duration
+	pongWait              time.Duration
+	writeWait             time.Duration
+	sendBuffer            int
+
+	// handlers is only written before the hub serves connections.
+	handlers map[MessageType]MessageHandlerFunc
+
+	mu          sync.Mutex
+	nextIdx     UserConnectionIdx
+	reserved    map[UserID]int
+	connections map[UserID]map[UserConnectionIdx]*connection
+	subscribers map[string]map[*connection]struct{}
+}
+
+type connection struct {
+	infos    *ConnectionInfos
+	ws       *websocket.Conn
+	send     chan *Message
+	done     chan struct{}
+	doneOnce sync.Once
+	// accounts is guarded by Hub.mu.
+	accounts map[string]struct{}
+}
+
+func NewHub(deps *HubDeps) *Hub {
+	return &Hub{
+		logger:                deps.Logger,
+		maxConnectionsPerUser: deps.MaxConnectionsPerUser,
+		maxConnectionAge:      deps.MaxConnectionAge,
+		pingInterval:          deps.PingInterval,
+		pongWait:              deps.PongWait,
+		writeWait:             deps.WriteWait,
+		sendBuffer:            deps.SendBuffer,
+		handlers:              make(map[MessageType]MessageHandlerFunc),
+		reserved:              make(map[UserID]int),
+		connections:           make(map[UserID]map[UserConnectionIdx]*connection),
+		subscribers:           make(map[string]map[*connection]struct{}),
+	}
+}
+
+// HandleFunc registers the handler of a message type sent by clients. It
+// must be called before connections are served.
+func (h *Hub) HandleFunc(messageType MessageType, handler MessageHandlerFunc) {
+	h.handlers[messageType] = handler
+}
+
+// Reserve takes one of the connection slots of principal before the upgrade.
+// It must be followed by Serve or Release.
+func (h *Hub) Reserve(principal *domains.Principal) (*ConnectionInfos, error) {
+	userID := UserID(principal.ID)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.reserved[userID] >= h.maxConnectionsPerUser {
+		return nil, ErrTooManyConnections
+	}
+	h.reserved[userID]++
+	h.nextIdx++
+
+	return &ConnectionInfos{
+		UserID:            userID,
+		ExpiresAt:         time.Now().Add(h.maxConnectionAge),
+		UserConnectionIdx: h.nextIdx,
+		Principal:         principal,
+	}, nil
+}
+
+func (h *Hub) Release(infos *ConnectionInfos) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.reserved[infos.UserID]--
+	if h.reserved[infos.UserID] <= 0 {
+		delete(h.reserved, infos.UserID)
+	}
+}
+
+// Serve runs the connection until the client leaves, the connection expires
+// or it falls behind, then releases its slot.
+func (h *Hub) Serve(ctx context.Context, ws *websocket.Conn, infos *ConnectionInfos, authorize SubscribeAuthorizer) {
+	c := &connection{
+		infos:    infos,
+		ws:       ws,
+		send:     make(chan *Message, h.sendBuffer),
+		done:     make(chan struct{}),
+		accounts: make(map[string]struct{}),
+	}
+	h.register(c)
+	defer h.Release(infos)
+	defer h.unregister(c)
+
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		h.write(c)
+	}()
+
+	h.read(ctx, c, authorize)
+	c.close()
+	<-writerDone
+}
+
+// Close disconnects every connection, for shutdown; hijacked connections
+// are not closed by http.Server.Shutdown.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, connections := range h.connections {
+		for _, c := range connections {
+			c.close()
+		}
+	}
+}
+
+// OnEvent implements workers.EventListener.
+func (h *Hub) OnEvent(event *domains.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	targets := make(map[*connection]struct{})
+	for _, accountID := range event.AccountIDs() {
+		for c := range h.subscribers[accountID] {
+			targets[c] = struct{}{}
+		}
+	}
+
+	for c := range targets {
+		connectionEvent := event.RedactCounterparty(func(accountID string) bool {
+			_, ok := c.accounts[accountID]
+			return ok
+		})
+		h.push(c, &Message{
+			Type: EventMessage,
+			Data: &MessageData{
+				Event: connectionEvent,
+			},
+		})
+	}
+}
+
+// IsConnected reports whether userID has a connection on this instance.
+func (h *Hub) IsConnected(userID UserID) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.connections[userID]) != 0
+}
+
+// SendToUser pushes msg to every connection of userID on this instance.
+func (h *Hub) SendToUser(userID UserID, msg *Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, c := range h.connections[userID] {
+		h.push(c, msg)
+	}
+}
+
+// push drops connections that do not keep up rather than blocking the hub.
+// The caller must hold h.mu.
+func (h *Hub) push(c *connection, msg *Message) {
+	select {
+	case c.send <- msg:
+	default:
+		h.logger.Sugar().Warnf("closing slow websocket connection %d of %s", c.infos.UserConnectionIdx, c.infos.UserID)
+		c.close()
+	}
+}
+
+func (h *Hub) register(c *connection) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.connections[c.infos.UserID] == nil {
+		h.connections[c.infos.UserID] = make(map[UserConnectionIdx]*connection)
+	}
+	h.connections[c.infos.UserID][c.infos.UserConnectionIdx] = c
+}
+
+func (h *Hub) unregister(c *connection) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.connections[c.infos.UserID], c.infos.UserConnectionIdx)
+	if len(h.connections[c.infos.UserID]) == 0 {
+		delete(h.connections, c.infos.UserID)
+	}
+	for accountID := range c.accounts {
+		h.removeSubscriber(c, accountID)
+	}
+}
+
+func (h *Hub) subscribe(c *connection, accountIDs []string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	added := 0
+	for _, accountID := range accountIDs {
+		if _, ok := c.accounts[accountID]; !ok {
+			added++
+		}
+	}
+	if len(c.accounts)+added > maxSubscriptionsPerConnection {
+		return errors.New("too many subscriptions")
+	}
+
+	for _, accountID := range accountIDs {
+		c.accounts[accountID] = struct{}{}
+		if h.subscribers[accountID] == nil {
+			h.subscribers[accountID] = make(map[*connection]struct{})
+		}
+		h.subscribers[accountID][c] = struct{}{}
+	}
+
+	return nil
+}
+
+func (h *Hub) unsubscribe(c *connection, accountIDs []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, accountID := range accountIDs {
+		delete(c.accounts, accountID)
+		h.removeSubscriber(c, accountID)
+	}
+}
+
+// removeSubscriber must be called with h.mu held.
+func (h *Hub) removeSubscriber(c *connection, accountID string) {
+	delete(h.subscribers[accountID], c)
+	if len(h.subscribers[accountID]) == 0 {
+		delete(h.subscribers, accountID)
+	}
+}
+
+func (h *Hub) read(ctx context.Context, c *connection, authorize SubscribeAuthorizer) {
+	c.ws.SetReadLimit(maxMessageSize)
+	extendDeadline := func() {
+		_ = c.ws.SetReadDeadline(time.Now().Add(h.pongWait))
+	}
+	extendDeadline()
+	c.ws.SetPongHandler(func(string) error {
+		extendDeadline()
+		return nil
+	})
+
+	for {
+		var msg Message
+		if err := c.ws.ReadJSON(&msg); err != nil {
+			if _, ok := err.(*websocket.CloseError); !ok {
+				h.logger.Sugar().Debugf("read websocket message error: %s", err.Error())
+			}
+			return
+		}
+		extendDeadline()
+
+		switch msg.Type {
+		case PingMessage:
+			h.reply(c, &Message{Type: PingMessage})
+		case SubscribeMessage:
+			if msg.Data == nil || len(msg.Data.AccountIDs) == 0 {
+				h.reply(c, newErrorMessage("missing account_ids"))
+				continue
+			}
+			if err := authorize(ctx, msg.Data.AccountIDs); err != nil {
+				h.reply(c, newErrorMessage(err.Error()))
+				continue
+			}
+			if err := h.subscribe(c, msg.Data.AccountIDs); err != nil {
+				h.reply(c, newErrorMessage(err.Error()))
+				continue
+			}
+			h.reply(c, &Message{Type: SubscribeMessage, Data: &MessageData{AccountIDs: msg.Data.AccountIDs}})
+		case UnsubscribeMessage:
+			if msg.Data == nil || len(msg.Data.AccountIDs) == 0 {
+				h.reply(c, newErrorMessage("missing account_ids"))
+				continue
+			}
+			h.unsubscribe(c, msg.Data.AccountIDs)
+			h.reply(c, &Message{Type: UnsubscribeMessage, Data: &MessageData{AccountIDs: msg.Data.AccountIDs}})
+		default:
+			handler, ok := h.handlers[msg.Type]
+			if !ok {
+				h.reply(c, newErrorMessage("unsupported message type"))
+				continue
+			}
+			if err := handler(ctx, c.infos, &msg); err != nil {
+				h.reply(c, newErrorMessage(err.Error()))
+			}
+		}
+	}
+}
+
+func (h *Hub) reply(c *connection, msg *Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.push(c, msg)
+}
+
+func (h *Hub) write(c *connection) {
+	ticker := time.NewTicker(h.pingInterval)
+	defer ticker.Stop()
+	expiry := time.NewTimer(time.Until(c.infos.ExpiresAt))
+	defer expiry.Stop()
+	// closing the socket also ends the read loop
+	defer c.ws.Close()
+
+	for {
+		select {
+		case <-c.done:
+			_ = c.ws.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(h.writeWait))
+			return
+		case msg := <-c.send:
+			_ = c.ws.SetWriteDeadline(time.Now().Add(h.writeWait))
+			if err := c.ws.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(h.writeWait)); err != nil {
+				return
+			}
+		case <-expiry.C:
+			_ = c.ws.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, "connection expired"), time.Now().Add(h.writeWait))
+			return
+		}
+	}
+}
+
+func (c *connection) close() {
+	c.doneOnce.Do(func() {
+		close(c.done)
+	})
+}
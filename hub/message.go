@@ -0,0 +1,72 @@
+package hub
+
+import (
+	"context"
+	"time"
+
+	"banking-service/domains"
+)
+
+type (
+	// UserID identifies the principal owning a connection: the user ID for
+	// end users and the API key ID for service clients.
+	UserID  string
+	GroupID string
+
+	UserConnectionIdx int64
+	ConnectionInfos   struct {
+		UserID            UserID
+		ExpiresAt         time.Time
+		UserConnectionIdx UserConnectionIdx
+		Principal         *domains.Principal
+	}
+
+	MessageType int64
+
+	MessageData struct {
+		FromUserID UserID  `json:"from_user_id,omitempty"`
+		ToUserID   UserID  `json:"to_user_id,omitempty"`
+		ToGroupID  GroupID `json:"to_group_id,omitempty"`
+		Text       string  `json:"text,omitempty"`
+		// AccountIDs are the accounts to subscribe to or unsubscribe from,
+		// echoed back once done.
+		AccountIDs []string       `json:"account_ids,omitempty"`
+		Event      *domains.Event `json:"event,omitempty"`
+		// MessageID and SentAt identify a stored chat message.
+		MessageID string     `json:"message_id,omitempty"`
+		SentAt    *time.Time `json:"sent_at,omitempty"`
+	}
+
+	Message struct {
+		Type MessageType  `json:"type,omitempty"`
+		Data *MessageData `json:"data,omitempty"`
+	}
+)
+
+const (
+	// PingMessage is answered with a PingMessage, for clients that cannot
+	// see WebSocket control frames.
+	PingMessage MessageType = iota + 1
+	TextMessage
+	SubscribeMessage
+	UnsubscribeMessage
+	// EventMessage carries a domain event touching a subscribed account.
+	EventMessage
+	ErrorMessage
+	// ReadMessage is a read receipt: MessageID in ToGroupID was read by
+	// FromUserID.
+	ReadMessage
+)
+
+// MessageHandlerFunc handles a message type the hub does not know itself.
+// A returned error is sent back as an ErrorMessage.
+type MessageHandlerFunc func(ctx context.Context, infos *ConnectionInfos, msg *Message) error
+
+func newErrorMessage(text string) *Message {
+	return &Message{
+		Type: ErrorMessage,
+		Data: &MessageData{
+			Text: text,
+		},
+	}
+}
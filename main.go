@@ -2,7 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,113 +14,461 @@ import (
 
 	"banking-service/configs"
 	"banking-service/handlers"
+	"banking-service/hub"
+	"banking-service/metrics"
+	"banking-service/middlewares"
+	"banking-service/migrations"
+	"banking-service/openapi"
+	"banking-service/proto/bankingpb"
+	"banking-service/tracing"
 	"banking-service/utilities"
+	"banking-service/workers"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	gormLogger "gorm.io/gorm/logger"
 )
 
-type (
-	UserID  string
-	GroupID string
-
-	UserConnectionIdx int64
-	ConnectionInfos   struct {
-		UserID            UserID
-		ExpiresAt         time.Time
-		UserConnectionIdx UserConnectionIdx
+func main() {
+	loggerConfig := zap.NewProductionConfig()
+	// sampling would drop access log lines under load
+	loggerConfig.Sampling = nil
+	logger, err := loggerConfig.Build()
+	if err != nil {
+		panic(fmt.Sprintf("create logger error: %s", err.Error()))
 	}
 
-	MessageType int64
-
-	MessageData struct {
-		FromUserID UserID  `json:"from_user_id,omitempty"`
-		ToUserID   UserID  `json:"to_user_id,omitempty"`
-		ToGroupID  GroupID `json:"to_group_id,omitempty"`
-		Text       string  `json:"text,omitempty"`
+	cfg, err := configs.Load(os.Args[0], os.Args[1:])
+	if err == nil {
+		err = cfg.Validate()
 	}
-
-	Message struct {
-		Type MessageType  `json:"type,omitempty"`
-		Data *MessageData `json:"data,omitempty"`
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%s\n", err.Error())
+		os.Exit(2)
 	}
-)
-
-const (
-	PingMessage MessageType = iota + 1
-	TextMessage
-)
 
-func main() {
-	logger, err := zap.NewProduction()
+	shutdownTracing, err := tracing.Setup(context.Background(), &tracing.ProviderDeps{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.OTLPEndpoint,
+		Insecure:    cfg.Tracing.OTLPInsecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
 	if err != nil {
-		panic(fmt.Sprintf("create logger error: %s", err.Error()))
+		logger.Sugar().Errorf("setup tracing error: %s", err.Error())
+		return
 	}
 
-	configs.LoadConfig()
+	gormZapLogger, err := utilities.NewGormLogger(&utilities.GormLoggerDeps{
+		Logger:        logger.Named("gorm"),
+		Level:         cfg.Database.LogLevel,
+		SlowThreshold: cfg.Database.SlowQueryThreshold,
+	})
+	if err != nil {
+		logger.Sugar().Errorf("new gorm logger error: %s", err.Error())
+		return
+	}
+	db, err := openDatabase(cfg.Database.DSN(), "banking", &cfg.Database, gormZapLogger)
+	if err != nil {
+		logger.Sugar().Errorf("open database error: %s", err.Error())
+		return
+	}
 
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-		configs.Cfg.Database.Host,
-		configs.Cfg.Database.Username,
-		configs.Cfg.Database.Password,
-		configs.Cfg.Database.Name,
-		configs.Cfg.Database.Port,
+	// reads go to the primary unless a replica is configured and watched
+	var (
+		replicaDB      *gorm.DB
+		replicaStatus  handlers.ReplicaStatus
+		replicaMonitor *workers.ReplicaMonitor
 	)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: gormLogger.Default.LogMode(gormLogger.Info),
+	if cfg.Database.ReplicaDSN != "" {
+		replicaDB, err = openDatabase(cfg.Database.ReplicaDSN, "banking_replica", &cfg.Database, gormZapLogger)
+		if err != nil {
+			logger.Sugar().Errorf("open replica database error: %s", err.Error())
+			return
+		}
+		replicaMonitor = workers.NewReplicaMonitor(&workers.ReplicaMonitorDeps{
+			DB:       replicaDB,
+			Logger:   logger,
+			Interval: cfg.Database.ReplicaCheckInterval,
+			MaxLag:   cfg.Database.ReplicaMaxLag,
+		})
+		replicaStatus = replicaMonitor
+	}
+
+	migrator, err := migrations.NewMigrator(&migrations.MigratorDeps{
+		DB: db,
 	})
 	if err != nil {
-		logger.Sugar().Errorf("connect database error: %s", err.Error())
+		logger.Sugar().Errorf("new migrator error: %s", err.Error())
 		return
 	}
+	if cfg.Database.MigrateOnStartup {
+		applied, err := migrator.Up(context.Background())
+		for _, migration := range applied {
+			logger.Info("applied migration", zap.Int64("version", migration.Version), zap.String("name", migration.Name))
+		}
+		if err != nil {
+			logger.Sugar().Errorf("migrate error: %s", err.Error())
+			return
+		}
+	}
 
 	// gracefull shutdown
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	router := gin.Default()
+	router := gin.New()
 
-	// TODO: add ping and health
 	snowflakeIDGenerator, err := utilities.NewSnowflakeIDGenerator()
 	if err != nil {
 		logger.Sugar().Errorf("new snowflakeIDGenerator error: %s", err.Error())
 		return
 	}
 
-	accountHandlersDeps := &handlers.AccountHandlersDeps{
+	jwtManager, err := utilities.NewJWTManager(cfg.Auth.JWTSecret, cfg.Auth.JWTIssuer)
+	if err != nil {
+		logger.Sugar().Errorf("new jwtManager error: %s", err.Error())
+		return
+	}
+
+	spec, err := openapi.Load()
+	if err != nil {
+		logger.Sugar().Errorf("load openapi spec error: %s", err.Error())
+		return
+	}
+	openapi.SetMaxPageSize(spec, cfg.BankingService.MaxPageSize)
+	validateOpenAPI, err := middlewares.ValidateOpenAPI(&middlewares.OpenAPIDeps{
+		Spec:              spec,
+		Logger:            logger,
+		ValidateResponses: cfg.OpenAPI.ValidateResponses,
+	})
+	if err != nil {
+		logger.Sugar().Errorf("new openapi validator error: %s", err.Error())
+		return
+	}
+
+	router.Use(middlewares.Tracing(handlers.LivezPath, handlers.ReadyzPath))
+	router.Use(middlewares.Metrics())
+	router.Use(middlewares.RequestID(snowflakeIDGenerator))
+	router.Use(middlewares.AccessLog(&middlewares.AccessLogDeps{
+		Logger:    logger.Named("access"),
+		SkipPaths: []string{handlers.LivezPath, handlers.ReadyzPath},
+	}))
+	router.Use(middlewares.Recovery(logger))
+	router.Use(middlewares.Audit(&middlewares.AuditDeps{
 		DB:          db,
 		IDGenerator: snowflakeIDGenerator,
+		Logger:      logger,
+	}))
+	router.Use(middlewares.Authenticate(&middlewares.AuthDeps{
+		DB:              db,
+		JWTManager:      jwtManager,
+		BootstrapAPIKey: cfg.Auth.BootstrapAPIKey,
+		PublicPaths:     []string{handlers.OpenAPIPath, handlers.DocsPath, handlers.LivezPath, handlers.ReadyzPath},
+	}))
+	router.Use(validateOpenAPI)
+
+	openAPIHandlers := handlers.NewOpenAPIHandlers(&handlers.OpenAPIHandlersDeps{
+		Spec:   spec,
+		Logger: logger,
+	})
+	openAPIHandlers.RouteGroup(router)
+
+	authorizer := middlewares.NewAuthorizer(db)
+
+	authHandlersDeps := &handlers.AuthHandlersDeps{
+		DB:          db,
+		Logger:      logger,
+		IDGenerator: snowflakeIDGenerator,
+		JWTManager:  jwtManager,
+		Authorizer:  authorizer,
+	}
+	authHandlers := handlers.NewAuthHandlers(authHandlersDeps)
+	authHandlers.RouteGroup(router)
+
+	accountHandlersDeps := &handlers.AccountHandlersDeps{
+		DB:                db,
+		Logger:            logger,
+		IDGenerator:       snowflakeIDGenerator,
+		Authorizer:        authorizer,
+		ApprovalThreshold: cfg.Approval.Threshold,
+		ApprovalTTL:       cfg.Approval.TTL,
+		ReadDB:            replicaDB,
+		Replica:           replicaStatus,
 	}
 	accountHandlers := handlers.NewAccountHandlers(accountHandlersDeps)
 	accountHandlers.RouteGroup(router)
 
 	userHandlersDeps := &handlers.UserHandlersDeps{
 		DB:          db,
+		Logger:      logger,
 		IDGenerator: snowflakeIDGenerator,
+		Authorizer:  authorizer,
+		ReadDB:      replicaDB,
+		Replica:     replicaStatus,
 	}
 	userHandlers := handlers.NewUserHandlers(userHandlersDeps)
 	userHandlers.RouteGroup(router)
 
 	transactionHandlersDeps := &handlers.TransactionHandlersDeps{
-		DB:          db,
-		IDGenerator: snowflakeIDGenerator,
+		DB:                db,
+		Logger:            logger,
+		IDGenerator:       snowflakeIDGenerator,
+		Authorizer:        authorizer,
+		HeartbeatInterval: cfg.Notification.SSEHeartbeatInterval,
+		MaxStreamAge:      cfg.Notification.MaxConnectionAge,
+		SequenceInterval:  cfg.ChangeFeed.SequenceInterval,
+		ReadDB:            replicaDB,
+		Replica:           replicaStatus,
 	}
 	transactionHandlers := handlers.NewTransactionHandlers(transactionHandlersDeps)
 	transactionHandlers.RouteGroup(router)
 
+	approvalHandlersDeps := &handlers.ApprovalHandlersDeps{
+		DB:          db,
+		Logger:      logger,
+		IDGenerator: snowflakeIDGenerator,
+		Authorizer:  authorizer,
+	}
+	approvalHandlers := handlers.NewApprovalHandlers(approvalHandlersDeps)
+	approvalHandlers.RouteGroup(router)
+
+	auditLogHandlersDeps := &handlers.AuditLogHandlersDeps{
+		DB:          db,
+		Logger:      logger,
+		IDGenerator: snowflakeIDGenerator,
+		Authorizer:  authorizer,
+	}
+	auditLogHandlers := handlers.NewAuditLogHandlers(auditLogHandlersDeps)
+	auditLogHandlers.RouteGroup(router)
+
+	webhookHandlersDeps := &handlers.WebhookHandlersDeps{
+		DB:                db,
+		Logger:            logger,
+		IDGenerator:       snowflakeIDGenerator,
+		Authorizer:        authorizer,
+		AllowInsecureURLs: cfg.Webhook.AllowInsecureURLs,
+	}
+	webhookHandlers := handlers.NewWebhookHandlers(webhookHandlersDeps)
+	webhookHandlers.RouteGroup(router)
+
+	notificationHub := hub.NewHub(&hub.HubDeps{
+		Logger:                logger,
+		MaxConnectionsPerUser: cfg.Notification.MaxConnectionsPerUser,
+		MaxConnectionAge:      cfg.Notification.MaxConnectionAge,
+		PingInterval:          cfg.Notification.PingInterval,
+		PongWait:              cfg.Notification.PongWait,
+		WriteWait:             cfg.Notification.WriteWait,
+		SendBuffer:            cfg.Notification.SendBuffer,
+	})
+
+	notificationHandlersDeps := &handlers.NotificationHandlersDeps{
+		DB:         db,
+		Logger:     logger,
+		Hub:        notificationHub,
+		Authorizer: authorizer,
+	}
+	notificationHandlers := handlers.NewNotificationHandlers(notificationHandlersDeps)
+	notificationHandlers.RouteGroup(router)
+
+	changeHandlersDeps := &handlers.ChangeHandlersDeps{
+		DB:          db,
+		Logger:      logger,
+		IDGenerator: snowflakeIDGenerator,
+		Authorizer:  authorizer,
+	}
+	changeHandlers := handlers.NewChangeHandlers(changeHandlersDeps)
+	changeHandlers.RouteGroup(router)
+
+	chatHandlersDeps := &handlers.ChatHandlersDeps{
+		DB:          db,
+		Logger:      logger,
+		IDGenerator: snowflakeIDGenerator,
+		Authorizer:  authorizer,
+		Hub:         notificationHub,
+	}
+	chatHandlers := handlers.NewChatHandlers(chatHandlersDeps)
+	chatHandlers.RouteGroup(router)
+	go chatHandlers.Run(ctx)
+
+	approvalExpirer := workers.NewApprovalExpirer(&workers.ApprovalExpirerDeps{
+		DB:       db,
+		Logger:   logger,
+		Interval: cfg.Approval.ExpiryInterval,
+	})
+	go approvalExpirer.Run(ctx)
+
+	outboxRelay := workers.NewOutboxRelay(&workers.OutboxRelayDeps{
+		DB:     db,
+		Logger: logger,
+		Sinks: []workers.OutboxSink{
+			workers.NewLogSink(logger),
+			workers.NewWebhookSink(db, snowflakeIDGenerator),
+		},
+		Interval:       cfg.Outbox.RelayInterval,
+		BatchSize:      cfg.Outbox.RelayBatchSize,
+		MaxAttempts:    cfg.Outbox.MaxAttempts,
+		PublishTimeout: cfg.Outbox.PublishTimeout,
+	})
+	go outboxRelay.Run(ctx)
+
+	webhookDispatcher := workers.NewWebhookDispatcher(&workers.WebhookDispatcherDeps{
+		DB:                db,
+		IDGenerator:       snowflakeIDGenerator,
+		Logger:            logger,
+		Workers:           cfg.Webhook.Workers,
+		PollInterval:      cfg.Webhook.PollInterval,
+		Timeout:           cfg.Webhook.Timeout,
+		MaxAttempts:       cfg.Webhook.MaxAttempts,
+		BaseBackoff:       cfg.Webhook.BaseBackoff,
+		MaxBackoff:        cfg.Webhook.MaxBackoff,
+		AllowInsecureURLs: cfg.Webhook.AllowInsecureURLs,
+	})
+	go webhookDispatcher.Run(ctx)
+
+	changeSequencer := workers.NewChangeSequencer(&workers.ChangeSequencerDeps{
+		DB:        db,
+		Logger:    logger,
+		Interval:  cfg.ChangeFeed.SequenceInterval,
+		BatchSize: cfg.ChangeFeed.SequenceBatchSize,
+	})
+	go changeSequencer.Run(ctx)
+
+	eventBroadcaster := workers.NewEventBroadcaster(&workers.EventBroadcasterDeps{
+		DB:           db,
+		Logger:       logger,
+		PollInterval: cfg.Notification.EventPollInterval,
+		GapTimeout:   cfg.Notification.EventGapTimeout,
+		BatchSize:    cfg.Outbox.RelayBatchSize,
+	})
+	eventBroadcaster.AddListener(notificationHub)
+	eventBroadcaster.AddListener(chatHandlers)
+	eventBroadcaster.AddListener(transactionHandlers)
+
+	bankingService := handlers.NewBankingService(&handlers.BankingServiceDeps{
+		DB:                db,
+		Logger:            logger,
+		IDGenerator:       snowflakeIDGenerator,
+		Authorizer:        authorizer,
+		ApprovalThreshold: cfg.Approval.Threshold,
+		ApprovalTTL:       cfg.Approval.TTL,
+		HeartbeatInterval: cfg.Notification.SSEHeartbeatInterval,
+		MaxStreamAge:      cfg.Notification.MaxConnectionAge,
+		SequenceInterval:  cfg.ChangeFeed.SequenceInterval,
+		MaxPageSize:       cfg.BankingService.MaxPageSize,
+	})
+	eventBroadcaster.AddListener(bankingService)
+	go eventBroadcaster.Run(ctx)
+
+	workerHealths := []handlers.WorkerHealth{
+		approvalExpirer,
+		outboxRelay,
+		webhookDispatcher,
+		changeSequencer,
+		eventBroadcaster,
+	}
+	if replicaMonitor != nil {
+		go replicaMonitor.Run(ctx)
+		workerHealths = append(workerHealths, replicaMonitor)
+	}
+
+	healthHandlers := handlers.NewHealthHandlers(&handlers.HealthHandlersDeps{
+		DB:       db,
+		Logger:   logger,
+		Migrator: migrator,
+		Workers:  workerHealths,
+		Timeout:  cfg.Health.ReadinessTimeout,
+	})
+	healthHandlers.RouteGroup(router)
+
+	grpcServer := grpc.NewServer(middlewares.GRPCServerOptions(&middlewares.GRPCDeps{
+		IDGenerator: snowflakeIDGenerator,
+		Logger:      logger,
+		Auth: &middlewares.AuthDeps{
+			DB:              db,
+			JWTManager:      jwtManager,
+			BootstrapAPIKey: cfg.Auth.BootstrapAPIKey,
+		},
+		Audit: &middlewares.AuditDeps{
+			DB:          db,
+			IDGenerator: snowflakeIDGenerator,
+			Logger:      logger,
+		},
+	})...)
+	bankingpb.RegisterBankingServiceServer(grpcServer, bankingService)
+
+	if cfg.BankingService.GRPCEnabled {
+		grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.BankingService.GRPCPort))
+		if err != nil {
+			logger.Sugar().Errorf("listen grpc error: %s", err.Error())
+			return
+		}
+		go func() {
+			if err := grpcServer.Serve(grpcListener); err != nil {
+				logger.Sugar().Errorf("serve grpc error: %s", err.Error())
+			}
+		}()
+	}
+
+	metricsSrv := &http.Server{
+		Addr:    fmt.Sprintf(":%s", cfg.Metrics.Port),
+		Handler: metrics.Handler(),
+	}
+	go func() {
+		if err := metricsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Sugar().Errorf("serve metrics error: %s", err.Error())
+		}
+	}()
+
 	srv := &http.Server{
-		Addr:    fmt.Sprintf(":%s", configs.Cfg.BankingService.Port),
-		Handler: router,
+		Addr:              fmt.Sprintf(":%s", cfg.BankingService.Port),
+		Handler:           router,
+		ReadHeaderTimeout: cfg.BankingService.ReadHeaderTimeout,
+		IdleTimeout:       cfg.BankingService.IdleTimeout,
 	}
+	// Shutdown waits for the event streams, which only end with their clients
+	srv.RegisterOnShutdown(transactionHandlers.Close)
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
 		<-ctx.Done()
-		if err := srv.Shutdown(ctx); err != nil {
+
+		// fail readiness first so load balancers stop routing here while
+		// the servers still accept requests
+		healthHandlers.Drain()
+		time.Sleep(cfg.Health.DrainDelay)
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Health.ShutdownTimeout)
+		defer cancel()
+
+		notificationHub.Close()
+		bankingService.Close()
+		grpcStopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(grpcStopped)
+		}()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
 			logger.Sugar().Errorf("shutdown http.Server error: %s", err.Error())
-			return
+		}
+		if err := metricsSrv.Shutdown(shutdownCtx); err != nil {
+			logger.Sugar().Errorf("shutdown metrics server error: %s", err.Error())
+		}
+		// stop waiting for streams that did not end after Close once
+		// in-flight calls have had a chance to finish
+		select {
+		case <-grpcStopped:
+		case <-shutdownCtx.Done():
+			grpcServer.Stop()
+		}
+		// flush the spans of the last requests
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			logger.Sugar().Errorf("shutdown tracing error: %s", err.Error())
 		}
 	}()
 
@@ -125,4 +476,37 @@ func main() {
 		logger.Sugar().Errorf("ListenAndServe error: %s", err.Error())
 		return
 	}
+	// ListenAndServe returns as soon as Shutdown starts
+	<-shutdownDone
+}
+
+// openDatabase connects to the PostgreSQL database at dsn with the metrics
+// and tracing plugins, exporting its pool statistics under dbName.
+func openDatabase(dsn string, dbName string, cfg *configs.Database, logger gormLogger.Interface) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger:         logger,
+		TranslateError: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("use metrics plugin: %w", err)
+	}
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("use tracing plugin: %w", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("get sql.DB: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	if err := metrics.RegisterDB(sqlDB, dbName); err != nil {
+		return nil, fmt.Errorf("register metrics: %w", err)
+	}
+
+	return db, nil
 }
@@ -0,0 +1,149 @@
+// Package metrics defines the Prometheus metrics of the service and the
+// registry served on the metrics port.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Path is where Handler serves the metrics.
+const Path = "/metrics"
+
+const namespace = "banking"
+
+// Outcomes of a money movement.
+const (
+	OutcomeCompleted = "completed"
+	// OutcomePendingApproval is a movement above the approval threshold that
+	// waits for a second person.
+	OutcomePendingApproval = "pending_approval"
+	// OutcomeRejected is a movement refused by a business rule, such as an
+	// insufficient balance or a frozen account.
+	OutcomeRejected = "rejected"
+	OutcomeFailed   = "failed"
+)
+
+// Targets of a routed read and why it did not go to the replica.
+const (
+	TargetPrimary = "primary"
+	TargetReplica = "replica"
+
+	ReasonReplicaLagging     = "replica_lagging"
+	ReasonReplicaBehindToken = "replica_behind_token"
+	ReasonInvalidToken       = "invalid_token"
+)
+
+// Registry holds every metric of the service, plus the Go runtime and
+// process collectors.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// RequestsTotal and RequestDuration cover the REST API and, with method
+	// GRPC and the full method name as route, the gRPC API.
+	RequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Request latency by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	QueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Database query latency by operation, table and status.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"operation", "table", "status"})
+	// LockWaitDuration times the SELECT ... FOR UPDATE queries, which are
+	// dominated by waiting for concurrent transactions on the same rows.
+	LockWaitDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "lock_wait_seconds",
+		Help:      "Time spent acquiring row locks by table.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"table"})
+
+	// ReplicaLag is the replay lag of the read replica, 0 when it has
+	// replayed everything it received.
+	ReplicaLag = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "replica_lag_seconds",
+		Help:      "Replay lag of the read replica.",
+	})
+	// ReadsTotal counts the routed reads by the database serving them and,
+	// for the primary, why the replica was not used.
+	ReadsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "reads_total",
+		Help:      "Reads of the list endpoints by target and reason.",
+	}, []string{"target", "reason"})
+
+	// AuditWriteFailuresTotal counts requests whose audit log entry could not
+	// be written after retries. Any increase needs attention: the entry is
+	// only left in the error log.
+	AuditWriteFailuresTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "audit",
+		Name:      "write_failures_total",
+		Help:      "Audit log entries that could not be written.",
+	})
+
+	MoneyMovementsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "money_movements_total",
+		Help:      "Deposits, withdrawals, transfers and adjustments by type and outcome.",
+	}, []string{"type", "outcome"})
+	// MoneyMovementAmountTotal sums absolute amounts across currencies.
+	MoneyMovementAmountTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "money_movement_amount_total",
+		Help:      "Absolute amount of money movements by type and outcome.",
+	}, []string{"type", "outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		RequestsTotal,
+		RequestDuration,
+		QueryDuration,
+		LockWaitDuration,
+		ReplicaLag,
+		ReadsTotal,
+		AuditWriteFailuresTotal,
+		MoneyMovementsTotal,
+		MoneyMovementAmountTotal,
+	)
+}
+
+// RegisterDB exports the connection pool statistics of db, labelled with
+// dbName.
+func RegisterDB(db *sql.DB, dbName string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// Handler serves Registry at /metrics.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(Path, promhttp.HandlerFor(Registry, promhttp.HandlerOpts{
+		Registry: Registry,
+	}))
+
+	return mux
+}
@@ -0,0 +1,115 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"time"
+
+	"banking-service/domains"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const redacted = "REDACTED"
+
+// redactedQueryParams carry credentials and are not logged.
+var redactedQueryParams = []string{accessTokenParam}
+
+type AccessLogDeps struct {
+	Logger *zap.Logger
+	// SkipPaths are not logged, such as health probes.
+	SkipPaths []string
+}
+
+// AccessLog logs every request once it is served: at error level for 5xx
+// responses, warn for 4xx and info otherwise, with the errors the handler
+// attached to the context.
+func AccessLog(deps *AccessLogDeps) gin.HandlerFunc {
+	skip := make(map[string]bool, len(deps.SkipPaths))
+	for _, path := range deps.SkipPaths {
+		skip[path] = true
+	}
+
+	return func(c *gin.Context) {
+		started := time.Now()
+
+		c.Next()
+
+		if skip[c.Request.URL.Path] {
+			return
+		}
+
+		status := c.Writer.Status()
+		level := zapcore.InfoLevel
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = zapcore.ErrorLevel
+		case status >= http.StatusBadRequest:
+			level = zapcore.WarnLevel
+		}
+		entry := deps.Logger.Check(level, "request")
+		if entry == nil {
+			return
+		}
+
+		fields := []zap.Field{
+			zap.String("request_id", domains.GetRequestID(c)),
+			zap.String("method", c.Request.Method),
+			zap.String("route", c.FullPath()),
+			zap.String("path", redactPath(c.Request.URL)),
+			zap.Int("status_code", status),
+			zap.Duration("latency", time.Since(started)),
+			zap.Int("response_size", c.Writer.Size()),
+			zap.String("client_ip", c.ClientIP()),
+			zap.String("user_agent", c.Request.UserAgent()),
+		}
+		if principal := domains.GetPrincipal(c); principal != nil {
+			fields = append(fields,
+				zap.String("principal_kind", principal.Kind.String()),
+				zap.String("principal_id", principal.ID),
+			)
+		}
+		if spanContext := trace.SpanContextFromContext(c.Request.Context()); spanContext.IsValid() {
+			fields = append(fields, zap.String("trace_id", spanContext.TraceID().String()))
+		}
+		if len(c.Errors) != 0 {
+			fields = append(fields, zap.Strings("errors", c.Errors.Errors()))
+		}
+		entry.Write(fields...)
+	}
+}
+
+// Recovery turns a panic into a 500 response carrying the request ID and
+// logs it with its stack, instead of gin's plain text dump to stderr.
+func Recovery(logger *zap.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered interface{}) {
+		logger.Error("panic serving request",
+			zap.String("request_id", domains.GetRequestID(c)),
+			zap.String("method", c.Request.Method),
+			zap.String("route", c.FullPath()),
+			zap.Any("panic", recovered),
+			zap.ByteString("stack", debug.Stack()),
+		)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, domains.NewErrorResp(c, http.StatusText(http.StatusInternalServerError)))
+	})
+}
+
+// redactPath returns the path and query of u with credentials masked.
+func redactPath(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Path
+	}
+
+	query := u.Query()
+	for _, param := range redactedQueryParams {
+		if query.Has(param) {
+			query.Set(param, redacted)
+		}
+	}
+
+	return u.Path + "?" + query.Encode()
+}
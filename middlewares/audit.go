@@ -0,0 +1,230 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/metrics"
+	"banking-service/models"
+	"banking-service/repositories"
+	"banking-service/utilities"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// auditWriteTimeout bounds the audit insert and its retries, which run
+	// after the handler and must not depend on the client still being
+	// connected.
+	auditWriteTimeout  = 5 * time.Second
+	auditWriteAttempts = 3
+	auditRetryDelay    = 100 * time.Millisecond
+	// maxAuditedBodySize bounds the request bodies buffered to be hashed.
+	maxAuditedBodySize = 1 << 20
+)
+
+type AuditDeps struct {
+	DB          *gorm.DB
+	IDGenerator utilities.SnowflakeIDGenerator
+	Logger      *zap.Logger
+}
+
+type auditor struct {
+	db                 *gorm.DB
+	idGenerator        utilities.SnowflakeIDGenerator
+	logger             *zap.Logger
+	auditLogRepository repositories.AuditLogRepositoryI
+}
+
+// Audit records every mutating request in the append-only audit log. It must
+// run after RequestID and before Authenticate so rejected credentials are
+// recorded too.
+func Audit(deps *AuditDeps) gin.HandlerFunc {
+	return newAuditor(deps).handle
+}
+
+func newAuditor(deps *AuditDeps) *auditor {
+	return &auditor{
+		db:                 deps.DB,
+		idGenerator:        deps.IDGenerator,
+		logger:             deps.Logger,
+		auditLogRepository: repositories.NewAuditLogRepository(),
+	}
+}
+
+func (a *auditor) handle(c *gin.Context) {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		c.Next()
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxAuditedBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, domains.NewErrorResp(c, fmt.Sprintf("request body exceeds %d bytes", maxAuditedBodySize)))
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	payloadHash := sha256.Sum256(body)
+
+	trail := &domains.AuditTrail{}
+	pending := &pendingAudit{
+		auditor: a,
+		auditLog: models.AuditLog{
+			RequestID:   GetRequestID(c),
+			ClientIP:    c.ClientIP(),
+			Method:      c.Request.Method,
+			Route:       c.FullPath(),
+			Path:        c.Request.URL.Path,
+			PayloadHash: hex.EncodeToString(payloadHash[:]),
+		},
+		trail: trail,
+		principal: func() *domains.Principal {
+			return domains.GetPrincipal(c)
+		},
+	}
+	ctx := domains.WithAuditTrail(c.Request.Context(), trail)
+	c.Request = c.Request.WithContext(context.WithValue(ctx, pendingAuditKey{}, pending))
+
+	c.Next()
+
+	pending.finish(c.Writer.Status())
+}
+
+type pendingAuditKey struct{}
+
+// pendingAudit is the audit log entry of a request being served. Handlers
+// write it with WriteAudit in the transaction that changes state, so it
+// commits or rolls back with the changes it records. Otherwise finish writes
+// it once the request has been answered.
+type pendingAudit struct {
+	auditor *auditor
+	// auditLog holds the fields known before the handler runs.
+	auditLog  models.AuditLog
+	trail     *domains.AuditTrail
+	principal func() *domains.Principal
+
+	mu      sync.Mutex
+	written bool
+	status  int
+}
+
+// WriteAudit writes the audit log entry of the request served with ctx in
+// tx, with status as the status the request is answered with once tx
+// commits. It must be called at the end of the transaction, after the last
+// account change was recorded. Without an audited request in ctx, or when
+// the entry was already written, it does nothing.
+func WriteAudit(ctx context.Context, tx *gorm.DB, status int) error {
+	pending, _ := ctx.Value(pendingAuditKey{}).(*pendingAudit)
+	if pending == nil {
+		return nil
+	}
+
+	pending.mu.Lock()
+	defer pending.mu.Unlock()
+
+	if pending.written {
+		return nil
+	}
+	auditLog, err := pending.entry(status)
+	if err != nil {
+		return err
+	}
+	if err := pending.auditor.auditLogRepository.Create(ctx, tx, auditLog); err != nil {
+		return err
+	}
+	pending.written, pending.status = true, status
+
+	return nil
+}
+
+// finish writes the entry after the request was answered with status,
+// unless the handler already committed it with that status. An entry written
+// in a transaction that then failed was rolled back, and the request is
+// answered with an error status instead.
+func (p *pendingAudit) finish(status int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.written && p.status == status {
+		return
+	}
+	auditLog, err := p.entry(status)
+	if err != nil {
+		p.auditor.logger.Error("marshal audit account changes error", zap.Error(err))
+		return
+	}
+	p.auditor.write(auditLog)
+}
+
+func (p *pendingAudit) entry(status int) (*models.AuditLog, error) {
+	// account changes of failed requests were rolled back
+	changes := []*domains.AccountChange{}
+	if status < http.StatusBadRequest {
+		changes = p.trail.Changes()
+	}
+	changesBytes, err := json.Marshal(changes)
+	if err != nil {
+		return nil, err
+	}
+
+	auditLog := p.auditLog
+	auditLog.AuditID = p.auditor.idGenerator.Next().String()
+	auditLog.StatusCode = status
+	auditLog.AccountChanges = string(changesBytes)
+	auditLog.CreatedAt = time.Now()
+	if principal := p.principal(); principal != nil {
+		auditLog.ActorKind = principal.Kind.String()
+		auditLog.ActorID = principal.ID
+		auditLog.ActorRole = principal.Role.String()
+	}
+
+	return &auditLog, nil
+}
+
+// write inserts the entry of a request that did not write it in its
+// transaction, retrying transient failures. The request has already been
+// handled, so an entry that still cannot be written is logged in full, to be
+// restored from the logs, and counted for alerting.
+func (a *auditor) write(auditLog *models.AuditLog) {
+	ctx, cancel := context.WithTimeout(context.Background(), auditWriteTimeout)
+	defer cancel()
+
+	var err error
+	for attempt := 0; attempt < auditWriteAttempts; attempt++ {
+		if attempt != 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(auditRetryDelay << (attempt - 1)):
+			}
+		}
+		if err = a.auditLogRepository.Create(ctx, a.db, auditLog); err == nil {
+			return
+		}
+	}
+
+	metrics.AuditWriteFailuresTotal.Inc()
+	a.logger.Error("audit log entry lost",
+		zap.String("request_id", auditLog.RequestID),
+		zap.String("route", auditLog.Route),
+		zap.Reflect("audit_log", auditLog),
+		zap.Error(err),
+	)
+}
@@ -0,0 +1,107 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/utilities"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+func TestAuditWritesInHandlerTransaction(t *testing.T) {
+	tests := []struct {
+		name string
+		// fail makes the handler transaction fail after writing the entry
+		fail   bool
+		status int
+	}{
+		{name: "committed", status: http.StatusOK},
+		{name: "rolled back", fail: true, status: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+
+			sqlDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sqlDB.Close()
+			db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+				Logger: gormLogger.Default.LogMode(gormLogger.Silent),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			idGenerator, err := utilities.NewSnowflakeIDGenerator()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			router := gin.New()
+			router.Use(Audit(&AuditDeps{DB: db, IDGenerator: idGenerator, Logger: zap.NewNop()}))
+			router.Use(func(c *gin.Context) {
+				domains.SetPrincipal(c, &domains.Principal{Kind: enums.APIKeyPrincipal, ID: "test", Role: enums.Operator})
+			})
+			router.POST("/accounts/:accountID/deposit", func(c *gin.Context) {
+				ctx := c.Request.Context()
+				err := db.Transaction(func(tx *gorm.DB) error {
+					if err := WriteAudit(ctx, tx, http.StatusOK); err != nil {
+						return err
+					}
+					if tt.fail {
+						return errors.New("commit refused")
+					}
+					return nil
+				})
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, domains.NewErrorResp(c, err.Error()))
+					return
+				}
+				c.JSON(http.StatusOK, gin.H{})
+			})
+
+			mock.ExpectBegin()
+			mock.ExpectExec(`INSERT INTO "audit_logs"`).
+				WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), enums.APIKeyPrincipal.String(), "test", enums.Operator.String(),
+					sqlmock.AnyArg(), http.MethodPost, "/accounts/:accountID/deposit", "/accounts/1/deposit", http.StatusOK,
+					sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			if tt.fail {
+				mock.ExpectRollback()
+				// the rolled back entry is written again with the response status
+				mock.ExpectBegin()
+				mock.ExpectExec(`INSERT INTO "audit_logs"`).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), enums.APIKeyPrincipal.String(), "test", enums.Operator.String(),
+						sqlmock.AnyArg(), http.MethodPost, "/accounts/:accountID/deposit", "/accounts/1/deposit", http.StatusInternalServerError,
+						sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectCommit()
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/accounts/1/deposit", strings.NewReader(`{"amount":10}`))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/repositories"
+	"banking-service/utilities"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+	bearerPrefix = "Bearer "
+	// accessTokenParam carries the JWT on WebSocket upgrades and event
+	// streams, since browsers cannot set headers on them.
+	accessTokenParam = "access_token"
+
+	// lastUsedResolution limits how often last_used_at is written per key.
+	lastUsedResolution = time.Minute
+
+	bootstrapPrincipalID = "bootstrap"
+)
+
+var errUnauthenticated = errors.New("missing or invalid credentials")
+
+type AuthDeps struct {
+	DB              *gorm.DB
+	JWTManager      utilities.JWTManager
+	BootstrapAPIKey string
+	// PublicPaths are route paths, as registered in gin, that skip
+	// authentication.
+	PublicPaths []string
+}
+
+// Authenticator resolves credentials to a principal.
+type Authenticator struct {
+	db               *gorm.DB
+	jwtManager       utilities.JWTManager
+	bootstrapKeyHash string
+	publicPaths      map[string]struct{}
+	apiKeyRepository repositories.APIKeyRepositoryI
+	userRepository   repositories.UserRepositoryI
+}
+
+// Authenticate accepts either an API key in the X-API-Key header for service
+// clients or a JWT in the Authorization header for end users, and stores the
+// resulting principal in the gin context.
+func Authenticate(deps *AuthDeps) gin.HandlerFunc {
+	return NewAuthenticator(deps).handle
+}
+
+func NewAuthenticator(deps *AuthDeps) *Authenticator {
+	a := &Authenticator{
+		db:               deps.DB,
+		jwtManager:       deps.JWTManager,
+		publicPaths:      make(map[string]struct{}, len(deps.PublicPaths)),
+		apiKeyRepository: repositories.NewAPIKeyRepository(),
+		userRepository:   repositories.NewUserRepository(),
+	}
+	if deps.BootstrapAPIKey != "" {
+		a.bootstrapKeyHash = utilities.HashAPIKeySecret(deps.BootstrapAPIKey)
+	}
+	for _, path := range deps.PublicPaths {
+		a.publicPaths[path] = struct{}{}
+	}
+
+	return a
+}
+
+func (a *Authenticator) handle(c *gin.Context) {
+	if _, ok := a.publicPaths[c.FullPath()]; ok {
+		c.Next()
+		return
+	}
+
+	authorization := c.GetHeader("Authorization")
+	if token := c.Query(accessTokenParam); token != "" && !strings.HasPrefix(authorization, bearerPrefix) && (isWebSocketUpgrade(c) || isEventStream(c)) {
+		authorization = bearerPrefix + token
+	}
+
+	principal, err := a.Credentials(c.Request.Context(), c.GetHeader(apiKeyHeader), authorization)
+	if err != nil {
+		c.Abort()
+		err.(domains.XError).Response(c)
+		return
+	}
+
+	domains.SetPrincipal(c, principal)
+	c.Next()
+}
+
+// Credentials authenticates an API key or, without one, the bearer token of
+// an Authorization header value. It returns domains.XError.
+func (a *Authenticator) Credentials(ctx context.Context, apiKey, authorization string) (*domains.Principal, error) {
+	if apiKey != "" {
+		return a.authenticateAPIKey(ctx, apiKey)
+	}
+	if strings.HasPrefix(authorization, bearerPrefix) {
+		return a.authenticateJWT(ctx, strings.TrimPrefix(authorization, bearerPrefix))
+	}
+
+	return nil, domains.NewXError(errUnauthenticated, enums.Unauthorized)
+}
+
+func (a *Authenticator) authenticateAPIKey(ctx context.Context, key string) (*domains.Principal, error) {
+	if a.bootstrapKeyHash != "" && utilities.CompareAPIKeyHash(utilities.HashAPIKeySecret(key), a.bootstrapKeyHash) {
+		return &domains.Principal{
+			Kind:  enums.APIKeyPrincipal,
+			ID:    bootstrapPrincipalID,
+			Name:  bootstrapPrincipalID,
+			Role:  enums.Admin,
+			Owner: bootstrapPrincipalID,
+		}, nil
+	}
+
+	keyID, secret, ok := utilities.ParseAPIKey(key)
+	if !ok {
+		return nil, domains.NewXError(errUnauthenticated, enums.Unauthorized)
+	}
+
+	apiKey, err := a.apiKeyRepository.GetAPIKey(ctx, a.db, &repositories.GetAPIKeyArgs{
+		KeyID: keyID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domains.NewXError(errUnauthenticated, enums.Unauthorized)
+		}
+		return nil, domains.NewXError(err, enums.InternalError)
+	}
+
+	now := time.Now()
+	if !utilities.CompareAPIKeyHash(utilities.HashAPIKeySecret(secret), apiKey.KeyHash) ||
+		apiKey.RevokedAt != nil ||
+		(apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(now)) {
+		return nil, domains.NewXError(errUnauthenticated, enums.Unauthorized)
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > lastUsedResolution {
+		if err := a.apiKeyRepository.UpdateLastUsedAt(ctx, a.db, &repositories.UpdateLastUsedAtArgs{
+			KeyID:      apiKey.KeyID,
+			LastUsedAt: now,
+		}); err != nil {
+			return nil, domains.NewXError(err, enums.InternalError)
+		}
+	}
+
+	role, ok := enums.ParseRole(apiKey.Role)
+	if !ok {
+		return nil, domains.NewXError(errUnauthenticated, enums.Unauthorized)
+	}
+
+	return &domains.Principal{
+		Kind:  enums.APIKeyPrincipal,
+		ID:    apiKey.KeyID,
+		Name:  apiKey.Name,
+		Role:  role,
+		Owner: apiKey.Owner,
+	}, nil
+}
+
+func (a *Authenticator) authenticateJWT(ctx context.Context, token string) (*domains.Principal, error) {
+	userID, issuedBy, err := a.jwtManager.Verify(token)
+	if err != nil {
+		return nil, domains.NewXError(errUnauthenticated, enums.Unauthorized)
+	}
+
+	user, err := a.userRepository.GetUser(ctx, a.db, &repositories.GetUserArgs{
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domains.NewXError(errUnauthenticated, enums.Unauthorized)
+		}
+		return nil, domains.NewXError(err, enums.InternalError)
+	}
+	if user.ErasedAt != nil || user.Status == enums.UserClosed.String() {
+		return nil, domains.NewXError(errUnauthenticated, enums.Unauthorized)
+	}
+
+	owner := issuedBy
+	if owner == "" {
+		owner = user.UserID
+	}
+
+	return &domains.Principal{
+		Kind:  enums.UserPrincipal,
+		ID:    user.UserID,
+		Name:  user.Name,
+		Role:  enums.Customer,
+		Owner: owner,
+	}, nil
+}
+
+func isWebSocketUpgrade(c *gin.Context) bool {
+	return strings.EqualFold(c.GetHeader("Upgrade"), "websocket")
+}
+
+func isEventStream(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), "text/event-stream")
+}
@@ -0,0 +1,146 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/repositories"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errForbidden = errors.New("forbidden")
+
+var (
+	// StaffRoles may act on any user or account.
+	StaffRoles = []enums.Role{enums.Operator, enums.Admin}
+	// ReadRoles may read any user or account.
+	ReadRoles = []enums.Role{enums.Operator, enums.Auditor, enums.Admin}
+)
+
+// Authorizer builds per-route middlewares that run after Authenticate.
+// Customers are limited to their own user and accounts; every other role is
+// granted by listing it on the route.
+type Authorizer struct {
+	db                *gorm.DB
+	accountRepository repositories.AccountRepositoryI
+}
+
+func NewAuthorizer(db *gorm.DB) *Authorizer {
+	return &Authorizer{
+		db:                db,
+		accountRepository: repositories.NewAccountRepository(),
+	}
+}
+
+// Roles allows only the given roles.
+func (a *Authorizer) Roles(roles ...enums.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := domains.GetPrincipal(c)
+		if principal == nil || !principal.HasRole(roles...) {
+			Forbid(c)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// UserOwnerOr allows a customer whose user ID is the :userID route parameter,
+// and the given roles on any user.
+func (a *Authorizer) UserOwnerOr(roles ...enums.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := domains.GetPrincipal(c)
+		if principal == nil {
+			Forbid(c)
+			return
+		}
+		if principal.HasRole(roles...) {
+			c.Next()
+			return
+		}
+
+		if principal.Role != enums.Customer || principal.ID != c.Param("userID") {
+			Forbid(c)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AccountOwnerOr allows a customer owning the :accountID route parameter, and
+// the given roles on any account.
+func (a *Authorizer) AccountOwnerOr(roles ...enums.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := a.CheckAccountOwnerOr(c.Request.Context(), domains.GetPrincipal(c), c.Param("accountID"), roles...); err != nil {
+			c.Abort()
+			err.(domains.XError).Response(c)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// CheckAccountOwnerOr allows a customer owning accountID, and the given roles
+// on any account. Unknown accounts are forbidden rather than not found so
+// customers cannot probe for account IDs. It returns domains.XError.
+func (a *Authorizer) CheckAccountOwnerOr(ctx context.Context, principal *domains.Principal, accountID string, roles ...enums.Role) error {
+	if principal == nil {
+		return domains.NewXError(errForbidden, enums.Forbidden)
+	}
+	if principal.HasRole(roles...) {
+		return nil
+	}
+	if principal.Role != enums.Customer {
+		return domains.NewXError(errForbidden, enums.Forbidden)
+	}
+
+	account, err := a.accountRepository.GetAccount(ctx, a.db, &repositories.GetAccountArgs{
+		AccountID: accountID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domains.NewXError(errForbidden, enums.Forbidden)
+		}
+		return domains.NewXError(err, enums.InternalError)
+	}
+	if account.UserID != principal.ID {
+		return domains.NewXError(errForbidden, enums.Forbidden)
+	}
+
+	return nil
+}
+
+// CheckRoles is Roles for APIs served outside of gin. It returns
+// domains.XError.
+func CheckRoles(principal *domains.Principal, roles ...enums.Role) error {
+	if principal == nil || !principal.HasRole(roles...) {
+		return domains.NewXError(errForbidden, enums.Forbidden)
+	}
+
+	return nil
+}
+
+// CanActOnUser reports whether principal may act on userID with the given
+// roles, for checks that depend on the request body.
+func CanActOnUser(principal *domains.Principal, userID string, roles ...enums.Role) bool {
+	if principal == nil {
+		return false
+	}
+	if principal.HasRole(roles...) {
+		return true
+	}
+
+	return principal.Role == enums.Customer && principal.ID == userID
+}
+
+// Forbid aborts the request with the 403 response shared by every route.
+func Forbid(c *gin.Context) {
+	c.Abort()
+	domains.NewXError(errForbidden, enums.Forbidden).Response(c)
+}
@@ -0,0 +1,254 @@
+package middlewares
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/models"
+	"banking-service/tracing"
+	"banking-service/utilities"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// grpcAuditMethod is stored as the method of audit log entries written for
+// gRPC calls.
+const grpcAuditMethod = "GRPC"
+
+// grpcReadPrefixes are the method names that do not change state and are
+// left out of the audit log, like GET requests.
+var grpcReadPrefixes = []string{"Get", "List", "Stream"}
+
+type auditedCallKey struct{}
+
+// auditedCall lets the authentication interceptor report the principal to
+// the audit interceptor that runs before it.
+type auditedCall struct {
+	principal *domains.Principal
+}
+
+// GRPCDeps wires the interceptors of the gRPC API, which apply the request
+// IDs, audit log and authentication of the REST middlewares. Credentials are
+// read from the x-api-key and authorization metadata.
+type GRPCDeps struct {
+	IDGenerator utilities.SnowflakeIDGenerator
+	Logger      *zap.Logger
+	Auth        *AuthDeps
+	Audit       *AuditDeps
+}
+
+type grpcInterceptors struct {
+	idGenerator   utilities.SnowflakeIDGenerator
+	authenticator *Authenticator
+	auditor       *auditor
+}
+
+// GRPCServerOptions returns the interceptors in the order of the REST
+// middlewares: tracing, metrics, request ID, audit, then authentication.
+func GRPCServerOptions(deps *GRPCDeps) []grpc.ServerOption {
+	i := &grpcInterceptors{
+		idGenerator:   deps.IDGenerator,
+		authenticator: NewAuthenticator(deps.Auth),
+		auditor:       newAuditor(deps.Audit),
+	}
+
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(i.unaryTracing, i.unaryMetrics, i.unaryRequestID, i.unaryAudit, i.unaryAuthenticate),
+		grpc.ChainStreamInterceptor(i.streamTracing, i.streamMetrics, i.streamRequestID, i.streamAuthenticate),
+	}
+}
+
+// unaryTracing starts a span per call like Tracing, continuing the trace
+// context of the traceparent metadata.
+func (i *grpcInterceptors) unaryTracing(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (_ interface{}, err error) {
+	ctx, span := startGRPCSpan(ctx, info.FullMethod)
+	defer func() { tracing.End(span, err) }()
+
+	return handler(ctx, req)
+}
+
+func (i *grpcInterceptors) streamTracing(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	ctx, span := startGRPCSpan(ss.Context(), info.FullMethod)
+	defer func() { tracing.End(span, err) }()
+
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// unaryMetrics records calls like Metrics, with method GRPC, the full
+// method as route and the HTTP equivalent of the status code.
+func (i *grpcInterceptors) unaryMetrics(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	started := time.Now()
+	resp, err := handler(ctx, req)
+	observeRequest(grpcAuditMethod, info.FullMethod, strconv.Itoa(grpcHTTPStatus(err)), started)
+
+	return resp, err
+}
+
+func (i *grpcInterceptors) streamMetrics(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	started := time.Now()
+	err := handler(srv, ss)
+	observeRequest(grpcAuditMethod, info.FullMethod, strconv.Itoa(grpcHTTPStatus(err)), started)
+
+	return err
+}
+
+func (i *grpcInterceptors) unaryRequestID(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(i.withRequestID(ctx), req)
+}
+
+func (i *grpcInterceptors) streamRequestID(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &contextStream{ServerStream: ss, ctx: i.withRequestID(ss.Context())})
+}
+
+// withRequestID reuses a well-formed x-request-id from the client or
+// generates one, and echoes it in the response headers.
+func (i *grpcInterceptors) withRequestID(ctx context.Context) context.Context {
+	requestID := firstMetadata(ctx, strings.ToLower(RequestIDHeader))
+	if !requestIDRegexp.MatchString(requestID) {
+		requestID = i.idGenerator.Next().String()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(RequestIDHeader), requestID))
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("request_id", requestID))
+
+	return domains.WithRequestID(ctx, requestID)
+}
+
+func (i *grpcInterceptors) unaryAuthenticate(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	principal, err := i.authenticator.Credentials(ctx, firstMetadata(ctx, strings.ToLower(apiKeyHeader)), firstMetadata(ctx, "authorization"))
+	if err != nil {
+		return nil, err
+	}
+	if call, ok := ctx.Value(auditedCallKey{}).(*auditedCall); ok {
+		call.principal = principal
+	}
+
+	return handler(domains.WithPrincipal(ctx, principal), req)
+}
+
+func (i *grpcInterceptors) streamAuthenticate(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx := ss.Context()
+	principal, err := i.authenticator.Credentials(ctx, firstMetadata(ctx, strings.ToLower(apiKeyHeader)), firstMetadata(ctx, "authorization"))
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &contextStream{ServerStream: ss, ctx: domains.WithPrincipal(ctx, principal)})
+}
+
+// unaryAudit records every mutating call in the audit log, like Audit. The
+// payload hash covers the protobuf encoding of the request.
+func (i *grpcInterceptors) unaryAudit(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	method := info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]
+	for _, prefix := range grpcReadPrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return handler(ctx, req)
+		}
+	}
+
+	var payload []byte
+	if message, ok := req.(proto.Message); ok {
+		payload, _ = proto.MarshalOptions{Deterministic: true}.Marshal(message)
+	}
+	payloadHash := sha256.Sum256(payload)
+
+	call := &auditedCall{}
+	trail := &domains.AuditTrail{}
+	pending := &pendingAudit{
+		auditor: i.auditor,
+		auditLog: models.AuditLog{
+			RequestID:   domains.RequestIDFromContext(ctx),
+			ClientIP:    peerIP(ctx),
+			Method:      grpcAuditMethod,
+			Route:       info.FullMethod,
+			Path:        info.FullMethod,
+			PayloadHash: hex.EncodeToString(payloadHash[:]),
+		},
+		trail: trail,
+		principal: func() *domains.Principal {
+			return call.principal
+		},
+	}
+	ctx = context.WithValue(ctx, auditedCallKey{}, call)
+	ctx = context.WithValue(domains.WithAuditTrail(ctx, trail), pendingAuditKey{}, pending)
+	resp, err := handler(ctx, req)
+
+	pending.finish(grpcHTTPStatus(err))
+
+	return resp, err
+}
+
+// contextStream overrides the context of a server stream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func firstMetadata(ctx context.Context, key string) string {
+	values := metadata.ValueFromIncomingContext(ctx, key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
+
+// grpcHTTPStatus is the HTTP status the REST API answers with for the same
+// outcome, so audit entries of both APIs compare.
+func grpcHTTPStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	var xerr domains.XError
+	if errors.As(err, &xerr) {
+		return xerr.HTTPStatus()
+	}
+
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists:
+		return http.StatusConflict
+	}
+
+	return http.StatusInternalServerError
+}
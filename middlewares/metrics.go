@@ -0,0 +1,34 @@
+package middlewares
+
+import (
+	"strconv"
+	"time"
+
+	"banking-service/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels requests that matched no route, so scanners cannot
+// create a series per path.
+const unmatchedRoute = "unmatched"
+
+// Metrics records the count and latency of every request by route.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		observeRequest(c.Request.Method, route, strconv.Itoa(c.Writer.Status()), started)
+	}
+}
+
+func observeRequest(method, route, status string, started time.Time) {
+	metrics.RequestsTotal.WithLabelValues(method, route, status).Inc()
+	metrics.RequestDuration.WithLabelValues(method, route, status).Observe(time.Since(started).Seconds())
+}
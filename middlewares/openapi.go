@@ -0,0 +1,114 @@
+package middlewares
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"banking-service/domains"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type OpenAPIDeps struct {
+	Spec   *openapi3.T
+	Logger *zap.Logger
+	// ValidateResponses logs responses that do not match the specification.
+	// It buffers every response body, so enable it in tests and staging.
+	ValidateResponses bool
+}
+
+type openAPIValidator struct {
+	router            routers.Router
+	logger            *zap.Logger
+	validateResponses bool
+}
+
+// ValidateOpenAPI rejects requests that do not match the specification with
+// 400. Routes missing from the specification are passed through. It must run
+// after Authenticate, which the specification does not model.
+func ValidateOpenAPI(deps *OpenAPIDeps) (gin.HandlerFunc, error) {
+	// keep the schema and the value out of the messages sent to clients
+	openapi3.SchemaErrorDetailsDisabled = true
+
+	router, err := gorillamux.NewRouter(deps.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &openAPIValidator{
+		router:            router,
+		logger:            deps.Logger,
+		validateResponses: deps.ValidateResponses,
+	}
+
+	return v.handle, nil
+}
+
+func (v *openAPIValidator) handle(c *gin.Context) {
+	route, pathParams, err := v.router.FindRoute(c.Request)
+	if err != nil {
+		c.Next()
+		return
+	}
+
+	input := &openapi3filter.RequestValidationInput{
+		Request:    c.Request,
+		PathParams: pathParams,
+		Route:      route,
+		Options: &openapi3filter.Options{
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		},
+	}
+	if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, domains.NewErrorResp(c, err.Error()))
+		return
+	}
+
+	if !v.validateResponses || isWebSocketUpgrade(c) || isEventStream(c) {
+		c.Next()
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+	c.Next()
+
+	if err := openapi3filter.ValidateResponse(c.Request.Context(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 recorder.Status(),
+		Header:                 recorder.Header(),
+		Body:                   io.NopCloser(&recorder.body),
+		Options: &openapi3filter.Options{
+			IncludeResponseStatus: true,
+		},
+	}); err != nil {
+		v.logger.Error("response does not match the OpenAPI specification",
+			zap.String("method", c.Request.Method),
+			zap.String("route", c.FullPath()),
+			zap.Int("status_code", recorder.Status()),
+			zap.Error(err),
+		)
+	}
+}
+
+// responseRecorder keeps a copy of the response body for validation.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"regexp"
+
+	"banking-service/domains"
+	"banking-service/utilities"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDRegexp bounds client supplied IDs so they are safe to log and store.
+var requestIDRegexp = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID reuses a well-formed X-Request-ID from the client or generates
+// one, echoes it in the response and tags the request span with it.
+func RequestID(idGenerator utilities.SnowflakeIDGenerator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !requestIDRegexp.MatchString(requestID) {
+			requestID = idGenerator.Next().String()
+		}
+
+		domains.SetRequestID(c, requestID)
+		c.Header(RequestIDHeader, requestID)
+		trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("request_id", requestID))
+		c.Next()
+	}
+}
+
+func GetRequestID(c *gin.Context) string {
+	return domains.GetRequestID(c)
+}
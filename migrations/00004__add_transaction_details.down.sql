@@ -0,0 +1,9 @@
+DROP INDEX transactions_tags_idx;
+DROP INDEX transactions_end_to_end_id_idx;
+DROP INDEX transactions_reference_idx;
+
+ALTER TABLE transactions
+    DROP COLUMN tags,
+    DROP COLUMN end_to_end_id,
+    DROP COLUMN reference,
+    DROP COLUMN description;
@@ -0,0 +1,9 @@
+ALTER TABLE transactions
+    ADD COLUMN description VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN reference VARCHAR(140) NOT NULL DEFAULT '',
+    ADD COLUMN end_to_end_id VARCHAR(35) NOT NULL DEFAULT '',
+    ADD COLUMN tags JSONB NOT NULL DEFAULT '[]';
+
+CREATE INDEX transactions_reference_idx ON transactions (reference) WHERE reference <> '';
+CREATE INDEX transactions_end_to_end_id_idx ON transactions (end_to_end_id) WHERE end_to_end_id <> '';
+CREATE INDEX transactions_tags_idx ON transactions USING GIN (tags);
@@ -0,0 +1,5 @@
+DROP INDEX transactions_account_id_transaction_id_idx;
+DROP INDEX accounts_user_id_idx;
+
+ALTER TABLE accounts
+    DROP COLUMN currency;
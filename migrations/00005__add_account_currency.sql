@@ -0,0 +1,5 @@
+ALTER TABLE accounts
+    ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+
+CREATE INDEX accounts_user_id_idx ON accounts (user_id) WHERE deleted_at IS NULL;
+CREATE INDEX transactions_account_id_transaction_id_idx ON transactions (account_id, transaction_id DESC);
@@ -0,0 +1,4 @@
+DROP TABLE user_audits;
+
+ALTER TABLE users
+    DROP COLUMN erased_at;
@@ -0,0 +1,13 @@
+ALTER TABLE users
+    ADD COLUMN erased_at TIMESTAMPTZ NULL;
+
+CREATE TABLE user_audits(
+    audit_id VARCHAR(80) PRIMARY KEY,
+    user_id VARCHAR(80) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    changes JSONB NOT NULL DEFAULT '{}',
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX user_audits_user_id_audit_id_idx ON user_audits (user_id, audit_id DESC);
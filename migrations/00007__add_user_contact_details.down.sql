@@ -0,0 +1,13 @@
+DROP INDEX users_phone_key;
+DROP INDEX users_email_key;
+
+ALTER TABLE users
+    DROP COLUMN preferred_language,
+    DROP COLUMN address_country,
+    DROP COLUMN address_postal_code,
+    DROP COLUMN address_city,
+    DROP COLUMN address_line2,
+    DROP COLUMN address_line1,
+    DROP COLUMN date_of_birth,
+    DROP COLUMN phone,
+    DROP COLUMN email;
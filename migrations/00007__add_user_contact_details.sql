@@ -0,0 +1,13 @@
+ALTER TABLE users
+    ADD COLUMN email VARCHAR(254) NULL,
+    ADD COLUMN phone VARCHAR(16) NULL,
+    ADD COLUMN date_of_birth DATE NULL,
+    ADD COLUMN address_line1 VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN address_line2 VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN address_city VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN address_postal_code VARCHAR(20) NOT NULL DEFAULT '',
+    ADD COLUMN address_country VARCHAR(2) NOT NULL DEFAULT '',
+    ADD COLUMN preferred_language VARCHAR(35) NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX users_email_key ON users (email) WHERE email IS NOT NULL;
+CREATE UNIQUE INDEX users_phone_key ON users (phone) WHERE phone IS NOT NULL;
@@ -0,0 +1,5 @@
+DROP INDEX transactions_user_id_created_at_idx;
+
+ALTER TABLE users
+    DROP COLUMN kyc_level,
+    DROP COLUMN status;
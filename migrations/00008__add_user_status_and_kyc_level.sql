@@ -0,0 +1,9 @@
+ALTER TABLE users
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'Pending',
+    ADD COLUMN kyc_level VARCHAR(20) NOT NULL DEFAULT 'None';
+
+-- users created before onboarding existed keep moving money without limits
+UPDATE users SET status = 'Verified', kyc_level = 'Full' WHERE erased_at IS NULL;
+UPDATE users SET status = 'Closed' WHERE erased_at IS NOT NULL;
+
+CREATE INDEX transactions_user_id_created_at_idx ON transactions (user_id, created_at);
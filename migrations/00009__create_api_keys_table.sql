@@ -0,0 +1,11 @@
+CREATE TABLE api_keys(
+    key_id VARCHAR(80) PRIMARY KEY,
+    name VARCHAR(80) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    rotated_from VARCHAR(80) NULL,
+    last_used_at TIMESTAMPTZ NULL,
+    expires_at TIMESTAMPTZ NULL,
+    revoked_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+ALTER TABLE api_keys
+    ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'Operator';
@@ -0,0 +1,18 @@
+CREATE TABLE approvals(
+    approval_id VARCHAR(80) PRIMARY KEY,
+    type VARCHAR(20) NOT NULL,
+    account_id VARCHAR(80) NOT NULL,
+    amount float8 NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    requested_by VARCHAR(80) NOT NULL,
+    decided_by VARCHAR(80) NULL,
+    decision_reason VARCHAR(255) NOT NULL DEFAULT '',
+    transaction_id VARCHAR(80) NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    decided_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX approvals_status_expires_at_idx ON approvals (status, expires_at);
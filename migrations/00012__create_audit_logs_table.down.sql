@@ -0,0 +1,3 @@
+-- dropping the table also drops its append-only triggers
+DROP TABLE audit_logs;
+DROP FUNCTION audit_logs_append_only();
@@ -0,0 +1,34 @@
+CREATE TABLE audit_logs(
+    audit_id VARCHAR(80) PRIMARY KEY,
+    request_id VARCHAR(128) NOT NULL,
+    actor_kind VARCHAR(20) NOT NULL DEFAULT '',
+    actor_id VARCHAR(80) NOT NULL DEFAULT '',
+    actor_role VARCHAR(20) NOT NULL DEFAULT '',
+    client_ip VARCHAR(45) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    route VARCHAR(255) NOT NULL,
+    path VARCHAR(2048) NOT NULL,
+    status_code INT NOT NULL,
+    payload_hash VARCHAR(64) NOT NULL,
+    account_changes JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX audit_logs_actor_id_idx ON audit_logs (actor_id, audit_id DESC);
+CREATE INDEX audit_logs_request_id_idx ON audit_logs (request_id);
+CREATE INDEX audit_logs_account_changes_idx ON audit_logs USING GIN (account_changes jsonb_path_ops);
+
+-- the audit log is append-only
+CREATE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_logs_append_only
+    BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
+
+CREATE TRIGGER audit_logs_no_truncate
+    BEFORE TRUNCATE ON audit_logs
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only();
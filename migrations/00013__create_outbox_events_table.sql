@@ -0,0 +1,14 @@
+CREATE TABLE outbox_events(
+    sequence BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(80) NOT NULL UNIQUE,
+    event_type VARCHAR(50) NOT NULL,
+    aggregate_id VARCHAR(80) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMPTZ
+);
+
+CREATE INDEX outbox_events_unpublished_idx ON outbox_events (sequence) WHERE published_at IS NULL;
+CREATE INDEX outbox_events_aggregate_id_idx ON outbox_events (aggregate_id, sequence);
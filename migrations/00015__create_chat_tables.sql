@@ -0,0 +1,33 @@
+CREATE TABLE conversations(
+    conversation_id VARCHAR(80) PRIMARY KEY,
+    subject VARCHAR(255) NOT NULL DEFAULT '',
+    account_id VARCHAR(80) NOT NULL DEFAULT '',
+    created_by VARCHAR(80) NOT NULL,
+    last_message_id VARCHAR(80) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE conversation_participants(
+    conversation_id VARCHAR(80) NOT NULL REFERENCES conversations (conversation_id),
+    participant_id VARCHAR(80) NOT NULL,
+    participant_kind VARCHAR(20) NOT NULL,
+    last_read_message_id VARCHAR(80) NOT NULL DEFAULT '',
+    last_read_at TIMESTAMPTZ,
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (conversation_id, participant_id)
+);
+
+CREATE INDEX conversation_participants_participant_id_idx ON conversation_participants (participant_id, conversation_id DESC);
+
+CREATE TABLE chat_messages(
+    message_id VARCHAR(80) PRIMARY KEY,
+    conversation_id VARCHAR(80) NOT NULL REFERENCES conversations (conversation_id),
+    sender_id VARCHAR(80) NOT NULL,
+    sender_kind VARCHAR(20) NOT NULL,
+    text TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX chat_messages_conversation_id_idx ON chat_messages (conversation_id, message_id DESC);
+CREATE INDEX chat_messages_sender_id_idx ON chat_messages (sender_id);
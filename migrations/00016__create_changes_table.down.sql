@@ -0,0 +1,5 @@
+DROP TRIGGER transactions_record_change ON transactions;
+DROP TRIGGER accounts_record_change ON accounts;
+DROP TRIGGER users_record_change ON users;
+DROP FUNCTION record_change();
+DROP TABLE changes;
@@ -0,0 +1,53 @@
+-- changes records every write to users, accounts and transactions for the
+-- change feed. position is assigned after commit by a single sequencer, so
+-- the feed is ordered by commit and never skips a late-committing write.
+CREATE TABLE changes(
+    change_id BIGSERIAL PRIMARY KEY,
+    position BIGINT UNIQUE,
+    entity_type VARCHAR(20) NOT NULL,
+    entity_id VARCHAR(80) NOT NULL,
+    operation VARCHAR(10) NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX changes_unsequenced_idx ON changes (change_id) WHERE position IS NULL;
+
+-- record_change(entity_type, id_column)
+CREATE FUNCTION record_change() RETURNS trigger AS $$
+DECLARE
+    row JSONB;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        row := to_jsonb(OLD);
+    ELSE
+        row := to_jsonb(NEW);
+    END IF;
+
+    INSERT INTO changes (entity_type, entity_id, operation)
+    VALUES (TG_ARGV[0], row ->> TG_ARGV[1], TG_OP);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_record_change
+    AFTER INSERT OR UPDATE OR DELETE ON users
+    FOR EACH ROW EXECUTE FUNCTION record_change('User', 'user_id');
+
+CREATE TRIGGER accounts_record_change
+    AFTER INSERT OR UPDATE OR DELETE ON accounts
+    FOR EACH ROW EXECUTE FUNCTION record_change('Account', 'account_id');
+
+CREATE TRIGGER transactions_record_change
+    AFTER INSERT OR UPDATE OR DELETE ON transactions
+    FOR EACH ROW EXECUTE FUNCTION record_change('Transaction', 'transaction_id');
+
+-- existing rows start the feed so consumers can replicate from scratch
+INSERT INTO changes (entity_type, entity_id, operation, changed_at)
+SELECT 'User', user_id, 'INSERT', created_at FROM users ORDER BY created_at, user_id;
+
+INSERT INTO changes (entity_type, entity_id, operation, changed_at)
+SELECT 'Account', account_id, 'INSERT', created_at FROM accounts ORDER BY created_at, account_id;
+
+INSERT INTO changes (entity_type, entity_id, operation, changed_at)
+SELECT 'Transaction', transaction_id, 'INSERT', created_at FROM transactions ORDER BY transaction_id;
@@ -0,0 +1,2 @@
+ALTER TABLE accounts DROP COLUMN freeze_reason;
+ALTER TABLE accounts DROP COLUMN frozen_at;
@@ -0,0 +1,4 @@
+-- frozen accounts accept no deposits, withdrawals or transfers; back office
+-- adjustments still apply
+ALTER TABLE accounts ADD COLUMN frozen_at TIMESTAMPTZ;
+ALTER TABLE accounts ADD COLUMN freeze_reason VARCHAR(255) NOT NULL DEFAULT '';
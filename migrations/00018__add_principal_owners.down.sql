@@ -0,0 +1,2 @@
+ALTER TABLE approvals DROP COLUMN requested_by_owner;
+ALTER TABLE api_keys DROP COLUMN owner;
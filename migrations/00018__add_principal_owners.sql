@@ -0,0 +1,19 @@
+-- maker-checker compares the people behind credentials, not the credentials:
+-- one person may hold several API keys
+ALTER TABLE api_keys ADD COLUMN owner VARCHAR(80) NOT NULL DEFAULT '';
+
+-- a key issued before owners were recorded belongs to the first key of its
+-- rotation chain
+WITH RECURSIVE chains AS (
+    SELECT key_id, key_id AS root FROM api_keys WHERE rotated_from IS NULL
+    UNION ALL
+    SELECT api_keys.key_id, chains.root
+    FROM api_keys JOIN chains ON api_keys.rotated_from = chains.key_id
+)
+UPDATE api_keys SET owner = chains.root FROM chains WHERE api_keys.key_id = chains.key_id;
+
+ALTER TABLE approvals ADD COLUMN requested_by_owner VARCHAR(80) NOT NULL DEFAULT '';
+UPDATE approvals SET requested_by_owner = COALESCE(
+    (SELECT owner FROM api_keys WHERE api_keys.key_id = approvals.requested_by),
+    approvals.requested_by
+);
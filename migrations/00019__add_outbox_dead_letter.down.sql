@@ -0,0 +1,4 @@
+DROP INDEX outbox_events_dead_lettered_idx;
+DROP INDEX outbox_events_unpublished_idx;
+CREATE INDEX outbox_events_unpublished_idx ON outbox_events (sequence) WHERE published_at IS NULL;
+ALTER TABLE outbox_events DROP COLUMN dead_lettered_at;
@@ -0,0 +1,9 @@
+-- an event failing outbox.max_attempts times is set aside so it no longer
+-- holds back the events after it
+ALTER TABLE outbox_events ADD COLUMN dead_lettered_at TIMESTAMPTZ;
+
+DROP INDEX outbox_events_unpublished_idx;
+CREATE INDEX outbox_events_unpublished_idx ON outbox_events (sequence)
+    WHERE published_at IS NULL AND dead_lettered_at IS NULL;
+CREATE INDEX outbox_events_dead_lettered_idx ON outbox_events (sequence)
+    WHERE dead_lettered_at IS NOT NULL;
@@ -0,0 +1 @@
+DROP INDEX changes_entity_idx;
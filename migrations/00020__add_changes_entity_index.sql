@@ -0,0 +1,2 @@
+-- account event streams resume from the position of a transaction
+CREATE INDEX changes_entity_idx ON changes (entity_type, entity_id);
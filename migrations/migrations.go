@@ -0,0 +1,324 @@
+// Package migrations embeds the SQL schema migrations and applies them.
+//
+// A migration is a file named <version>__<name>.sql, optionally paired with
+// <version>__<name>.down.sql to revert it. Applied versions are recorded in
+// the schema_migrations table.
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationsLockID is the advisory lock key that serializes migrations
+// across instances starting together.
+const migrationsLockID = 7_310_003
+
+const downSuffix = ".down.sql"
+
+const createTableSQL = `CREATE TABLE IF NOT EXISTS schema_migrations(
+    version BIGINT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+)`
+
+//go:embed *.sql
+var files embed.FS
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	// Down is empty when the migration cannot be reverted.
+	Down string
+}
+
+type MigrationStatus struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+type schemaMigration struct {
+	Version   int64
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+type MigratorDeps struct {
+	DB *gorm.DB
+}
+
+// Migrator applies and reverts the embedded migrations. Each migration runs
+// in its own transaction together with its schema_migrations row, holding
+// an advisory lock, so a failed migration leaves no trace and concurrent
+// migrators apply every migration once.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []*Migration
+}
+
+func NewMigrator(deps *MigratorDeps) (*Migrator, error) {
+	migrations, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         deps.DB,
+		migrations: migrations,
+	}, nil
+}
+
+// load reads the embedded migrations ordered by version.
+func load() ([]*Migration, error) {
+	entries, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		version, name, down, err := parseFileName(entry)
+		if err != nil {
+			return nil, err
+		}
+		content, err := files.ReadFile(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, name)
+		}
+		if down {
+			migration.Down = string(content)
+		} else {
+			migration.Up = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d %s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func parseFileName(fileName string) (version int64, name string, down bool, err error) {
+	base := strings.TrimSuffix(fileName, ".sql")
+	if strings.HasSuffix(fileName, downSuffix) {
+		base, down = strings.TrimSuffix(fileName, downSuffix), true
+	}
+
+	versionStr, name, ok := strings.Cut(base, "__")
+	if !ok || name == "" {
+		return 0, "", false, fmt.Errorf("invalid migration file name %s", fileName)
+	}
+	version, err = strconv.ParseInt(versionStr, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, "", false, fmt.Errorf("invalid migration version in %s", fileName)
+	}
+
+	return version, name, down, nil
+}
+
+// Up applies every pending migration in version order and returns them.
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	var applied []*Migration
+	for {
+		var migration *Migration
+		err := m.locked(ctx, func(tx *gorm.DB, appliedVersions map[int64]bool) error {
+			for _, pending := range m.migrations {
+				if !appliedVersions[pending.Version] {
+					migration = pending
+					break
+				}
+			}
+			if migration == nil {
+				return nil
+			}
+
+			if err := tx.Exec(migration.Up).Error; err != nil {
+				return fmt.Errorf("apply migration %d %s: %w", migration.Version, migration.Name, err)
+			}
+
+			return tx.Create(&schemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return applied, err
+		}
+		if migration == nil {
+			return applied, nil
+		}
+		applied = append(applied, migration)
+	}
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// them. It stops at the first migration without a down file.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	var reverted []*Migration
+	for len(reverted) < steps {
+		var migration *Migration
+		err := m.locked(ctx, func(tx *gorm.DB, appliedVersions map[int64]bool) error {
+			for i := len(m.migrations) - 1; i >= 0; i-- {
+				if appliedVersions[m.migrations[i].Version] {
+					migration = m.migrations[i]
+					break
+				}
+			}
+			if migration == nil {
+				return nil
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d %s cannot be reverted", migration.Version, migration.Name)
+			}
+
+			if err := tx.Exec(migration.Down).Error; err != nil {
+				return fmt.Errorf("revert migration %d %s: %w", migration.Version, migration.Name, err)
+			}
+
+			return tx.Where("version = ?", migration.Version).Delete(&schemaMigration{}).Error
+		})
+		if err != nil {
+			return reverted, err
+		}
+		if migration == nil {
+			return reverted, nil
+		}
+		reverted = append(reverted, migration)
+	}
+
+	return reverted, nil
+}
+
+// Baseline records every migration up to version as applied without running
+// it, for databases that were migrated by hand.
+func (m *Migrator) Baseline(ctx context.Context, version int64) ([]*Migration, error) {
+	var recorded []*Migration
+	err := m.locked(ctx, func(tx *gorm.DB, appliedVersions map[int64]bool) error {
+		now := time.Now()
+		for _, migration := range m.migrations {
+			if migration.Version > version || appliedVersions[migration.Version] {
+				continue
+			}
+			if err := tx.Create(&schemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: now,
+			}).Error; err != nil {
+				return err
+			}
+			recorded = append(recorded, migration)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recorded, nil
+}
+
+// Pending returns the embedded migrations not applied yet.
+func (m *Migrator) Pending(ctx context.Context) ([]*Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []*Migration
+	for i, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, m.migrations[i])
+		}
+	}
+
+	return pending, nil
+}
+
+// Status lists the embedded migrations with when they were applied.
+func (m *Migrator) Status(ctx context.Context) ([]*MigrationStatus, error) {
+	var exists bool
+	if err := m.db.WithContext(ctx).Raw("SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists).Error; err != nil {
+		return nil, err
+	}
+	var rows []*schemaMigration
+	if exists {
+		if err := m.db.WithContext(ctx).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+	}
+	appliedAt := make(map[int64]time.Time, len(rows))
+	for _, row := range rows {
+		appliedAt[row.Version] = row.AppliedAt
+	}
+
+	statuses := make([]*MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := &MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+		}
+		if t, ok := appliedAt[migration.Version]; ok {
+			status.AppliedAt = &t
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// locked runs fn in a transaction holding the migrations lock, with the
+// versions applied so far.
+func (m *Migrator) locked(ctx context.Context, fn func(tx *gorm.DB, appliedVersions map[int64]bool) error) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationsLockID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(createTableSQL).Error; err != nil {
+			return err
+		}
+
+		var versions []int64
+		if err := tx.Model(&schemaMigration{}).Pluck("version", &versions).Error; err != nil {
+			return err
+		}
+		appliedVersions := make(map[int64]bool, len(versions))
+		for _, version := range versions {
+			appliedVersions[version] = true
+		}
+
+		return fn(tx, appliedVersions)
+	})
+}
@@ -5,13 +5,16 @@ import (
 )
 
 type Account struct {
-	AccountID string
-	UserID    string
-	Name      string
-	Balance   float64
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	DeletedAt *time.Time
+	AccountID    string
+	UserID       string
+	Name         string
+	Currency     string
+	Balance      float64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    *time.Time
+	FrozenAt     *time.Time
+	FreezeReason string
 }
 
 func (Account) TableName() string {
@@ -19,3 +22,9 @@ func (Account) TableName() string {
 }
 
 type Accounts []*Account
+
+type BalanceTotal struct {
+	Currency     string
+	Balance      float64
+	AccountCount int64
+}
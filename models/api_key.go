@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type APIKey struct {
+	KeyID       string
+	Name        string
+	Owner       string
+	Role        string
+	KeyHash     string
+	RotatedFrom *string
+	LastUsedAt  *time.Time
+	ExpiresAt   *time.Time
+	RevokedAt   *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+type APIKeys []*APIKey
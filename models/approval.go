@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+type Approval struct {
+	ApprovalID  string
+	Type        string
+	AccountID   string
+	Amount      float64
+	Payload     string
+	Status      string
+	RequestedBy string
+	// RequestedByOwner is the Owner of the requesting principal.
+	RequestedByOwner string
+	DecidedBy        *string
+	DecisionReason   string
+	TransactionID    *string
+	ExpiresAt        time.Time
+	DecidedAt        *time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+func (Approval) TableName() string {
+	return "approvals"
+}
+
+type Approvals []*Approval
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type AuditLog struct {
+	AuditID        string
+	RequestID      string
+	ActorKind      string
+	ActorID        string
+	ActorRole      string
+	ClientIP       string `gorm:"column:client_ip"`
+	Method         string
+	Route          string
+	Path           string
+	StatusCode     int
+	PayloadHash    string
+	AccountChanges string
+	CreatedAt      time.Time
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+type AuditLogs []*AuditLog
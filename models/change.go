@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+type Change struct {
+	ChangeID   int64 `gorm:"primaryKey;autoIncrement"`
+	Position   *int64
+	EntityType string
+	EntityID   string
+	Operation  string
+	ChangedAt  time.Time
+}
+
+func (Change) TableName() string {
+	return "changes"
+}
+
+type Changes []*Change
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+type Conversation struct {
+	ConversationID string
+	Subject        string
+	// AccountID is the account the conversation is about, if any.
+	AccountID     string
+	CreatedBy     string
+	LastMessageID string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (Conversation) TableName() string {
+	return "conversations"
+}
+
+type Conversations []*Conversation
+
+type ConversationParticipant struct {
+	ConversationID string
+	// ParticipantID is a user ID for customers and an API key ID for
+	// support agents.
+	ParticipantID     string
+	ParticipantKind   string
+	LastReadMessageID string
+	LastReadAt        *time.Time
+	JoinedAt          time.Time
+}
+
+func (ConversationParticipant) TableName() string {
+	return "conversation_participants"
+}
+
+type ConversationParticipants []*ConversationParticipant
+
+type ChatMessage struct {
+	MessageID      string
+	ConversationID string
+	SenderID       string
+	SenderKind     string
+	Text           string
+	CreatedAt      time.Time
+}
+
+func (ChatMessage) TableName() string {
+	return "chat_messages"
+}
+
+type ChatMessages []*ChatMessage
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type OutboxEvent struct {
+	// Sequence is assigned by the database and orders delivery.
+	Sequence    int64 `gorm:"primaryKey;autoIncrement"`
+	EventID     string
+	EventType   string
+	AggregateID string
+	Payload     string
+	Attempts    int
+	LastError   string
+	CreatedAt   time.Time
+	PublishedAt *time.Time
+	// DeadLetteredAt is set once the event has failed every attempt; the
+	// relay skips it until it is replayed.
+	DeadLetteredAt *time.Time
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+type OutboxEvents []*OutboxEvent
@@ -12,6 +12,10 @@ type Transaction struct {
 	Balance       float64
 	Type          string
 	Status        string
+	Description   string
+	Reference     string
+	EndToEndID    string `gorm:"column:end_to_end_id"`
+	Tags          Tags
 	Metadata      string
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
@@ -24,7 +28,19 @@ func (Transaction) TableName() string {
 
 type Transactions []*Transaction
 
+type LedgerTotal struct {
+	AccountID        string
+	Total            float64
+	TransactionCount int64
+	LastBalance      float64
+}
+
 type TransactionMetadata struct {
 	FromAccountID string `json:"from_account_id,omitempty"`
 	ToAccountID   string `json:"to_account_id,omitempty"`
+	ReasonCode    string `json:"reason_code,omitempty"`
+	ApprovalID    string `json:"approval_id,omitempty"`
 }
+
+// Tags is stored as a JSONB array so it can be searched with the @> operator.
+type Tags = StringList
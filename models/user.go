@@ -3,8 +3,49 @@ package models
 import "time"
 
 type User struct {
+	UserID            string
+	Name              string
+	Email             *string
+	Phone             *string
+	DateOfBirth       *time.Time
+	Address           Address `gorm:"embedded;embeddedPrefix:address_"`
+	PreferredLanguage string
+	Status            string
+	KYCLevel          string `gorm:"column:kyc_level"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	ErasedAt          *time.Time
+}
+
+func (User) TableName() string {
+	return "users"
+}
+
+type Address struct {
+	Line1      string
+	Line2      string
+	City       string
+	PostalCode string
+	Country    string
+}
+
+type UserAudit struct {
+	AuditID   string
 	UserID    string
-	Name      string
+	Action    string
+	Changes   string
+	Reason    string
 	CreatedAt time.Time
-	UpdatedAt time.Time
+}
+
+func (UserAudit) TableName() string {
+	return "user_audits"
+}
+
+type UserAudits []*UserAudit
+
+// FieldChange is the value stored per field in UserAudit.Changes.
+type FieldChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
 }
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+type WebhookSubscription struct {
+	SubscriptionID string
+	// UserID restricts the subscription to accounts of a user; empty means
+	// every account.
+	UserID     string
+	URL        string `gorm:"column:url"`
+	Secret     string
+	EventTypes StringList
+	AccountIDs StringList `gorm:"column:account_ids"`
+	Active     bool
+	CreatedBy  string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  *time.Time
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+type WebhookSubscriptions []*WebhookSubscription
+
+type WebhookDelivery struct {
+	DeliveryID     string
+	SubscriptionID string
+	EventID        string
+	EventType      string
+	Payload        string
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastError      string
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+type WebhookDeliveries []*WebhookDelivery
+
+type WebhookDeliveryAttempt struct {
+	AttemptID    string
+	DeliveryID   string
+	Attempt      int
+	StatusCode   int
+	ResponseBody string
+	Error        string
+	DurationMs   int64
+	CreatedAt    time.Time
+}
+
+func (WebhookDeliveryAttempt) TableName() string {
+	return "webhook_delivery_attempts"
+}
+
+type WebhookDeliveryAttempts []*WebhookDeliveryAttempt
@@ -0,0 +1,33 @@
+// Package openapi embeds the OpenAPI 3 specification of the REST API.
+package openapi
+
+import (
+	"context"
+	_ "embed"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+//go:embed openapi.yaml
+var spec []byte
+
+// Load parses and validates the embedded specification.
+func Load() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, err
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// SetMaxPageSize sets the maximum of the limit parameter of list endpoints,
+// so larger pages are rejected by the request validation.
+func SetMaxPageSize(doc *openapi3.T, maxPageSize int) {
+	limit := doc.Components.Parameters["Limit"].Value.Schema.Value
+	max := float64(maxPageSize)
+	limit.Max = &max
+}
@@ -0,0 +1,4 @@
+// Package bankingpb holds the code generated from proto/banking.proto.
+package bankingpb
+
+//go:generate protoc -I .. --go_out=../.. --go_opt=module=banking-service --go-grpc_out=../.. --go-grpc_opt=module=banking-service,require_unimplemented_servers=true ../banking.proto
@@ -5,6 +5,7 @@ import (
 	"banking-service/models"
 	"context"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -22,26 +23,46 @@ type (
 	}
 
 	GetAccountsArgs struct {
-		UserID string
-		Cursor string
-		Limit  int
+		UserID     string
+		AccountIDs []string
+		Cursor     string
+		ForUpdate  bool
+		// WithDeleted includes closed accounts.
+		WithDeleted bool
+		// Limit defaults to 100; a negative value returns every account.
+		Limit int
 	}
 
 	GetAccountIDsArgs struct {
 		UserID string
 	}
 
+	GetBalanceTotalsArgs struct {
+		UserID string
+	}
+
+	// SetFrozenArgs freezes the account when FrozenAt is set and unfreezes
+	// it otherwise.
+	SetFrozenArgs struct {
+		AccountID string
+		FrozenAt  *time.Time
+		Reason    string
+	}
+
 	AccountRepositoryI interface {
 		GetAccount(context.Context, *gorm.DB, *GetAccountArgs) (*models.Account, error)
 		GetAccounts(context.Context, *gorm.DB, *GetAccountsArgs) (models.Accounts, error)
 		GetAccountIDs(context.Context, *gorm.DB, *GetAccountIDsArgs) ([]string, error)
+		GetBalanceTotals(context.Context, *gorm.DB, *GetBalanceTotalsArgs) ([]*models.BalanceTotal, error)
 		Create(context.Context, *gorm.DB, *models.Account) error
 		Update(context.Context, *gorm.DB, *models.Account) error
+		SetFrozen(context.Context, *gorm.DB, *SetFrozenArgs) error
+		Delete(context.Context, *gorm.DB, *models.Account) error
 	}
 )
 
 func NewAccountRepository() AccountRepositoryI {
-	return &accountRepository{}
+	return tracedAccountRepository{next: &accountRepository{}}
 }
 
 func (accountRepository) Create(ctx context.Context, db *gorm.DB, account *models.Account) error {
@@ -81,15 +102,23 @@ func (accountRepository) GetAccounts(ctx context.Context, db *gorm.DB, args *Get
 	if args.UserID != "" {
 		db.Where("user_id = ?", args.UserID)
 	}
+	if args.AccountIDs != nil {
+		db.Where("account_id IN ?", args.AccountIDs)
+	}
 	if args.Cursor != "" {
 		db.Where("account_id < ?", args.Cursor)
 	}
 	if args.Limit == 0 {
 		args.Limit = 100
 	}
+	if args.ForUpdate {
+		db.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
 	db.Limit(args.Limit)
 	db.Order("account_id DESC")
-	db.Where("deleted_at IS NULL")
+	if !args.WithDeleted {
+		db.Where("deleted_at IS NULL")
+	}
 	err = db.Find(&accounts).Error
 
 	return
@@ -111,11 +140,36 @@ func (accountRepository) GetAccountIDs(ctx context.Context, db *gorm.DB, args *G
 	return accountIDs, result.Error
 }
 
+func (accountRepository) GetBalanceTotals(ctx context.Context, db *gorm.DB, args *GetBalanceTotalsArgs) ([]*models.BalanceTotal, error) {
+	db = db.
+		WithContext(ctx).
+		Table("accounts")
+
+	if args.UserID != "" {
+		db = db.Where("user_id = ?", args.UserID)
+	}
+	db = db.Where("deleted_at IS NULL")
+
+	var totals []*models.BalanceTotal
+	result := db.
+		Select("currency, SUM(balance) AS balance, COUNT(*) AS account_count").
+		Group("currency").
+		Order("currency").
+		Find(&totals)
+
+	return totals, result.Error
+}
+
+// Update writes every mutable column of account, including zero values, so
+// that a drained account is stored with a zero balance. Freezing and closing
+// go through SetFrozen and Delete.
 func (accountRepository) Update(ctx context.Context, db *gorm.DB, account *models.Account) (err error) {
 	db = db.
 		WithContext(ctx).
 		Table("accounts").
 		Where("account_id = ?", account.AccountID).
+		Select("*").
+		Omit("account_id", "user_id", "currency", "created_at", "deleted_at", "frozen_at", "freeze_reason").
 		Updates(account)
 	if err = db.Error; err != nil {
 		return err
@@ -128,13 +182,35 @@ func (accountRepository) Update(ctx context.Context, db *gorm.DB, account *model
 	return nil
 }
 
+func (accountRepository) SetFrozen(ctx context.Context, db *gorm.DB, args *SetFrozenArgs) (err error) {
+	db = db.
+		WithContext(ctx).
+		Table("accounts").
+		Where("account_id = ?", args.AccountID).
+		Where("deleted_at IS NULL").
+		Updates(map[string]interface{}{
+			"frozen_at":     args.FrozenAt,
+			"freeze_reason": args.Reason,
+			"updated_at":    time.Now(),
+		})
+	if err = db.Error; err != nil {
+		return err
+	}
+
+	if db.RowsAffected == 0 {
+		return errors.New(enums.NotRowsAffected)
+	}
+
+	return nil
+}
+
 func (accountRepository) Delete(ctx context.Context, db *gorm.DB, account *models.Account) (err error) {
 	db = db.
 		WithContext(ctx).
 		Table("accounts").
 		Where("account_id = ?", account.AccountID).
 		Where("user_id = ?", account.UserID).
-		Update("deleted_at", "NOW()")
+		Update("deleted_at", gorm.Expr("NOW()"))
 	if err = db.Error; err != nil {
 		return err
 	}
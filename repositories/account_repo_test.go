@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"banking-service/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger: gormLogger.Default.LogMode(gormLogger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db, mock
+}
+
+func TestAccountRepositoryUpdateWritesZeroBalance(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	now := time.Now()
+	account := &models.Account{
+		AccountID: "fde7f07a-fd12-493c-83a9-7bec2644c4c2",
+		UserID:    "1719286237483253760",
+		Name:      "savings",
+		Currency:  "USD",
+		Balance:   0,
+		CreatedAt: now.Add(-time.Hour),
+		UpdatedAt: now,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "accounts" SET "name"=$1,"balance"=$2,"updated_at"=$3 WHERE account_id = $4`)).
+		WithArgs(account.Name, float64(0), sqlmock.AnyArg(), account.AccountID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := NewAccountRepository().Update(context.Background(), db, account); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"banking-service/enums"
+	"banking-service/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ APIKeyRepositoryI = &apiKeyRepository{}
+
+type APIKeyRepositoryI interface {
+	Create(ctx context.Context, db *gorm.DB, apiKey *models.APIKey) error
+	GetAPIKey(ctx context.Context, db *gorm.DB, args *GetAPIKeyArgs) (*models.APIKey, error)
+	GetAPIKeys(ctx context.Context, db *gorm.DB, args *GetAPIKeysArgs) (models.APIKeys, error)
+	Update(ctx context.Context, db *gorm.DB, apiKey *models.APIKey) error
+	UpdateLastUsedAt(ctx context.Context, db *gorm.DB, args *UpdateLastUsedAtArgs) error
+}
+
+type apiKeyRepository struct {
+}
+
+func NewAPIKeyRepository() APIKeyRepositoryI {
+	return &apiKeyRepository{}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, db *gorm.DB, apiKey *models.APIKey) error {
+	return db.WithContext(ctx).Table("api_keys").Create(apiKey).Error
+}
+
+type GetAPIKeyArgs struct {
+	KeyID     string
+	ForUpdate bool
+}
+
+func (r *apiKeyRepository) GetAPIKey(ctx context.Context, db *gorm.DB, args *GetAPIKeyArgs) (*models.APIKey, error) {
+	query := db.WithContext(ctx).Table("api_keys")
+	if args.KeyID != "" {
+		query.Where("key_id = ?", args.KeyID)
+	}
+	if args.ForUpdate {
+		query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var apiKey models.APIKey
+	result := query.First(&apiKey)
+
+	return &apiKey, result.Error
+}
+
+type GetAPIKeysArgs struct {
+	Cursor string
+	Limit  int
+}
+
+func (r *apiKeyRepository) GetAPIKeys(ctx context.Context, db *gorm.DB, args *GetAPIKeysArgs) (apiKeys models.APIKeys, _ error) {
+	db = db.WithContext(ctx).Table("api_keys")
+	if args.Cursor != "" {
+		db.Where("key_id < ?", args.Cursor)
+	}
+	if args.Limit == 0 {
+		args.Limit = 100
+	}
+
+	db.Order("key_id DESC")
+	db.Limit(args.Limit)
+
+	result := db.Find(&apiKeys)
+
+	return apiKeys, result.Error
+}
+
+func (r *apiKeyRepository) Update(ctx context.Context, db *gorm.DB, apiKey *models.APIKey) error {
+	db = db.
+		WithContext(ctx).
+		Table("api_keys").
+		Where("key_id = ?", apiKey.KeyID).
+		Select("*").
+		Omit("key_id", "key_hash", "created_at").
+		Updates(apiKey)
+	if err := db.Error; err != nil {
+		return err
+	}
+
+	if db.RowsAffected == 0 {
+		return errors.New(enums.NotRowsAffected)
+	}
+
+	return nil
+}
+
+type UpdateLastUsedAtArgs struct {
+	KeyID      string
+	LastUsedAt time.Time
+}
+
+// UpdateLastUsedAt only writes last_used_at, so it cannot undo a revocation,
+// rotation or role change committed since the key was read. Revoked keys are
+// left alone.
+func (r *apiKeyRepository) UpdateLastUsedAt(ctx context.Context, db *gorm.DB, args *UpdateLastUsedAtArgs) error {
+	return db.
+		WithContext(ctx).
+		Table("api_keys").
+		Where("key_id = ? AND revoked_at IS NULL", args.KeyID).
+		UpdateColumn("last_used_at", args.LastUsedAt).
+		Error
+}
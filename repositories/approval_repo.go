@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"banking-service/enums"
+	"banking-service/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ ApprovalRepositoryI = &approvalRepository{}
+
+type ApprovalRepositoryI interface {
+	Create(ctx context.Context, db *gorm.DB, approval *models.Approval) error
+	GetApproval(ctx context.Context, db *gorm.DB, args *GetApprovalArgs) (*models.Approval, error)
+	GetApprovals(ctx context.Context, db *gorm.DB, args *GetApprovalsArgs) (models.Approvals, error)
+	Update(ctx context.Context, db *gorm.DB, approval *models.Approval) error
+	ExpireApprovals(ctx context.Context, db *gorm.DB, args *ExpireApprovalsArgs) (int64, error)
+}
+
+type approvalRepository struct {
+}
+
+func NewApprovalRepository() ApprovalRepositoryI {
+	return &approvalRepository{}
+}
+
+func (r *approvalRepository) Create(ctx context.Context, db *gorm.DB, approval *models.Approval) error {
+	return db.WithContext(ctx).Table("approvals").Create(approval).Error
+}
+
+type GetApprovalArgs struct {
+	ApprovalID string
+	ForUpdate  bool
+}
+
+func (r *approvalRepository) GetApproval(ctx context.Context, db *gorm.DB, args *GetApprovalArgs) (*models.Approval, error) {
+	query := db.WithContext(ctx).Table("approvals")
+	if args.ApprovalID != "" {
+		query.Where("approval_id = ?", args.ApprovalID)
+	}
+	if args.ForUpdate {
+		query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var approval models.Approval
+	result := query.First(&approval)
+
+	return &approval, result.Error
+}
+
+type GetApprovalsArgs struct {
+	Status    string
+	AccountID string
+	Cursor    string
+	Limit     int
+}
+
+func (r *approvalRepository) GetApprovals(ctx context.Context, db *gorm.DB, args *GetApprovalsArgs) (approvals models.Approvals, _ error) {
+	db = db.WithContext(ctx).Table("approvals")
+	if args.Status != "" {
+		db.Where("status = ?", args.Status)
+	}
+	if args.AccountID != "" {
+		db.Where("account_id = ?", args.AccountID)
+	}
+	if args.Cursor != "" {
+		db.Where("approval_id < ?", args.Cursor)
+	}
+	if args.Limit == 0 {
+		args.Limit = 100
+	}
+
+	db.Order("approval_id DESC")
+	db.Limit(args.Limit)
+
+	result := db.Find(&approvals)
+
+	return approvals, result.Error
+}
+
+func (r *approvalRepository) Update(ctx context.Context, db *gorm.DB, approval *models.Approval) error {
+	db = db.
+		WithContext(ctx).
+		Table("approvals").
+		Where("approval_id = ?", approval.ApprovalID).
+		Select("*").
+		Omit("approval_id", "created_at").
+		Updates(approval)
+	if err := db.Error; err != nil {
+		return err
+	}
+
+	if db.RowsAffected == 0 {
+		return errors.New(enums.NotRowsAffected)
+	}
+
+	return nil
+}
+
+type ExpireApprovalsArgs struct {
+	Now time.Time
+}
+
+// ExpireApprovals marks every pending approval past its expiry as expired and
+// returns how many were changed.
+func (r *approvalRepository) ExpireApprovals(ctx context.Context, db *gorm.DB, args *ExpireApprovalsArgs) (int64, error) {
+	result := db.
+		WithContext(ctx).
+		Table("approvals").
+		Where("status = ?", enums.ApprovalPending.String()).
+		Where("expires_at <= ?", args.Now).
+		Updates(map[string]interface{}{
+			"status":     enums.ApprovalExpired.String(),
+			"updated_at": args.Now,
+		})
+
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"banking-service/models"
+
+	"gorm.io/gorm"
+)
+
+var _ AuditLogRepositoryI = &auditLogRepository{}
+
+// AuditLogRepositoryI has no update or delete on purpose; the table rejects
+// them as well.
+type AuditLogRepositoryI interface {
+	Create(ctx context.Context, db *gorm.DB, auditLog *models.AuditLog) error
+	GetAuditLog(ctx context.Context, db *gorm.DB, args *GetAuditLogArgs) (*models.AuditLog, error)
+	GetAuditLogs(ctx context.Context, db *gorm.DB, args *GetAuditLogsArgs) (models.AuditLogs, error)
+}
+
+type auditLogRepository struct {
+}
+
+func NewAuditLogRepository() AuditLogRepositoryI {
+	return &auditLogRepository{}
+}
+
+func (r *auditLogRepository) Create(ctx context.Context, db *gorm.DB, auditLog *models.AuditLog) error {
+	return db.WithContext(ctx).Table("audit_logs").Create(auditLog).Error
+}
+
+type GetAuditLogArgs struct {
+	AuditID string
+}
+
+func (r *auditLogRepository) GetAuditLog(ctx context.Context, db *gorm.DB, args *GetAuditLogArgs) (*models.AuditLog, error) {
+	query := db.WithContext(ctx).Table("audit_logs")
+	if args.AuditID != "" {
+		query.Where("audit_id = ?", args.AuditID)
+	}
+
+	var auditLog models.AuditLog
+	result := query.First(&auditLog)
+
+	return &auditLog, result.Error
+}
+
+type GetAuditLogsArgs struct {
+	ActorID   string
+	RequestID string
+	AccountID string
+	Route     string
+	From      time.Time
+	To        time.Time
+	Cursor    string
+	Limit     int
+}
+
+func (r *auditLogRepository) GetAuditLogs(ctx context.Context, db *gorm.DB, args *GetAuditLogsArgs) (auditLogs models.AuditLogs, _ error) {
+	db = db.WithContext(ctx).Table("audit_logs")
+	if args.ActorID != "" {
+		db.Where("actor_id = ?", args.ActorID)
+	}
+	if args.RequestID != "" {
+		db.Where("request_id = ?", args.RequestID)
+	}
+	if args.AccountID != "" {
+		filter, err := json.Marshal([]map[string]string{{"account_id": args.AccountID}})
+		if err != nil {
+			return nil, err
+		}
+		db.Where("account_changes @> ?::jsonb", string(filter))
+	}
+	if args.Route != "" {
+		db.Where("route = ?", args.Route)
+	}
+	if !args.From.IsZero() {
+		db.Where("created_at >= ?", args.From)
+	}
+	if !args.To.IsZero() {
+		db.Where("created_at < ?", args.To)
+	}
+	if args.Cursor != "" {
+		db.Where("audit_id < ?", args.Cursor)
+	}
+	if args.Limit == 0 {
+		args.Limit = 100
+	}
+
+	db.Order("audit_id DESC")
+	db.Limit(args.Limit)
+
+	result := db.Find(&auditLogs)
+
+	return auditLogs, result.Error
+}
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+
+	"banking-service/models"
+
+	"gorm.io/gorm"
+)
+
+var _ ChangeRepositoryI = &changeRepository{}
+
+type (
+	GetChangesArgs struct {
+		// After is the position of the last change already consumed.
+		After      int64
+		EntityType string
+		EntityID   string
+		Operation  string
+		// AccountID keeps the changes of the transactions of one account.
+		AccountID string
+		Limit     int
+	}
+
+	SequenceChangesArgs struct {
+		Limit int
+	}
+
+	ChangeRepositoryI interface {
+		GetChanges(ctx context.Context, db *gorm.DB, args *GetChangesArgs) (models.Changes, error)
+		SequenceChanges(ctx context.Context, db *gorm.DB, args *SequenceChangesArgs) (int64, error)
+		GetLatestPosition(ctx context.Context, db *gorm.DB) (int64, error)
+	}
+)
+
+type changeRepository struct {
+}
+
+func NewChangeRepository() ChangeRepositoryI {
+	return &changeRepository{}
+}
+
+// GetChanges returns sequenced changes in position order.
+func (changeRepository) GetChanges(ctx context.Context, db *gorm.DB, args *GetChangesArgs) (changes models.Changes, err error) {
+	db = db.
+		WithContext(ctx).
+		Table("changes").
+		Where("position > ?", args.After)
+
+	if args.EntityType != "" {
+		db.Where("entity_type = ?", args.EntityType)
+	}
+	if args.EntityID != "" {
+		db.Where("entity_id = ?", args.EntityID)
+	}
+	if args.Operation != "" {
+		db.Where("operation = ?", args.Operation)
+	}
+	if args.AccountID != "" {
+		db.Where("EXISTS (SELECT 1 FROM transactions WHERE transactions.transaction_id = changes.entity_id AND transactions.account_id = ?)", args.AccountID)
+	}
+	if args.Limit == 0 {
+		args.Limit = 100
+	}
+	db.Order("position ASC")
+	db.Limit(args.Limit)
+
+	err = db.Find(&changes).Error
+
+	return
+}
+
+// SequenceChanges assigns the next positions to committed changes that have
+// none yet and returns how many were sequenced. Callers must be the only
+// sequencer, see workers.ChangeSequencer.
+func (changeRepository) SequenceChanges(ctx context.Context, db *gorm.DB, args *SequenceChangesArgs) (int64, error) {
+	result := db.
+		WithContext(ctx).
+		Exec(`UPDATE changes SET position = s.position
+FROM (
+    SELECT change_id, (SELECT COALESCE(MAX(position), 0) FROM changes) + ROW_NUMBER() OVER (ORDER BY change_id) AS position
+    FROM changes
+    WHERE position IS NULL
+    ORDER BY change_id
+    LIMIT ?
+) s
+WHERE changes.change_id = s.change_id`, args.Limit)
+
+	return result.RowsAffected, result.Error
+}
+
+// GetLatestPosition returns the highest assigned position, 0 when nothing
+// was sequenced yet.
+func (changeRepository) GetLatestPosition(ctx context.Context, db *gorm.DB) (int64, error) {
+	var position int64
+	err := db.
+		WithContext(ctx).
+		Table("changes").
+		Select("COALESCE(MAX(position), 0)").
+		Scan(&position).
+		Error
+
+	return position, err
+}
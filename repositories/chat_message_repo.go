@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"context"
+
+	"banking-service/models"
+
+	"gorm.io/gorm"
+)
+
+var _ ChatMessageRepositoryI = &chatMessageRepository{}
+
+type ChatMessageRepositoryI interface {
+	Create(ctx context.Context, db *gorm.DB, message *models.ChatMessage) error
+	GetChatMessage(ctx context.Context, db *gorm.DB, args *GetChatMessageArgs) (*models.ChatMessage, error)
+	GetChatMessages(ctx context.Context, db *gorm.DB, args *GetChatMessagesArgs) (models.ChatMessages, error)
+	RedactChatMessages(ctx context.Context, db *gorm.DB, args *RedactChatMessagesArgs) error
+}
+
+type chatMessageRepository struct {
+}
+
+func NewChatMessageRepository() ChatMessageRepositoryI {
+	return &chatMessageRepository{}
+}
+
+func (r *chatMessageRepository) Create(ctx context.Context, db *gorm.DB, message *models.ChatMessage) error {
+	return db.WithContext(ctx).Table("chat_messages").Create(message).Error
+}
+
+type GetChatMessageArgs struct {
+	MessageID      string
+	ConversationID string
+}
+
+func (r *chatMessageRepository) GetChatMessage(ctx context.Context, db *gorm.DB, args *GetChatMessageArgs) (*models.ChatMessage, error) {
+	query := db.WithContext(ctx).Table("chat_messages")
+	if args.MessageID != "" {
+		query.Where("message_id = ?", args.MessageID)
+	}
+	if args.ConversationID != "" {
+		query.Where("conversation_id = ?", args.ConversationID)
+	}
+
+	var message models.ChatMessage
+	result := query.First(&message)
+
+	return &message, result.Error
+}
+
+type GetChatMessagesArgs struct {
+	ConversationID string
+	// Cursor returns messages older than the given message ID, newest first.
+	Cursor string
+	Limit  int
+}
+
+func (r *chatMessageRepository) GetChatMessages(ctx context.Context, db *gorm.DB, args *GetChatMessagesArgs) (messages models.ChatMessages, _ error) {
+	db = db.WithContext(ctx).Table("chat_messages")
+	if args.ConversationID != "" {
+		db.Where("conversation_id = ?", args.ConversationID)
+	}
+	if args.Cursor != "" {
+		db.Where("message_id < ?", args.Cursor)
+	}
+	if args.Limit == 0 {
+		args.Limit = 100
+	}
+
+	db.Order("message_id DESC")
+	db.Limit(args.Limit)
+
+	result := db.Find(&messages)
+
+	return messages, result.Error
+}
+
+type RedactChatMessagesArgs struct {
+	SenderID string
+	Text     string
+}
+
+// RedactChatMessages replaces the text of every message sent by SenderID.
+func (r *chatMessageRepository) RedactChatMessages(ctx context.Context, db *gorm.DB, args *RedactChatMessagesArgs) error {
+	return db.
+		WithContext(ctx).
+		Table("chat_messages").
+		Where("sender_id = ?", args.SenderID).
+		Update("text", args.Text).Error
+}
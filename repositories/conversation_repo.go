@@ -0,0 +1,173 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"banking-service/enums"
+	"banking-service/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ ConversationRepositoryI = &conversationRepository{}
+
+type ConversationRepositoryI interface {
+	Create(ctx context.Context, db *gorm.DB, conversation *models.Conversation) error
+	GetConversation(ctx context.Context, db *gorm.DB, args *GetConversationArgs) (*models.Conversation, error)
+	GetConversations(ctx context.Context, db *gorm.DB, args *GetConversationsArgs) (models.Conversations, error)
+	Update(ctx context.Context, db *gorm.DB, conversation *models.Conversation) error
+	// AddParticipant ignores participants already in the conversation.
+	AddParticipant(ctx context.Context, db *gorm.DB, participant *models.ConversationParticipant) error
+	GetParticipant(ctx context.Context, db *gorm.DB, args *GetParticipantArgs) (*models.ConversationParticipant, error)
+	GetParticipants(ctx context.Context, db *gorm.DB, args *GetParticipantsArgs) (models.ConversationParticipants, error)
+	UpdateParticipant(ctx context.Context, db *gorm.DB, participant *models.ConversationParticipant) error
+}
+
+type conversationRepository struct {
+}
+
+func NewConversationRepository() ConversationRepositoryI {
+	return &conversationRepository{}
+}
+
+func (r *conversationRepository) Create(ctx context.Context, db *gorm.DB, conversation *models.Conversation) error {
+	return db.WithContext(ctx).Table("conversations").Create(conversation).Error
+}
+
+type GetConversationArgs struct {
+	ConversationID string
+	ForUpdate      bool
+}
+
+func (r *conversationRepository) GetConversation(ctx context.Context, db *gorm.DB, args *GetConversationArgs) (*models.Conversation, error) {
+	query := db.WithContext(ctx).Table("conversations")
+	if args.ConversationID != "" {
+		query.Where("conversation_id = ?", args.ConversationID)
+	}
+	if args.ForUpdate {
+		query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var conversation models.Conversation
+	result := query.First(&conversation)
+
+	return &conversation, result.Error
+}
+
+type GetConversationsArgs struct {
+	ParticipantID string
+	AccountID     string
+	Cursor        string
+	Limit         int
+}
+
+func (r *conversationRepository) GetConversations(ctx context.Context, db *gorm.DB, args *GetConversationsArgs) (conversations models.Conversations, _ error) {
+	db = db.WithContext(ctx).Table("conversations")
+	if args.ParticipantID != "" {
+		db.Where("conversation_id IN (?)", db.Session(&gorm.Session{NewDB: true}).
+			Table("conversation_participants").
+			Select("conversation_id").
+			Where("participant_id = ?", args.ParticipantID))
+	}
+	if args.AccountID != "" {
+		db.Where("account_id = ?", args.AccountID)
+	}
+	if args.Cursor != "" {
+		db.Where("conversation_id < ?", args.Cursor)
+	}
+	if args.Limit == 0 {
+		args.Limit = 100
+	}
+
+	db.Order("conversation_id DESC")
+	db.Limit(args.Limit)
+
+	result := db.Find(&conversations)
+
+	return conversations, result.Error
+}
+
+func (r *conversationRepository) Update(ctx context.Context, db *gorm.DB, conversation *models.Conversation) error {
+	db = db.
+		WithContext(ctx).
+		Table("conversations").
+		Where("conversation_id = ?", conversation.ConversationID).
+		Select("*").
+		Omit("conversation_id", "created_by", "created_at").
+		Updates(conversation)
+	if err := db.Error; err != nil {
+		return err
+	}
+
+	if db.RowsAffected == 0 {
+		return errors.New(enums.NotRowsAffected)
+	}
+
+	return nil
+}
+
+func (r *conversationRepository) AddParticipant(ctx context.Context, db *gorm.DB, participant *models.ConversationParticipant) error {
+	return db.
+		WithContext(ctx).
+		Table("conversation_participants").
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(participant).Error
+}
+
+type GetParticipantArgs struct {
+	ConversationID string
+	ParticipantID  string
+	ForUpdate      bool
+}
+
+func (r *conversationRepository) GetParticipant(ctx context.Context, db *gorm.DB, args *GetParticipantArgs) (*models.ConversationParticipant, error) {
+	query := db.
+		WithContext(ctx).
+		Table("conversation_participants").
+		Where("conversation_id = ?", args.ConversationID).
+		Where("participant_id = ?", args.ParticipantID)
+	if args.ForUpdate {
+		query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var participant models.ConversationParticipant
+	result := query.First(&participant)
+
+	return &participant, result.Error
+}
+
+type GetParticipantsArgs struct {
+	ConversationID string
+}
+
+func (r *conversationRepository) GetParticipants(ctx context.Context, db *gorm.DB, args *GetParticipantsArgs) (participants models.ConversationParticipants, _ error) {
+	result := db.
+		WithContext(ctx).
+		Table("conversation_participants").
+		Where("conversation_id = ?", args.ConversationID).
+		Order("joined_at ASC").
+		Find(&participants)
+
+	return participants, result.Error
+}
+
+func (r *conversationRepository) UpdateParticipant(ctx context.Context, db *gorm.DB, participant *models.ConversationParticipant) error {
+	db = db.
+		WithContext(ctx).
+		Table("conversation_participants").
+		Where("conversation_id = ?", participant.ConversationID).
+		Where("participant_id = ?", participant.ParticipantID).
+		Select("last_read_message_id", "last_read_at").
+		Updates(participant)
+	if err := db.Error; err != nil {
+		return err
+	}
+
+	if db.RowsAffected == 0 {
+		return errors.New(enums.NotRowsAffected)
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"banking-service/enums"
+	"banking-service/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ OutboxEventRepositoryI = &outboxEventRepository{}
+
+type OutboxEventRepositoryI interface {
+	Create(ctx context.Context, db *gorm.DB, event *models.OutboxEvent) error
+	GetOutboxEvents(ctx context.Context, db *gorm.DB, args *GetOutboxEventsArgs) (models.OutboxEvents, error)
+	MarkPublished(ctx context.Context, db *gorm.DB, args *MarkPublishedArgs) error
+	RecordFailure(ctx context.Context, db *gorm.DB, args *RecordFailureArgs) error
+	MarkUnpublished(ctx context.Context, db *gorm.DB, args *MarkUnpublishedArgs) (int64, error)
+}
+
+type outboxEventRepository struct {
+}
+
+func NewOutboxEventRepository() OutboxEventRepositoryI {
+	return &outboxEventRepository{}
+}
+
+func (r *outboxEventRepository) Create(ctx context.Context, db *gorm.DB, event *models.OutboxEvent) error {
+	return db.WithContext(ctx).Table("outbox_events").Create(event).Error
+}
+
+type GetOutboxEventsArgs struct {
+	Unpublished bool
+	AggregateID string
+	// AfterSequence returns events with a greater sequence, oldest first.
+	AfterSequence int64
+	ForUpdate     bool
+	Limit         int
+}
+
+func (r *outboxEventRepository) GetOutboxEvents(ctx context.Context, db *gorm.DB, args *GetOutboxEventsArgs) (events models.OutboxEvents, _ error) {
+	db = db.WithContext(ctx).Table("outbox_events")
+	if args.Unpublished {
+		db.Where("published_at IS NULL AND dead_lettered_at IS NULL")
+	}
+	if args.AggregateID != "" {
+		db.Where("aggregate_id = ?", args.AggregateID)
+	}
+	if args.AfterSequence != 0 {
+		db.Where("sequence > ?", args.AfterSequence)
+	}
+	if args.ForUpdate {
+		db.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	if args.Limit == 0 {
+		args.Limit = 100
+	}
+
+	db.Order("sequence ASC")
+	db.Limit(args.Limit)
+
+	result := db.Find(&events)
+
+	return events, result.Error
+}
+
+type MarkPublishedArgs struct {
+	Sequence    int64
+	PublishedAt time.Time
+}
+
+func (r *outboxEventRepository) MarkPublished(ctx context.Context, db *gorm.DB, args *MarkPublishedArgs) error {
+	db = db.
+		WithContext(ctx).
+		Table("outbox_events").
+		Where("sequence = ?", args.Sequence).
+		Updates(map[string]interface{}{
+			"published_at": args.PublishedAt,
+			"attempts":     gorm.Expr("attempts + 1"),
+			"last_error":   "",
+		})
+	if err := db.Error; err != nil {
+		return err
+	}
+
+	if db.RowsAffected == 0 {
+		return errors.New(enums.NotRowsAffected)
+	}
+
+	return nil
+}
+
+type RecordFailureArgs struct {
+	Sequence int64
+	Error    string
+	// DeadLetteredAt sets the event aside when its last attempt failed.
+	DeadLetteredAt *time.Time
+}
+
+func (r *outboxEventRepository) RecordFailure(ctx context.Context, db *gorm.DB, args *RecordFailureArgs) error {
+	db = db.
+		WithContext(ctx).
+		Table("outbox_events").
+		Where("sequence = ?", args.Sequence).
+		Updates(map[string]interface{}{
+			"attempts":         gorm.Expr("attempts + 1"),
+			"last_error":       args.Error,
+			"dead_lettered_at": args.DeadLetteredAt,
+		})
+	if err := db.Error; err != nil {
+		return err
+	}
+
+	if db.RowsAffected == 0 {
+		return errors.New(enums.NotRowsAffected)
+	}
+
+	return nil
+}
+
+// MarkUnpublishedArgs selects the events to replay. ToSequence 0 means no
+// upper bound.
+type MarkUnpublishedArgs struct {
+	FromSequence int64
+	ToSequence   int64
+	EventType    string
+	AggregateID  string
+}
+
+// MarkUnpublished clears published_at, and dead_lettered_at with the attempt
+// count, so the relay delivers the events again, and returns how many were
+// marked. Sinks deduplicate by event ID.
+func (r *outboxEventRepository) MarkUnpublished(ctx context.Context, db *gorm.DB, args *MarkUnpublishedArgs) (int64, error) {
+	db = db.
+		WithContext(ctx).
+		Table("outbox_events").
+		Where("published_at IS NOT NULL OR dead_lettered_at IS NOT NULL").
+		Where("sequence >= ?", args.FromSequence)
+	if args.ToSequence != 0 {
+		db.Where("sequence <= ?", args.ToSequence)
+	}
+	if args.EventType != "" {
+		db.Where("event_type = ?", args.EventType)
+	}
+	if args.AggregateID != "" {
+		db.Where("aggregate_id = ?", args.AggregateID)
+	}
+
+	db = db.Updates(map[string]interface{}{
+		"published_at":     nil,
+		"dead_lettered_at": nil,
+		"attempts":         0,
+		"last_error":       "",
+	})
+
+	return db.RowsAffected, db.Error
+}
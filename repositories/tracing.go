@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"context"
+
+	"banking-service/models"
+	"banking-service/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+var (
+	_ AccountRepositoryI     = tracedAccountRepository{}
+	_ TransactionRepositoryI = tracedTransactionRepository{}
+	_ UserRepositoryI        = tracedUserRepository{}
+)
+
+// startSpan starts the span of a repository call, under the transaction span
+// when db is a transaction.
+func startSpan(ctx context.Context, db *gorm.DB, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Start(tracing.TransactionContext(ctx, db), name, attrs...)
+}
+
+type tracedAccountRepository struct {
+	next AccountRepositoryI
+}
+
+func (r tracedAccountRepository) GetAccount(ctx context.Context, db *gorm.DB, args *GetAccountArgs) (_ *models.Account, err error) {
+	ctx, span := startSpan(ctx, db, "AccountRepository.GetAccount",
+		attribute.String("account_id", args.AccountID),
+		attribute.Bool("for_update", args.ForUpdate),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.GetAccount(ctx, db, args)
+}
+
+func (r tracedAccountRepository) GetAccounts(ctx context.Context, db *gorm.DB, args *GetAccountsArgs) (_ models.Accounts, err error) {
+	ctx, span := startSpan(ctx, db, "AccountRepository.GetAccounts",
+		attribute.Int("account_ids", len(args.AccountIDs)),
+		attribute.Bool("for_update", args.ForUpdate),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.GetAccounts(ctx, db, args)
+}
+
+func (r tracedAccountRepository) GetAccountIDs(ctx context.Context, db *gorm.DB, args *GetAccountIDsArgs) (_ []string, err error) {
+	ctx, span := startSpan(ctx, db, "AccountRepository.GetAccountIDs")
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.GetAccountIDs(ctx, db, args)
+}
+
+func (r tracedAccountRepository) GetBalanceTotals(ctx context.Context, db *gorm.DB, args *GetBalanceTotalsArgs) (_ []*models.BalanceTotal, err error) {
+	ctx, span := startSpan(ctx, db, "AccountRepository.GetBalanceTotals")
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.GetBalanceTotals(ctx, db, args)
+}
+
+func (r tracedAccountRepository) Create(ctx context.Context, db *gorm.DB, account *models.Account) (err error) {
+	ctx, span := startSpan(ctx, db, "AccountRepository.Create")
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.Create(ctx, db, account)
+}
+
+func (r tracedAccountRepository) Update(ctx context.Context, db *gorm.DB, account *models.Account) (err error) {
+	ctx, span := startSpan(ctx, db, "AccountRepository.Update",
+		attribute.String("account_id", account.AccountID),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.Update(ctx, db, account)
+}
+
+func (r tracedAccountRepository) SetFrozen(ctx context.Context, db *gorm.DB, args *SetFrozenArgs) (err error) {
+	ctx, span := startSpan(ctx, db, "AccountRepository.SetFrozen",
+		attribute.String("account_id", args.AccountID),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.SetFrozen(ctx, db, args)
+}
+
+func (r tracedAccountRepository) Delete(ctx context.Context, db *gorm.DB, account *models.Account) (err error) {
+	ctx, span := startSpan(ctx, db, "AccountRepository.Delete",
+		attribute.String("account_id", account.AccountID),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.Delete(ctx, db, account)
+}
+
+type tracedTransactionRepository struct {
+	next TransactionRepositoryI
+}
+
+func (r tracedTransactionRepository) GetTransaction(ctx context.Context, db *gorm.DB, args *GetTransactionArgs) (_ *models.Transaction, err error) {
+	ctx, span := startSpan(ctx, db, "TransactionRepository.GetTransaction",
+		attribute.String("transaction_id", args.TransactionID),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.GetTransaction(ctx, db, args)
+}
+
+func (r tracedTransactionRepository) GetTransactions(ctx context.Context, db *gorm.DB, args *GetTransactionsArgs) (_ models.Transactions, err error) {
+	ctx, span := startSpan(ctx, db, "TransactionRepository.GetTransactions",
+		attribute.String("account_id", args.AccountID),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.GetTransactions(ctx, db, args)
+}
+
+func (r tracedTransactionRepository) GetOutflowTotal(ctx context.Context, db *gorm.DB, args *GetOutflowTotalArgs) (_ float64, err error) {
+	ctx, span := startSpan(ctx, db, "TransactionRepository.GetOutflowTotal")
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.GetOutflowTotal(ctx, db, args)
+}
+
+func (r tracedTransactionRepository) GetLedgerTotals(ctx context.Context, db *gorm.DB, args *GetLedgerTotalsArgs) (_ []*models.LedgerTotal, err error) {
+	ctx, span := startSpan(ctx, db, "TransactionRepository.GetLedgerTotals")
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.GetLedgerTotals(ctx, db, args)
+}
+
+func (r tracedTransactionRepository) Create(ctx context.Context, db *gorm.DB, transaction *models.Transaction) (err error) {
+	ctx, span := startSpan(ctx, db, "TransactionRepository.Create",
+		attribute.String("account_id", transaction.AccountID),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.Create(ctx, db, transaction)
+}
+
+type tracedUserRepository struct {
+	next UserRepositoryI
+}
+
+func (r tracedUserRepository) Create(ctx context.Context, db *gorm.DB, user *models.User) (err error) {
+	ctx, span := startSpan(ctx, db, "UserRepository.Create")
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.Create(ctx, db, user)
+}
+
+func (r tracedUserRepository) GetUser(ctx context.Context, db *gorm.DB, args *GetUserArgs) (_ *models.User, err error) {
+	ctx, span := startSpan(ctx, db, "UserRepository.GetUser",
+		attribute.String("user_id", args.UserID),
+		attribute.Bool("for_update", args.ForUpdate),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.GetUser(ctx, db, args)
+}
+
+func (r tracedUserRepository) GetUsers(ctx context.Context, db *gorm.DB, args *GetUsersArgs) (_ []*models.User, err error) {
+	ctx, span := startSpan(ctx, db, "UserRepository.GetUsers")
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.GetUsers(ctx, db, args)
+}
+
+func (r tracedUserRepository) Update(ctx context.Context, db *gorm.DB, user *models.User) (err error) {
+	ctx, span := startSpan(ctx, db, "UserRepository.Update",
+		attribute.String("user_id", user.UserID),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	return r.next.Update(ctx, db, user)
+}
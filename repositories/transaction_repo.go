@@ -1,8 +1,12 @@
 package repositories
 
 import (
+	"banking-service/enums"
 	"banking-service/models"
 	"context"
+	"encoding/json"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -13,23 +17,43 @@ type (
 	TransactionRepository struct{}
 
 	GetTransactionArgs struct {
-		ID string
+		TransactionID string
 	}
 	GetTransactionsArgs struct {
-		AccountID string
-		Cursor    string
-		Limit     int
+		TransactionIDs []string
+		AccountID      string
+		AccountIDs     []string
+		Reference      string
+		EndToEndID     string
+		Description    string
+		Tags           []string
+		Cursor         string
+		// After returns the transactions newer than this ID, oldest first.
+		// It cannot be combined with Cursor.
+		After string
+		Limit int
+	}
+
+	GetOutflowTotalArgs struct {
+		UserID string
+		Since  time.Time
+	}
+
+	GetLedgerTotalsArgs struct {
+		AccountIDs []string
 	}
 
 	TransactionRepositoryI interface {
 		GetTransaction(context.Context, *gorm.DB, *GetTransactionArgs) (*models.Transaction, error)
 		GetTransactions(context.Context, *gorm.DB, *GetTransactionsArgs) (models.Transactions, error)
+		GetOutflowTotal(context.Context, *gorm.DB, *GetOutflowTotalArgs) (float64, error)
+		GetLedgerTotals(context.Context, *gorm.DB, *GetLedgerTotalsArgs) ([]*models.LedgerTotal, error)
 		Create(context.Context, *gorm.DB, *models.Transaction) error
 	}
 )
 
 func NewTransactionRepository() TransactionRepositoryI {
-	return &TransactionRepository{}
+	return tracedTransactionRepository{next: &TransactionRepository{}}
 }
 
 func (TransactionRepository) Create(ctx context.Context, db *gorm.DB, account *models.Transaction) error {
@@ -45,14 +69,14 @@ func (TransactionRepository) GetTransaction(ctx context.Context, db *gorm.DB, ar
 		WithContext(ctx).
 		Table("transactions")
 
-	if args.ID != "" {
-		db.Where("id = ?", args.ID)
+	if args.TransactionID != "" {
+		db.Where("transaction_id = ?", args.TransactionID)
 	}
 
-	var transaction *models.Transaction
-	result := db.First(transaction)
+	var transaction models.Transaction
+	result := db.First(&transaction)
 
-	return transaction, result.Error
+	return &transaction, result.Error
 }
 
 func (TransactionRepository) GetTransactions(ctx context.Context, db *gorm.DB, args *GetTransactionsArgs) (transactions models.Transactions, err error) {
@@ -60,19 +84,87 @@ func (TransactionRepository) GetTransactions(ctx context.Context, db *gorm.DB, a
 		WithContext(ctx).
 		Table("transactions")
 
+	if args.TransactionIDs != nil {
+		db.Where("transaction_id IN ?", args.TransactionIDs)
+	}
 	if args.AccountID != "" {
 		db.Where("account_id = ?", args.AccountID)
 	}
+	if args.AccountIDs != nil {
+		db.Where("account_id IN ?", args.AccountIDs)
+	}
+	if args.Reference != "" {
+		db.Where("reference = ?", args.Reference)
+	}
+	if args.EndToEndID != "" {
+		db.Where("end_to_end_id = ?", args.EndToEndID)
+	}
+	if args.Description != "" {
+		db.Where("description ILIKE ?", "%"+escapeLike(args.Description)+"%")
+	}
+	if len(args.Tags) != 0 {
+		tags, err := json.Marshal(args.Tags)
+		if err != nil {
+			return nil, err
+		}
+		db.Where("tags @> ?::jsonb", string(tags))
+	}
 	if args.Cursor != "" {
 		db.Where("transaction_id < ?", args.Cursor)
 	}
 	if args.Limit == 0 {
 		args.Limit = 100
 	}
-	db.Order("transaction_id DESC")
+	if args.After != "" {
+		db.Where("transaction_id > ?", args.After)
+		db.Order("transaction_id ASC")
+	} else {
+		db.Order("transaction_id DESC")
+	}
 	db.Limit(args.Limit)
 
 	err = db.Find(&transactions).Error
 
 	return
 }
+
+// GetOutflowTotal sums the money that left the accounts of a user through
+// withdrawals and outgoing transfers since args.Since.
+func (TransactionRepository) GetOutflowTotal(ctx context.Context, db *gorm.DB, args *GetOutflowTotalArgs) (float64, error) {
+	var total float64
+	err := db.
+		WithContext(ctx).
+		Table("transactions").
+		Select("COALESCE(SUM(-amount), 0)").
+		Where("user_id = ?", args.UserID).
+		Where("amount < 0").
+		Where("type IN ?", []string{enums.Withdrawal.String(), enums.Transfer.String()}).
+		Where("created_at >= ?", args.Since).
+		Scan(&total).
+		Error
+
+	return total, err
+}
+
+// GetLedgerTotals sums the transactions of each account and returns the
+// balance recorded by its latest transaction, for reconciliation. Accounts
+// without transactions are left out.
+func (TransactionRepository) GetLedgerTotals(ctx context.Context, db *gorm.DB, args *GetLedgerTotalsArgs) (totals []*models.LedgerTotal, err error) {
+	db = db.
+		WithContext(ctx).
+		Table("transactions").
+		Select("account_id, SUM(amount) AS total, COUNT(*) AS transaction_count, (ARRAY_AGG(balance ORDER BY transaction_id DESC))[1] AS last_balance").
+		Group("account_id")
+
+	if args.AccountIDs != nil {
+		db.Where("account_id IN ?", args.AccountIDs)
+	}
+
+	err = db.Scan(&totals).Error
+
+	return
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
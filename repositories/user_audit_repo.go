@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+
+	"banking-service/models"
+
+	"gorm.io/gorm"
+)
+
+var _ UserAuditRepositoryI = &userAuditRepository{}
+
+type UserAuditRepositoryI interface {
+	Create(ctx context.Context, db *gorm.DB, audit *models.UserAudit) error
+	GetUserAudits(ctx context.Context, db *gorm.DB, args *GetUserAuditsArgs) (models.UserAudits, error)
+	RedactUserAudits(ctx context.Context, db *gorm.DB, args *RedactUserAuditsArgs) error
+}
+
+type userAuditRepository struct {
+}
+
+func NewUserAuditRepository() UserAuditRepositoryI {
+	return &userAuditRepository{}
+}
+
+func (u *userAuditRepository) Create(ctx context.Context, db *gorm.DB, audit *models.UserAudit) error {
+	return db.WithContext(ctx).Table("user_audits").Create(audit).Error
+}
+
+type GetUserAuditsArgs struct {
+	UserID string
+	Cursor string
+	Limit  int
+}
+
+func (u *userAuditRepository) GetUserAudits(ctx context.Context, db *gorm.DB, args *GetUserAuditsArgs) (audits models.UserAudits, _ error) {
+	db = db.WithContext(ctx).Table("user_audits")
+	if args.UserID != "" {
+		db.Where("user_id = ?", args.UserID)
+	}
+	if args.Cursor != "" {
+		db.Where("audit_id < ?", args.Cursor)
+	}
+	if args.Limit == 0 {
+		args.Limit = 100
+	}
+
+	db.Order("audit_id DESC")
+	db.Limit(args.Limit)
+
+	result := db.Find(&audits)
+
+	return audits, result.Error
+}
+
+type RedactUserAuditsArgs struct {
+	UserID string
+}
+
+// RedactUserAudits keeps the changed field names of every audit entry of the
+// user but replaces their old and new values.
+func (u *userAuditRepository) RedactUserAudits(ctx context.Context, db *gorm.DB, args *RedactUserAuditsArgs) error {
+	return db.
+		WithContext(ctx).
+		Table("user_audits").
+		Where("user_id = ?", args.UserID).
+		Update("changes", gorm.Expr(`COALESCE(
+			(SELECT jsonb_object_agg(key, '{"old":"[redacted]","new":"[redacted]"}'::jsonb) FROM jsonb_each(changes)),
+			'{}'::jsonb
+		)`)).
+		Error
+}
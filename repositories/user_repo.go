@@ -2,10 +2,13 @@ package repositories
 
 import (
 	"context"
+	"errors"
 
+	"banking-service/enums"
 	"banking-service/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var _ UserRepositoryI = &userRepository{}
@@ -15,13 +18,14 @@ type UserRepositoryI interface {
 	Create(ctx context.Context, db *gorm.DB, user *models.User) error
 	GetUser(ctx context.Context, db *gorm.DB, args *GetUserArgs) (*models.User, error)
 	GetUsers(ctx context.Context, db *gorm.DB, args *GetUsersArgs) (users []*models.User, _ error)
+	Update(ctx context.Context, db *gorm.DB, user *models.User) error
 }
 
 type userRepository struct {
 }
 
 func NewUserRepository() UserRepositoryI {
-	return &userRepository{}
+	return tracedUserRepository{next: &userRepository{}}
 }
 
 func (u *userRepository) Create(ctx context.Context, db *gorm.DB, user *models.User) error {
@@ -29,7 +33,10 @@ func (u *userRepository) Create(ctx context.Context, db *gorm.DB, user *models.U
 }
 
 type GetUserArgs struct {
-	UserID string
+	UserID    string
+	Email     string
+	Phone     string
+	ForUpdate bool
 }
 
 func (u *userRepository) GetUser(ctx context.Context, db *gorm.DB, args *GetUserArgs) (*models.User, error) {
@@ -37,6 +44,15 @@ func (u *userRepository) GetUser(ctx context.Context, db *gorm.DB, args *GetUser
 	if args.UserID != "" {
 		query.Where("user_id = ?", args.UserID)
 	}
+	if args.Email != "" {
+		query.Where("email = ?", args.Email)
+	}
+	if args.Phone != "" {
+		query.Where("phone = ?", args.Phone)
+	}
+	if args.ForUpdate {
+		query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
 
 	var user models.User
 	result := query.First(&user)
@@ -45,12 +61,16 @@ func (u *userRepository) GetUser(ctx context.Context, db *gorm.DB, args *GetUser
 }
 
 type GetUsersArgs struct {
-	Cursor string
-	Limit  int
+	UserIDs []string
+	Cursor  string
+	Limit   int
 }
 
 func (u *userRepository) GetUsers(ctx context.Context, db *gorm.DB, args *GetUsersArgs) (users []*models.User, _ error) {
 	db = db.WithContext(ctx).Table("users")
+	if args.UserIDs != nil {
+		db.Where("user_id IN ?", args.UserIDs)
+	}
 	if args.Cursor != "" {
 		db.Where("user_id < ?", args.Cursor)
 	}
@@ -65,3 +85,24 @@ func (u *userRepository) GetUsers(ctx context.Context, db *gorm.DB, args *GetUse
 
 	return users, result.Error
 }
+
+// Update writes every column of user, including zero values, so that
+// erasure can blank out personal data.
+func (u *userRepository) Update(ctx context.Context, db *gorm.DB, user *models.User) error {
+	db = db.
+		WithContext(ctx).
+		Table("users").
+		Where("user_id = ?", user.UserID).
+		Select("*").
+		Omit("user_id", "created_at").
+		Updates(user)
+	if err := db.Error; err != nil {
+		return err
+	}
+
+	if db.RowsAffected == 0 {
+		return errors.New(enums.NotRowsAffected)
+	}
+
+	return nil
+}
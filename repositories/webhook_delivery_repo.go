@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"banking-service/enums"
+	"banking-service/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ WebhookDeliveryRepositoryI = &webhookDeliveryRepository{}
+
+type WebhookDeliveryRepositoryI interface {
+	// Create ignores a delivery of an event already queued for the
+	// subscription.
+	Create(ctx context.Context, db *gorm.DB, delivery *models.WebhookDelivery) error
+	GetWebhookDelivery(ctx context.Context, db *gorm.DB, args *GetWebhookDeliveryArgs) (*models.WebhookDelivery, error)
+	GetWebhookDeliveries(ctx context.Context, db *gorm.DB, args *GetWebhookDeliveriesArgs) (models.WebhookDeliveries, error)
+	// GetDueWebhookDelivery locks the oldest pending delivery due at Now,
+	// skipping deliveries locked by other workers.
+	GetDueWebhookDelivery(ctx context.Context, db *gorm.DB, args *GetDueWebhookDeliveryArgs) (*models.WebhookDelivery, error)
+	Update(ctx context.Context, db *gorm.DB, delivery *models.WebhookDelivery) error
+	CreateAttempt(ctx context.Context, db *gorm.DB, attempt *models.WebhookDeliveryAttempt) error
+	GetWebhookDeliveryAttempts(ctx context.Context, db *gorm.DB, args *GetWebhookDeliveryAttemptsArgs) (models.WebhookDeliveryAttempts, error)
+}
+
+type webhookDeliveryRepository struct {
+}
+
+func NewWebhookDeliveryRepository() WebhookDeliveryRepositoryI {
+	return &webhookDeliveryRepository{}
+}
+
+func (r *webhookDeliveryRepository) Create(ctx context.Context, db *gorm.DB, delivery *models.WebhookDelivery) error {
+	return db.
+		WithContext(ctx).
+		Table("webhook_deliveries").
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(delivery).Error
+}
+
+type GetWebhookDeliveryArgs struct {
+	DeliveryID     string
+	SubscriptionID string
+	ForUpdate      bool
+}
+
+func (r *webhookDeliveryRepository) GetWebhookDelivery(ctx context.Context, db *gorm.DB, args *GetWebhookDeliveryArgs) (*models.WebhookDelivery, error) {
+	query := db.WithContext(ctx).Table("webhook_deliveries")
+	if args.DeliveryID != "" {
+		query.Where("delivery_id = ?", args.DeliveryID)
+	}
+	if args.SubscriptionID != "" {
+		query.Where("subscription_id = ?", args.SubscriptionID)
+	}
+	if args.ForUpdate {
+		query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var delivery models.WebhookDelivery
+	result := query.First(&delivery)
+
+	return &delivery, result.Error
+}
+
+type GetWebhookDeliveriesArgs struct {
+	SubscriptionID string
+	Status         string
+	Cursor         string
+	Limit          int
+}
+
+func (r *webhookDeliveryRepository) GetWebhookDeliveries(ctx context.Context, db *gorm.DB, args *GetWebhookDeliveriesArgs) (deliveries models.WebhookDeliveries, _ error) {
+	db = db.WithContext(ctx).Table("webhook_deliveries")
+	if args.SubscriptionID != "" {
+		db.Where("subscription_id = ?", args.SubscriptionID)
+	}
+	if args.Status != "" {
+		db.Where("status = ?", args.Status)
+	}
+	if args.Cursor != "" {
+		db.Where("delivery_id < ?", args.Cursor)
+	}
+	if args.Limit == 0 {
+		args.Limit = 100
+	}
+
+	db.Order("delivery_id DESC")
+	db.Limit(args.Limit)
+
+	result := db.Find(&deliveries)
+
+	return deliveries, result.Error
+}
+
+type GetDueWebhookDeliveryArgs struct {
+	Now time.Time
+}
+
+func (r *webhookDeliveryRepository) GetDueWebhookDelivery(ctx context.Context, db *gorm.DB, args *GetDueWebhookDeliveryArgs) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	result := db.
+		WithContext(ctx).
+		Table("webhook_deliveries").
+		Where("status = ?", enums.WebhookPending.String()).
+		Where("next_attempt_at <= ?", args.Now).
+		Order("next_attempt_at ASC").
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		First(&delivery)
+
+	return &delivery, result.Error
+}
+
+func (r *webhookDeliveryRepository) Update(ctx context.Context, db *gorm.DB, delivery *models.WebhookDelivery) error {
+	db = db.
+		WithContext(ctx).
+		Table("webhook_deliveries").
+		Where("delivery_id = ?", delivery.DeliveryID).
+		Select("*").
+		Omit("delivery_id", "subscription_id", "event_id", "created_at").
+		Updates(delivery)
+	if err := db.Error; err != nil {
+		return err
+	}
+
+	if db.RowsAffected == 0 {
+		return errors.New(enums.NotRowsAffected)
+	}
+
+	return nil
+}
+
+func (r *webhookDeliveryRepository) CreateAttempt(ctx context.Context, db *gorm.DB, attempt *models.WebhookDeliveryAttempt) error {
+	return db.WithContext(ctx).Table("webhook_delivery_attempts").Create(attempt).Error
+}
+
+type GetWebhookDeliveryAttemptsArgs struct {
+	DeliveryID string
+}
+
+func (r *webhookDeliveryRepository) GetWebhookDeliveryAttempts(ctx context.Context, db *gorm.DB, args *GetWebhookDeliveryAttemptsArgs) (attempts models.WebhookDeliveryAttempts, _ error) {
+	result := db.
+		WithContext(ctx).
+		Table("webhook_delivery_attempts").
+		Where("delivery_id = ?", args.DeliveryID).
+		Order("attempt_id ASC").
+		Find(&attempts)
+
+	return attempts, result.Error
+}
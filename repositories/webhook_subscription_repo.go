@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"banking-service/enums"
+	"banking-service/models"
+
+	"gorm.io/gorm"
+)
+
+var _ WebhookSubscriptionRepositoryI = &webhookSubscriptionRepository{}
+
+type WebhookSubscriptionRepositoryI interface {
+	Create(ctx context.Context, db *gorm.DB, subscription *models.WebhookSubscription) error
+	GetWebhookSubscription(ctx context.Context, db *gorm.DB, args *GetWebhookSubscriptionArgs) (*models.WebhookSubscription, error)
+	GetWebhookSubscriptions(ctx context.Context, db *gorm.DB, args *GetWebhookSubscriptionsArgs) (models.WebhookSubscriptions, error)
+	Update(ctx context.Context, db *gorm.DB, subscription *models.WebhookSubscription) error
+}
+
+type webhookSubscriptionRepository struct {
+}
+
+func NewWebhookSubscriptionRepository() WebhookSubscriptionRepositoryI {
+	return &webhookSubscriptionRepository{}
+}
+
+func (r *webhookSubscriptionRepository) Create(ctx context.Context, db *gorm.DB, subscription *models.WebhookSubscription) error {
+	return db.WithContext(ctx).Table("webhook_subscriptions").Create(subscription).Error
+}
+
+type GetWebhookSubscriptionArgs struct {
+	SubscriptionID string
+}
+
+func (r *webhookSubscriptionRepository) GetWebhookSubscription(ctx context.Context, db *gorm.DB, args *GetWebhookSubscriptionArgs) (*models.WebhookSubscription, error) {
+	query := db.WithContext(ctx).Table("webhook_subscriptions").Where("deleted_at IS NULL")
+	if args.SubscriptionID != "" {
+		query.Where("subscription_id = ?", args.SubscriptionID)
+	}
+
+	var subscription models.WebhookSubscription
+	result := query.First(&subscription)
+
+	return &subscription, result.Error
+}
+
+type GetWebhookSubscriptionsArgs struct {
+	UserID string
+	// ActiveOnly skips paused subscriptions.
+	ActiveOnly bool
+	EventType  string
+	Cursor     string
+	// Limit below zero returns every subscription.
+	Limit int
+}
+
+func (r *webhookSubscriptionRepository) GetWebhookSubscriptions(ctx context.Context, db *gorm.DB, args *GetWebhookSubscriptionsArgs) (subscriptions models.WebhookSubscriptions, _ error) {
+	db = db.WithContext(ctx).Table("webhook_subscriptions").Where("deleted_at IS NULL")
+	if args.UserID != "" {
+		db.Where("user_id = ?", args.UserID)
+	}
+	if args.ActiveOnly {
+		db.Where("active")
+	}
+	if args.EventType != "" {
+		filter, err := json.Marshal([]string{args.EventType})
+		if err != nil {
+			return nil, err
+		}
+		// an empty list subscribes to every event type
+		db.Where("(event_types = '[]'::jsonb OR event_types @> ?::jsonb)", string(filter))
+	}
+	if args.Cursor != "" {
+		db.Where("subscription_id < ?", args.Cursor)
+	}
+	if args.Limit == 0 {
+		args.Limit = 100
+	}
+
+	db.Order("subscription_id DESC")
+	if args.Limit > 0 {
+		db.Limit(args.Limit)
+	}
+
+	result := db.Find(&subscriptions)
+
+	return subscriptions, result.Error
+}
+
+func (r *webhookSubscriptionRepository) Update(ctx context.Context, db *gorm.DB, subscription *models.WebhookSubscription) error {
+	db = db.
+		WithContext(ctx).
+		Table("webhook_subscriptions").
+		Where("subscription_id = ?", subscription.SubscriptionID).
+		Select("*").
+		Omit("subscription_id", "created_by", "created_at").
+		Updates(subscription)
+	if err := db.Error; err != nil {
+		return err
+	}
+
+	if db.RowsAffected == 0 {
+		return errors.New(enums.NotRowsAffected)
+	}
+
+	return nil
+}
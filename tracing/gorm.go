@@ -0,0 +1,121 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+var (
+	_ gorm.Plugin           = GormPlugin{}
+	_ gorm.ConnPoolBeginner = &connPool{}
+	_ gorm.GetDBConnector   = &connPool{}
+	_ gorm.TxCommitter      = &tx{}
+)
+
+// GormPlugin starts a db.transaction span when a transaction begins and ends
+// it on commit or rollback, so time spent waiting on row locks shows up
+// between the repository spans of the transaction.
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	pool := &connPool{ConnPool: db.ConnPool}
+	db.ConnPool = pool
+	db.Statement.ConnPool = pool
+
+	return nil
+}
+
+// TransactionContext returns ctx with the span of the transaction db belongs
+// to when ctx still carries the span the transaction was begun under, so the
+// calls made inside gorm's Transaction callback nest under it.
+func TransactionContext(ctx context.Context, db *gorm.DB) context.Context {
+	t, ok := db.Statement.ConnPool.(*tx)
+	if !ok || !trace.SpanContextFromContext(ctx).Equal(t.parent) {
+		return ctx
+	}
+
+	return trace.ContextWithSpan(ctx, t.span)
+}
+
+// connPool wraps the connection pool to begin traced transactions.
+type connPool struct {
+	gorm.ConnPool
+}
+
+func (p *connPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	beginner, ok := p.ConnPool.(gorm.TxBeginner)
+	if !ok {
+		return nil, gorm.ErrInvalidTransaction
+	}
+
+	var attrs []attribute.KeyValue
+	if opts != nil {
+		attrs = append(attrs,
+			attribute.String("db.isolation_level", opts.Isolation.String()),
+			attribute.Bool("db.read_only", opts.ReadOnly),
+		)
+	}
+	spanCtx, span := Start(ctx, "db.transaction", attrs...)
+
+	sqlTx, err := beginner.BeginTx(ctx, opts)
+	if err != nil {
+		End(span, err)
+		return nil, err
+	}
+
+	return &tx{
+		Tx:     sqlTx,
+		parent: trace.SpanContextFromContext(ctx),
+		span:   trace.SpanFromContext(spanCtx),
+	}, nil
+}
+
+func (p *connPool) GetDBConn() (*sql.DB, error) {
+	if connector, ok := p.ConnPool.(gorm.GetDBConnector); ok {
+		return connector.GetDBConn()
+	}
+	if sqlDB, ok := p.ConnPool.(*sql.DB); ok {
+		return sqlDB, nil
+	}
+
+	return nil, gorm.ErrInvalidDB
+}
+
+type tx struct {
+	*sql.Tx
+	parent trace.SpanContext
+	span   trace.Span
+	once   sync.Once
+}
+
+func (t *tx) Commit() error {
+	err := t.Tx.Commit()
+	t.end("commit", err)
+
+	return err
+}
+
+// Rollback after a failed commit returns sql.ErrTxDone; the span already
+// ended with the commit error.
+func (t *tx) Rollback() error {
+	err := t.Tx.Rollback()
+	t.end("rollback", err)
+
+	return err
+}
+
+func (t *tx) end(outcome string, err error) {
+	t.once.Do(func() {
+		t.span.SetAttributes(attribute.String("db.transaction.outcome", outcome))
+		End(t.span, err)
+	})
+}
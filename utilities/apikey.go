@@ -0,0 +1,46 @@
+package utilities
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+const apiKeyPrefix = "bk"
+
+// GenerateAPIKey returns a new plaintext key of the form bk_<keyID>_<secret>
+// together with the hash of its secret. Only the hash is stored.
+func GenerateAPIKey(keyID string) (key string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	secret := base64.RawURLEncoding.EncodeToString(b)
+
+	return apiKeyPrefix + "_" + keyID + "_" + secret, HashAPIKeySecret(secret), nil
+}
+
+// ParseAPIKey splits a plaintext key into its key ID and secret.
+func ParseAPIKey(key string) (keyID string, secret string, ok bool) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", "", false
+	}
+
+	return parts[1], parts[2], true
+}
+
+// HashAPIKeySecret hashes a key secret. The secret has 256 bits of entropy so
+// a fast hash is enough.
+func HashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// CompareAPIKeyHash compares two hashes in constant time.
+func CompareAPIKeyHash(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
@@ -0,0 +1,118 @@
+package utilities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"banking-service/domains"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+var (
+	_ gormLogger.Interface = &gormZapLogger{}
+	_ gorm.ParamsFilter    = &gormZapLogger{}
+)
+
+// gormLogLevels are the accepted values of GormLoggerDeps.Level.
+var gormLogLevels = map[string]gormLogger.LogLevel{
+	"silent": gormLogger.Silent,
+	"error":  gormLogger.Error,
+	"warn":   gormLogger.Warn,
+	"info":   gormLogger.Info,
+}
+
+type GormLoggerDeps struct {
+	Logger *zap.Logger
+	// Level is silent, error, warn to add slow queries, or info to add every
+	// query.
+	Level string
+	// SlowThreshold is the duration above which a query is logged as slow.
+	SlowThreshold time.Duration
+}
+
+// gormZapLogger writes gorm logs through zap with the request ID of the
+// query context. Queries are logged with placeholders, never with their
+// values, which hold balances, emails and key hashes.
+type gormZapLogger struct {
+	logger        *zap.Logger
+	level         gormLogger.LogLevel
+	slowThreshold time.Duration
+}
+
+func NewGormLogger(deps *GormLoggerDeps) (gormLogger.Interface, error) {
+	level, ok := gormLogLevels[deps.Level]
+	if !ok {
+		return nil, fmt.Errorf("invalid gorm log level %q", deps.Level)
+	}
+
+	return &gormZapLogger{
+		logger:        deps.Logger,
+		level:         level,
+		slowThreshold: deps.SlowThreshold,
+	}, nil
+}
+
+func (l *gormZapLogger) LogMode(level gormLogger.LogLevel) gormLogger.Interface {
+	clone := *l
+	clone.level = level
+
+	return &clone
+}
+
+func (l *gormZapLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormLogger.Info {
+		l.logger.Info(fmt.Sprintf(msg, args...), l.requestField(ctx))
+	}
+}
+
+func (l *gormZapLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormLogger.Warn {
+		l.logger.Warn(fmt.Sprintf(msg, args...), l.requestField(ctx))
+	}
+}
+
+func (l *gormZapLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormLogger.Error {
+		l.logger.Error(fmt.Sprintf(msg, args...), l.requestField(ctx))
+	}
+}
+
+func (l *gormZapLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= gormLogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	fields := func() []zap.Field {
+		sql, rows := fc()
+		return []zap.Field{
+			l.requestField(ctx),
+			zap.String("sql", sql),
+			zap.Int64("rows", rows),
+			zap.Duration("elapsed", elapsed),
+		}
+	}
+
+	switch {
+	case err != nil && l.level >= gormLogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		l.logger.Error("query error", append(fields(), zap.Error(err))...)
+	case l.slowThreshold != 0 && elapsed > l.slowThreshold && l.level >= gormLogger.Warn:
+		l.logger.Warn("slow query", append(fields(), zap.Duration("threshold", l.slowThreshold))...)
+	case l.level >= gormLogger.Info:
+		l.logger.Info("query", fields()...)
+	}
+}
+
+// ParamsFilter drops the query values before gorm renders the SQL.
+func (l *gormZapLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+func (l *gormZapLogger) requestField(ctx context.Context) zap.Field {
+	return zap.String("request_id", domains.RequestIDFromContext(ctx))
+}
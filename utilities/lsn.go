@@ -0,0 +1,25 @@
+package utilities
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ParseLSN parses a PostgreSQL write-ahead log position such as 16/B374D848
+// into a comparable number.
+func ParseLSN(lsn string) (uint64, bool) {
+	hi, lo, found := strings.Cut(lsn, "/")
+	if !found {
+		return 0, false
+	}
+	high, err := strconv.ParseUint(hi, 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	low, err := strconv.ParseUint(lo, 16, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	return high<<32 | low, true
+}
@@ -0,0 +1,142 @@
+package utilities
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	WebhookIDHeader        = "X-Webhook-ID"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+
+	webhookSecretPrefix = "whsec_"
+)
+
+// GenerateWebhookSecret returns a random secret for signing deliveries.
+func GenerateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return webhookSecretPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// SignWebhookPayload returns the X-Webhook-Signature value for body sent at
+// timestamp (unix seconds): sha256=hex(HMAC-SHA256(secret, "<timestamp>.<body>")).
+// Receivers recompute it and reject stale timestamps to prevent replays.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ErrWebhookAddressNotAllowed is returned when a webhook URL resolves to an
+// address of the service's own network.
+var ErrWebhookAddressNotAllowed = errors.New("webhook address is not publicly routable")
+
+// nonPublicPrefixes are the ranges not caught by the netip predicates:
+// "this network", carrier-grade NAT, benchmarking and the reserved block.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// IsPublicAddress reports whether addr is routable on the internet, so a
+// webhook sent to it cannot reach the database, the metrics port, cloud
+// metadata or other internal services.
+func IsPublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || !addr.IsGlobalUnicast() || addr.IsPrivate() ||
+		addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsUnspecified() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CheckWebhookURL rejects webhook URLs that are not https or that name a
+// non-public address literally. Host names are checked again on every
+// connection by NewWebhookTransport, since DNS can change after this check.
+// allowInsecure accepts http and internal hosts, for local development.
+func CheckWebhookURL(rawURL string, allowInsecure bool) error {
+	if allowInsecure {
+		return nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" {
+		return errors.New("url must use https")
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrWebhookAddressNotAllowed
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !IsPublicAddress(addr) {
+		return ErrWebhookAddressNotAllowed
+	}
+
+	return nil
+}
+
+// NewWebhookTransport returns the transport of webhook deliveries. It checks
+// the address of every connection after DNS resolution, so a host name
+// rebound to an internal address is refused too. Proxies from the
+// environment are ignored, as they would connect on the service's behalf.
+func NewWebhookTransport(timeout time.Duration, allowInsecure bool) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+	}
+	if !allowInsecure {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrWebhookAddressNotAllowed, address)
+			}
+			if !IsPublicAddress(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrWebhookAddressNotAllowed, addrPort.Addr())
+			}
+			return nil
+		}
+	}
+
+	return &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   timeout,
+		ExpectContinueTimeout: time.Second,
+	}
+}
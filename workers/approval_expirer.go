@@ -0,0 +1,70 @@
+package workers
+
+import (
+	"context"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/repositories"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ApprovalExpirerDeps struct {
+	DB       *gorm.DB
+	Logger   *zap.Logger
+	Interval time.Duration
+}
+
+// ApprovalExpirer periodically expires pending approvals past their TTL so
+// stale requests can no longer be approved and drop out of the pending list.
+type ApprovalExpirer struct {
+	db                 *gorm.DB
+	logger             *zap.Logger
+	interval           time.Duration
+	health             *health
+	approvalRepository repositories.ApprovalRepositoryI
+}
+
+func NewApprovalExpirer(deps *ApprovalExpirerDeps) *ApprovalExpirer {
+	return &ApprovalExpirer{
+		db:                 deps.DB,
+		logger:             deps.Logger,
+		interval:           deps.Interval,
+		health:             newHealth("approval_expirer", deps.Interval),
+		approvalRepository: repositories.NewApprovalRepository(),
+	}
+}
+
+// Run blocks until ctx is done.
+func (w *ApprovalExpirer) Run(ctx context.Context) {
+	w.health.start()
+	defer w.health.stop()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := w.approvalRepository.ExpireApprovals(ctx, w.db, &repositories.ExpireApprovalsArgs{
+				Now: time.Now(),
+			})
+			w.health.ran(err)
+			if err != nil {
+				w.logger.Sugar().Errorf("expire approvals error: %s", err.Error())
+				continue
+			}
+			if expired != 0 {
+				w.logger.Sugar().Infof("expired %d approvals", expired)
+			}
+		}
+	}
+}
+
+func (w *ApprovalExpirer) Health() *domains.WorkerStatus {
+	return w.health.status()
+}
@@ -0,0 +1,101 @@
+package workers
+
+import (
+	"context"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/repositories"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// changeSequencerLockID is the advisory lock key that keeps a single
+// sequencer assigning change feed positions across instances.
+const changeSequencerLockID = 7_310_002
+
+type ChangeSequencerDeps struct {
+	DB        *gorm.DB
+	Logger    *zap.Logger
+	Interval  time.Duration
+	BatchSize int
+}
+
+// ChangeSequencer assigns change feed positions. Changes are recorded by
+// database triggers with no position; only committed ones are visible here,
+// so a write that commits late gets a later position instead of being
+// skipped by consumers that already moved past it.
+type ChangeSequencer struct {
+	db               *gorm.DB
+	logger           *zap.Logger
+	interval         time.Duration
+	batchSize        int
+	health           *health
+	changeRepository repositories.ChangeRepositoryI
+}
+
+func NewChangeSequencer(deps *ChangeSequencerDeps) *ChangeSequencer {
+	return &ChangeSequencer{
+		db:               deps.DB,
+		logger:           deps.Logger,
+		interval:         deps.Interval,
+		batchSize:        deps.BatchSize,
+		health:           newHealth("change_sequencer", deps.Interval),
+		changeRepository: repositories.NewChangeRepository(),
+	}
+}
+
+// Run blocks until ctx is done.
+func (w *ChangeSequencer) Run(ctx context.Context) {
+	w.health.start()
+	defer w.health.stop()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				sequenced, err := w.sequence(ctx)
+				w.health.ran(err)
+				if err != nil {
+					w.logger.Sugar().Errorf("sequence changes error: %s", err.Error())
+					break
+				}
+				if sequenced < int64(w.batchSize) {
+					break
+				}
+			}
+		}
+	}
+}
+
+func (w *ChangeSequencer) Health() *domains.WorkerStatus {
+	return w.health.status()
+}
+
+func (w *ChangeSequencer) sequence(ctx context.Context) (int64, error) {
+	var sequenced int64
+	err := w.db.Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.WithContext(ctx).Raw("SELECT pg_try_advisory_xact_lock(?)", changeSequencerLockID).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		var err error
+		sequenced, err = w.changeRepository.SequenceChanges(ctx, tx, &repositories.SequenceChangesArgs{
+			Limit: w.batchSize,
+		})
+
+		return err
+	})
+
+	return sequenced, err
+}
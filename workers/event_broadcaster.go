@@ -0,0 +1,177 @@
+package workers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/repositories"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// EventListener receives events from the event broadcaster. OnEvent runs on
+// the broadcaster goroutine and should return quickly.
+type EventListener interface {
+	OnEvent(event *domains.Event)
+}
+
+type EventBroadcasterDeps struct {
+	DB           *gorm.DB
+	Logger       *zap.Logger
+	PollInterval time.Duration
+	// GapTimeout is how long a missing sequence is waited for before it is
+	// considered a rolled back transaction.
+	GapTimeout time.Duration
+	BatchSize  int
+}
+
+// EventBroadcaster tails the outbox on every instance and hands new events to
+// in-process listeners such as the WebSocket hub. Unlike the relay it does
+// not mark anything and is best effort: events that commit more than
+// GapTimeout after a later sequence are skipped.
+type EventBroadcaster struct {
+	db                    *gorm.DB
+	logger                *zap.Logger
+	pollInterval          time.Duration
+	gapTimeout            time.Duration
+	batchSize             int
+	health                *health
+	outboxEventRepository repositories.OutboxEventRepositoryI
+
+	mu        sync.RWMutex
+	listeners []EventListener
+
+	// cursor is the sequence up to which every event was delivered or given
+	// up on; seen holds the delivered sequences above it and gaps the
+	// missing ones with the time they were first noticed.
+	cursor  int64
+	maxSeen int64
+	seen    map[int64]struct{}
+	gaps    map[int64]time.Time
+}
+
+func NewEventBroadcaster(deps *EventBroadcasterDeps) *EventBroadcaster {
+	return &EventBroadcaster{
+		db:                    deps.DB,
+		logger:                deps.Logger,
+		pollInterval:          deps.PollInterval,
+		gapTimeout:            deps.GapTimeout,
+		batchSize:             deps.BatchSize,
+		health:                newHealth("event_broadcaster", deps.PollInterval),
+		outboxEventRepository: repositories.NewOutboxEventRepository(),
+		seen:                  make(map[int64]struct{}),
+		gaps:                  make(map[int64]time.Time),
+	}
+}
+
+func (b *EventBroadcaster) AddListener(listener EventListener) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.listeners = append(b.listeners, listener)
+}
+
+// Run starts from the latest event and blocks until ctx is done.
+func (b *EventBroadcaster) Run(ctx context.Context) {
+	b.health.start()
+	defer b.health.stop()
+
+	for {
+		err := b.db.WithContext(ctx).Table("outbox_events").Select("COALESCE(MAX(sequence), 0)").Scan(&b.cursor).Error
+		if err == nil {
+			b.maxSeen = b.cursor
+			break
+		}
+		b.health.ran(err)
+		b.logger.Sugar().Errorf("load latest outbox sequence error: %s", err.Error())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(b.pollInterval):
+		}
+	}
+
+	ticker := time.NewTicker(b.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := b.poll(ctx)
+			b.health.ran(err)
+			if err != nil {
+				b.logger.Sugar().Errorf("poll outbox events error: %s", err.Error())
+			}
+		}
+	}
+}
+
+func (b *EventBroadcaster) Health() *domains.WorkerStatus {
+	return b.health.status()
+}
+
+func (b *EventBroadcaster) poll(ctx context.Context) error {
+	events, err := b.outboxEventRepository.GetOutboxEvents(ctx, b.db, &repositories.GetOutboxEventsArgs{
+		AfterSequence: b.cursor,
+		Limit:         b.batchSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	b.mu.RLock()
+	listeners := b.listeners
+	b.mu.RUnlock()
+
+	for _, event := range events {
+		if _, ok := b.seen[event.Sequence]; ok {
+			continue
+		}
+		b.seen[event.Sequence] = struct{}{}
+		delete(b.gaps, event.Sequence)
+		if event.Sequence > b.maxSeen {
+			b.maxSeen = event.Sequence
+		}
+
+		domainEvent := ToEvent(event)
+		for _, listener := range listeners {
+			listener.OnEvent(domainEvent)
+		}
+	}
+
+	b.advance(time.Now())
+	return nil
+}
+
+// advance moves the cursor over delivered sequences and expired gaps.
+func (b *EventBroadcaster) advance(now time.Time) {
+	for sequence := b.cursor + 1; sequence < b.maxSeen; sequence++ {
+		if _, ok := b.seen[sequence]; ok {
+			continue
+		}
+		if _, ok := b.gaps[sequence]; !ok {
+			b.gaps[sequence] = now
+		}
+	}
+
+	for b.cursor < b.maxSeen {
+		next := b.cursor + 1
+		if _, ok := b.seen[next]; ok {
+			delete(b.seen, next)
+			b.cursor = next
+			continue
+		}
+
+		if now.Sub(b.gaps[next]) < b.gapTimeout {
+			return
+		}
+		delete(b.gaps, next)
+		b.cursor = next
+	}
+}
@@ -0,0 +1,87 @@
+package workers
+
+import (
+	"sync"
+	"time"
+
+	"banking-service/domains"
+)
+
+// staleRuns is how many intervals a worker may go without completing a run
+// before it is reported stale.
+const staleRuns = 10
+
+// minStaleAfter keeps workers with short intervals from being reported stale
+// by a single slow run.
+const minStaleAfter = 30 * time.Second
+
+// health tracks the runs of a worker for the readiness endpoint.
+type health struct {
+	name     string
+	interval time.Duration
+
+	mu        sync.Mutex
+	running   bool
+	startedAt time.Time
+	lastRunAt time.Time
+	lastError string
+}
+
+func newHealth(name string, interval time.Duration) *health {
+	return &health{
+		name:     name,
+		interval: interval,
+	}
+}
+
+func (h *health) start() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.running = true
+	h.startedAt = time.Now()
+}
+
+func (h *health) stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.running = false
+}
+
+// ran records a completed run and its error, if any.
+func (h *health) ran(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastRunAt = time.Now()
+	h.lastError = ""
+	if err != nil {
+		h.lastError = err.Error()
+	}
+}
+
+func (h *health) status() *domains.WorkerStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	status := &domains.WorkerStatus{
+		Name:      h.name,
+		Running:   h.running,
+		LastError: h.lastError,
+	}
+	since := h.startedAt
+	if !h.lastRunAt.IsZero() {
+		lastRunAt := h.lastRunAt
+		status.LastRunAt = &lastRunAt
+		since = lastRunAt
+	}
+
+	staleAfter := staleRuns * h.interval
+	if staleAfter < minStaleAfter {
+		staleAfter = minStaleAfter
+	}
+	status.Stale = h.running && time.Since(since) > staleAfter
+
+	return status
+}
@@ -0,0 +1,214 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/models"
+	"banking-service/repositories"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// outboxRelayLockID is the advisory lock key that keeps a single relay
+// delivering at a time across instances, which preserves event order.
+const outboxRelayLockID = 7_310_001
+
+// OutboxSink receives domain events from the outbox relay. Publish must be
+// idempotent per event ID: delivery is at-least-once, so an event is
+// published again after a failure or a crash.
+type OutboxSink interface {
+	Name() string
+	Publish(ctx context.Context, event *domains.Event) error
+}
+
+type OutboxRelayDeps struct {
+	DB        *gorm.DB
+	Logger    *zap.Logger
+	Sinks     []OutboxSink
+	Interval  time.Duration
+	BatchSize int
+	// MaxAttempts is the number of failed deliveries before an event is
+	// dead-lettered.
+	MaxAttempts int
+	// PublishTimeout bounds each sink call, which runs while the relay holds
+	// its lock and transaction.
+	PublishTimeout time.Duration
+}
+
+// OutboxRelay delivers outbox events to every sink in sequence order. When a
+// sink fails, the batch stops at the failing event and is retried on the next
+// tick so later events never overtake it. After MaxAttempts failures the
+// event is dead-lettered and the events behind it go ahead.
+type OutboxRelay struct {
+	db                    *gorm.DB
+	logger                *zap.Logger
+	sinks                 []OutboxSink
+	interval              time.Duration
+	batchSize             int
+	maxAttempts           int
+	publishTimeout        time.Duration
+	health                *health
+	outboxEventRepository repositories.OutboxEventRepositoryI
+}
+
+func NewOutboxRelay(deps *OutboxRelayDeps) *OutboxRelay {
+	return &OutboxRelay{
+		db:                    deps.DB,
+		logger:                deps.Logger,
+		sinks:                 deps.Sinks,
+		interval:              deps.Interval,
+		batchSize:             deps.BatchSize,
+		maxAttempts:           deps.MaxAttempts,
+		publishTimeout:        deps.PublishTimeout,
+		health:                newHealth("outbox_relay", deps.Interval),
+		outboxEventRepository: repositories.NewOutboxEventRepository(),
+	}
+}
+
+// Run blocks until ctx is done.
+func (w *OutboxRelay) Run(ctx context.Context) {
+	w.health.start()
+	defer w.health.stop()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// drain the backlog before waiting for the next tick
+			for {
+				published, err := w.relay(ctx)
+				w.health.ran(err)
+				if err != nil {
+					w.logger.Sugar().Errorf("relay outbox events error: %s", err.Error())
+					break
+				}
+				if published < w.batchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+func (w *OutboxRelay) Health() *domains.WorkerStatus {
+	return w.health.status()
+}
+
+// relay delivers one batch and returns how many events were published.
+func (w *OutboxRelay) relay(ctx context.Context) (int, error) {
+	var published int
+	err := w.db.Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.WithContext(ctx).Raw("SELECT pg_try_advisory_xact_lock(?)", outboxRelayLockID).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		events, err := w.outboxEventRepository.GetOutboxEvents(ctx, tx, &repositories.GetOutboxEventsArgs{
+			Unpublished: true,
+			Limit:       w.batchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if err := w.publish(ctx, event); err != nil {
+				failure := &repositories.RecordFailureArgs{
+					Sequence: event.Sequence,
+					Error:    err.Error(),
+				}
+				if event.Attempts+1 < w.maxAttempts {
+					w.logger.Sugar().Warnf("publish outbox event %d error: %s", event.Sequence, err.Error())
+					return w.outboxEventRepository.RecordFailure(ctx, tx, failure)
+				}
+
+				w.logger.Sugar().Errorf("dead-letter outbox event %d after %d attempts: %s", event.Sequence, event.Attempts+1, err.Error())
+				now := time.Now()
+				failure.DeadLetteredAt = &now
+				if err := w.outboxEventRepository.RecordFailure(ctx, tx, failure); err != nil {
+					return err
+				}
+				// the events behind it no longer wait
+				continue
+			}
+
+			if err := w.outboxEventRepository.MarkPublished(ctx, tx, &repositories.MarkPublishedArgs{
+				Sequence:    event.Sequence,
+				PublishedAt: time.Now(),
+			}); err != nil {
+				return err
+			}
+			published++
+		}
+
+		return nil
+	})
+
+	return published, err
+}
+
+func (w *OutboxRelay) publish(ctx context.Context, event *models.OutboxEvent) error {
+	domainEvent := ToEvent(event)
+	for _, sink := range w.sinks {
+		sinkCtx, cancel := context.WithTimeout(ctx, w.publishTimeout)
+		err := sink.Publish(sinkCtx, domainEvent)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("sink %s: %w", sink.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// ToEvent converts a stored outbox event to the shape delivered to sinks.
+func ToEvent(event *models.OutboxEvent) *domains.Event {
+	return &domains.Event{
+		EventID:     event.EventID,
+		Sequence:    event.Sequence,
+		Type:        event.EventType,
+		AggregateID: event.AggregateID,
+		Payload:     json.RawMessage(event.Payload),
+		CreatedAt:   event.CreatedAt,
+	}
+}
+
+// LogSink writes every event to the logger. It is the default sink and is
+// useful to follow the event stream locally.
+type LogSink struct {
+	logger *zap.Logger
+}
+
+func NewLogSink(logger *zap.Logger) *LogSink {
+	return &LogSink{
+		logger: logger,
+	}
+}
+
+func (s *LogSink) Name() string {
+	return "log"
+}
+
+func (s *LogSink) Publish(_ context.Context, event *domains.Event) error {
+	s.logger.Info("domain event",
+		zap.String("event_id", event.EventID),
+		zap.Int64("sequence", event.Sequence),
+		zap.String("type", event.Type),
+		zap.String("aggregate_id", event.AggregateID),
+		zap.ByteString("payload", event.Payload),
+	)
+
+	return nil
+}
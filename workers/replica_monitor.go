@@ -0,0 +1,144 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/metrics"
+	"banking-service/utilities"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// replicaPositionQuery returns the replayed log position of the replica,
+// whether its WAL receiver is streaming from the primary and how far behind
+// the primary its replay is. A streaming replica that has replayed everything
+// it received is not lagging however old its last transaction; a
+// disconnected one has replayed everything it received too, so it is never
+// fresh. The status of pg_stat_wal_receiver is only visible with
+// pg_read_all_stats; without it the replica counts as disconnected.
+const replicaPositionQuery = `SELECT
+	(CASE WHEN recovery THEN pg_last_wal_replay_lsn() ELSE pg_current_wal_lsn() END)::text AS lsn,
+	streaming,
+	CASE WHEN NOT recovery OR (streaming AND pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn()) THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END AS lag_seconds
+FROM (SELECT
+	pg_is_in_recovery() AS recovery,
+	NOT pg_is_in_recovery() OR COALESCE((SELECT status = 'streaming' FROM pg_stat_wal_receiver), false) AS streaming
+) AS replica`
+
+type ReplicaMonitorDeps struct {
+	DB       *gorm.DB
+	Logger   *zap.Logger
+	Interval time.Duration
+	MaxLag   time.Duration
+}
+
+// ReplicaMonitor periodically checks how far the read replica is behind the
+// primary so reads are only routed to it while it is fresh enough.
+type ReplicaMonitor struct {
+	db       *gorm.DB
+	logger   *zap.Logger
+	interval time.Duration
+	maxLag   time.Duration
+	health   *health
+
+	mu        sync.RWMutex
+	checkedAt time.Time
+	lsn       uint64
+	streaming bool
+	lag       time.Duration
+}
+
+func NewReplicaMonitor(deps *ReplicaMonitorDeps) *ReplicaMonitor {
+	return &ReplicaMonitor{
+		db:       deps.DB,
+		logger:   deps.Logger,
+		interval: deps.Interval,
+		maxLag:   deps.MaxLag,
+		health:   newHealth("replica_monitor", deps.Interval),
+	}
+}
+
+// Run blocks until ctx is done.
+func (w *ReplicaMonitor) Run(ctx context.Context) {
+	w.health.start()
+	defer w.health.stop()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		err := w.check(ctx)
+		w.health.ran(err)
+		if err != nil {
+			w.logger.Sugar().Warnf("check replica error: %s", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *ReplicaMonitor) check(ctx context.Context) error {
+	var position struct {
+		LSN        string
+		Streaming  bool
+		LagSeconds float64
+	}
+	if err := w.db.WithContext(ctx).Raw(replicaPositionQuery).Scan(&position).Error; err != nil {
+		return err
+	}
+	lsn, ok := utilities.ParseLSN(position.LSN)
+	if !ok {
+		return errors.New("replica returned invalid log position " + position.LSN)
+	}
+	lag := time.Duration(position.LagSeconds * float64(time.Second))
+	metrics.ReplicaLag.Set(lag.Seconds())
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.checkedAt = time.Now()
+	w.lsn = lsn
+	w.streaming = position.Streaming
+	w.lag = lag
+
+	return nil
+}
+
+// Fresh reports whether the last check succeeded recently and found the
+// replica streaming from the primary within the allowed lag.
+func (w *ReplicaMonitor) Fresh() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.fresh()
+}
+
+// CaughtUp reports whether the replica is fresh and has replayed the primary
+// up to lsn, so a client reading after its own write sees it.
+func (w *ReplicaMonitor) CaughtUp(lsn uint64) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.fresh() && w.lsn >= lsn
+}
+
+func (w *ReplicaMonitor) fresh() bool {
+	// a failing check leaves the last result in place, so it expires once
+	// the replica could have fallen behind by maxLag since
+	return !w.checkedAt.IsZero() && w.streaming && time.Since(w.checkedAt)+w.lag <= w.maxLag
+}
+
+func (w *ReplicaMonitor) Health() *domains.WorkerStatus {
+	return w.health.status()
+}
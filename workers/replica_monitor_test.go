@@ -0,0 +1,62 @@
+package workers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+func TestReplicaMonitorFresh(t *testing.T) {
+	tests := []struct {
+		name       string
+		streaming  bool
+		lagSeconds float64
+		fresh      bool
+	}{
+		{name: "streaming and caught up", streaming: true, lagSeconds: 0, fresh: true},
+		{name: "streaming within the lag", streaming: true, lagSeconds: 2, fresh: true},
+		{name: "streaming behind the lag", streaming: true, lagSeconds: 30, fresh: false},
+		{name: "receiver disconnected", streaming: false, lagSeconds: 0, fresh: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sqlDB.Close()
+			db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+				Logger: gormLogger.Default.LogMode(gormLogger.Silent),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mock.ExpectQuery(`pg_stat_wal_receiver`).
+				WillReturnRows(sqlmock.NewRows([]string{"lsn", "streaming", "lag_seconds"}).AddRow("0/3000060", tt.streaming, tt.lagSeconds))
+
+			w := NewReplicaMonitor(&ReplicaMonitorDeps{
+				DB:       db,
+				Logger:   zap.NewNop(),
+				Interval: time.Second,
+				MaxLag:   5 * time.Second,
+			})
+			if err := w.check(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := w.Fresh(); got != tt.fresh {
+				t.Fatalf("Fresh() = %v, want %v", got, tt.fresh)
+			}
+			if got := w.CaughtUp(0x3000060); got != tt.fresh {
+				t.Fatalf("CaughtUp() = %v, want %v", got, tt.fresh)
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/models"
+	"banking-service/repositories"
+	"banking-service/utilities"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxLoggedResponseBody bounds the receiver response kept in the delivery log.
+	maxLoggedResponseBody = 1024
+	// webhookLeaseMargin is added to the request timeout to lease a claimed
+	// delivery, covering the time to record the result.
+	webhookLeaseMargin = 30 * time.Second
+)
+
+type WebhookDispatcherDeps struct {
+	DB           *gorm.DB
+	IDGenerator  utilities.SnowflakeIDGenerator
+	Logger       *zap.Logger
+	Workers      int
+	PollInterval time.Duration
+	Timeout      time.Duration
+	// MaxAttempts is the number of attempts before a delivery is moved to
+	// the dead-letter state.
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// AllowInsecureURLs delivers to http and internal addresses, for local
+	// development only.
+	AllowInsecureURLs bool
+}
+
+// WebhookDispatcher sends pending webhook deliveries with a pool of workers.
+// Failed deliveries are retried with exponential backoff and jitter.
+type WebhookDispatcher struct {
+	db                            *gorm.DB
+	idGenerator                   utilities.SnowflakeIDGenerator
+	logger                        *zap.Logger
+	client                        *http.Client
+	workers                       int
+	pollInterval                  time.Duration
+	maxAttempts                   int
+	baseBackoff                   time.Duration
+	maxBackoff                    time.Duration
+	lease                         time.Duration
+	allowInsecureURLs             bool
+	health                        *health
+	webhookSubscriptionRepository repositories.WebhookSubscriptionRepositoryI
+	webhookDeliveryRepository     repositories.WebhookDeliveryRepositoryI
+}
+
+func NewWebhookDispatcher(deps *WebhookDispatcherDeps) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		db:          deps.DB,
+		idGenerator: deps.IDGenerator,
+		logger:      deps.Logger,
+		client: &http.Client{
+			Timeout:   deps.Timeout,
+			Transport: utilities.NewWebhookTransport(deps.Timeout, deps.AllowInsecureURLs),
+			// a redirect could point the signed payload anywhere
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		workers:                       deps.Workers,
+		pollInterval:                  deps.PollInterval,
+		maxAttempts:                   deps.MaxAttempts,
+		baseBackoff:                   deps.BaseBackoff,
+		maxBackoff:                    deps.MaxBackoff,
+		lease:                         deps.Timeout + webhookLeaseMargin,
+		allowInsecureURLs:             deps.AllowInsecureURLs,
+		health:                        newHealth("webhook_dispatcher", deps.PollInterval),
+		webhookSubscriptionRepository: repositories.NewWebhookSubscriptionRepository(),
+		webhookDeliveryRepository:     repositories.NewWebhookDeliveryRepository(),
+	}
+}
+
+// Run blocks until ctx is done and every worker has stopped.
+func (w *WebhookDispatcher) Run(ctx context.Context) {
+	w.health.start()
+	defer w.health.stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (w *WebhookDispatcher) work(ctx context.Context) {
+	for {
+		dispatched, err := w.dispatch(ctx)
+		w.health.ran(err)
+		if err != nil {
+			w.logger.Sugar().Errorf("dispatch webhook delivery error: %s", err.Error())
+		}
+		if dispatched && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.pollInterval):
+		}
+	}
+}
+
+func (w *WebhookDispatcher) Health() *domains.WorkerStatus {
+	return w.health.status()
+}
+
+// dispatch sends one due delivery and reports whether there was one. The
+// delivery is claimed in a first transaction that pushes next_attempt_at past
+// the send, so no other worker picks it up and no row lock is held during the
+// HTTP call; the result is recorded in a second transaction.
+func (w *WebhookDispatcher) dispatch(ctx context.Context) (bool, error) {
+	delivery, subscription, err := w.claim(ctx)
+	if err != nil || delivery == nil {
+		return false, err
+	}
+	if subscription == nil {
+		return true, nil
+	}
+
+	attempt := w.send(ctx, subscription, delivery)
+
+	return true, w.db.Transaction(func(tx *gorm.DB) error {
+		current, err := w.webhookDeliveryRepository.GetWebhookDelivery(ctx, tx, &repositories.GetWebhookDeliveryArgs{
+			DeliveryID: delivery.DeliveryID,
+			ForUpdate:  true,
+		})
+		if err != nil {
+			return err
+		}
+		if !holdsLease(current, delivery) {
+			// the lease ran out and the delivery was claimed again or
+			// redelivered
+			w.logger.Sugar().Warnf("webhook delivery %s attempt %d finished after its lease", delivery.DeliveryID, delivery.Attempts)
+			return nil
+		}
+
+		if err := w.webhookDeliveryRepository.CreateAttempt(ctx, tx, attempt); err != nil {
+			return err
+		}
+		w.applyAttempt(current, attempt, time.Now())
+
+		return w.webhookDeliveryRepository.Update(ctx, tx, current)
+	})
+}
+
+// claim takes the oldest due delivery, counts the attempt and leases it to
+// this worker until the send is over. A nil subscription means the delivery
+// was dead-lettered because its subscription is gone or paused.
+func (w *WebhookDispatcher) claim(ctx context.Context) (*models.WebhookDelivery, *models.WebhookSubscription, error) {
+	var (
+		delivery     *models.WebhookDelivery
+		subscription *models.WebhookSubscription
+	)
+	err := w.db.Transaction(func(tx *gorm.DB) error {
+		due, err := w.webhookDeliveryRepository.GetDueWebhookDelivery(ctx, tx, &repositories.GetDueWebhookDeliveryArgs{
+			Now: time.Now(),
+		})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		found, err := w.webhookSubscriptionRepository.GetWebhookSubscription(ctx, tx, &repositories.GetWebhookSubscriptionArgs{
+			SubscriptionID: due.SubscriptionID,
+		})
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		now := time.Now()
+		if err != nil || !found.Active {
+			due.Status = enums.WebhookDeadLetter.String()
+			due.LastError = "subscription deleted or paused"
+		} else {
+			// a crash during the send still counts as an attempt
+			due.Attempts++
+			// the end of the lease identifies this claim; it is stored with
+			// microsecond precision
+			due.NextAttemptAt = now.Add(w.lease).Truncate(time.Microsecond)
+			subscription = found
+		}
+		due.UpdatedAt = now
+		if err := w.webhookDeliveryRepository.Update(ctx, tx, due); err != nil {
+			return err
+		}
+		delivery = due
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return delivery, subscription, nil
+}
+
+// holdsLease reports whether current, read again after the send, is still
+// under the lease of claimed. The attempt count alone does not tell, since a
+// redelivery restarts it.
+func holdsLease(current, claimed *models.WebhookDelivery) bool {
+	return current.Status == enums.WebhookPending.String() &&
+		current.Attempts == claimed.Attempts &&
+		current.NextAttemptAt.Equal(claimed.NextAttemptAt)
+}
+
+// applyAttempt records the outcome of attempt on delivery: succeeded on a 2xx,
+// dead-lettered once MaxAttempts is reached, otherwise retried after backoff.
+func (w *WebhookDispatcher) applyAttempt(delivery *models.WebhookDelivery, attempt *models.WebhookDeliveryAttempt, now time.Time) {
+	switch {
+	case attempt.Error == "":
+		delivery.Status = enums.WebhookSucceeded.String()
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= w.maxAttempts:
+		delivery.Status = enums.WebhookDeadLetter.String()
+		delivery.LastError = attempt.Error
+	default:
+		delivery.LastError = attempt.Error
+		delivery.NextAttemptAt = now.Add(w.backoff(delivery.Attempts))
+	}
+	delivery.UpdatedAt = now
+}
+
+// send posts the delivery and returns the attempt log entry; Error is empty
+// on a 2xx response.
+func (w *WebhookDispatcher) send(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) *models.WebhookDeliveryAttempt {
+	attempt := &models.WebhookDeliveryAttempt{
+		AttemptID:  w.idGenerator.Next().String(),
+		DeliveryID: delivery.DeliveryID,
+		Attempt:    delivery.Attempts,
+		CreatedAt:  time.Now(),
+	}
+
+	// subscriptions created before the URL rules were tightened
+	if err := utilities.CheckWebhookURL(subscription.URL, w.allowInsecureURLs); err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(utilities.WebhookIDHeader, delivery.DeliveryID)
+	req.Header.Set(utilities.WebhookEventHeader, delivery.EventType)
+	req.Header.Set(utilities.WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(utilities.WebhookSignatureHeader, utilities.SignWebhookPayload(subscription.Secret, timestamp, body))
+
+	start := time.Now()
+	resp, err := w.client.Do(req)
+	attempt.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxLoggedResponseBody))
+	attempt.StatusCode = resp.StatusCode
+	// the log is a TEXT column, which rejects NUL and invalid UTF-8
+	attempt.ResponseBody = strings.ToValidUTF8(strings.ReplaceAll(string(respBody), "\x00", ""), "")
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+
+	return attempt
+}
+
+// backoff returns the delay before the next attempt: BaseBackoff doubled per
+// attempt, capped at MaxBackoff, with the upper half jittered.
+func (w *WebhookDispatcher) backoff(attempts int) time.Duration {
+	delay := w.maxBackoff
+	if shift := attempts - 1; shift < 32 {
+		if d := w.baseBackoff << uint(shift); d > 0 && d < w.maxBackoff {
+			delay = d
+		}
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
@@ -0,0 +1,258 @@
+package workers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"banking-service/enums"
+	"banking-service/models"
+	"banking-service/utilities"
+
+	"go.uber.org/zap"
+)
+
+func newTestDispatcher(t *testing.T, maxAttempts int) *WebhookDispatcher {
+	t.Helper()
+
+	idGenerator, err := utilities.NewSnowflakeIDGenerator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewWebhookDispatcher(&WebhookDispatcherDeps{
+		IDGenerator:  idGenerator,
+		Logger:       zap.NewNop(),
+		Workers:      1,
+		PollInterval: time.Second,
+		Timeout:      5 * time.Second,
+		MaxAttempts:  maxAttempts,
+		BaseBackoff:  time.Second,
+		MaxBackoff:   time.Minute,
+		// httptest listens on loopback over http
+		AllowInsecureURLs: true,
+	})
+}
+
+func newTestDelivery() *models.WebhookDelivery {
+	return &models.WebhookDelivery{
+		DeliveryID:     "1719286237483253760",
+		SubscriptionID: "1719286237483253761",
+		EventID:        "evt_1",
+		EventType:      "FundsDeposited",
+		Payload:        `{"amount":100}`,
+		Status:         enums.WebhookPending.String(),
+	}
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	body := []byte(`{"amount":100}`)
+
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte("1700000000." + string(body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := utilities.SignWebhookPayload("whsec_test", 1700000000, body); got != want {
+		t.Fatalf("SignWebhookPayload() = %q, want %q", got, want)
+	}
+	if got := utilities.SignWebhookPayload("whsec_other", 1700000000, body); got == want {
+		t.Fatal("SignWebhookPayload() does not depend on the secret")
+	}
+	if got := utilities.SignWebhookPayload("whsec_test", 1700000001, body); got == want {
+		t.Fatal("SignWebhookPayload() does not depend on the timestamp")
+	}
+}
+
+func TestWebhookDispatcherBackoff(t *testing.T) {
+	w := newTestDispatcher(t, 10)
+
+	tests := []struct {
+		attempts int
+		delay    time.Duration
+	}{
+		{attempts: 1, delay: time.Second},
+		{attempts: 2, delay: 2 * time.Second},
+		{attempts: 4, delay: 8 * time.Second},
+		{attempts: 7, delay: time.Minute},
+		{attempts: 40, delay: time.Minute},
+		{attempts: 100, delay: time.Minute},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			got := w.backoff(tt.attempts)
+			if got < tt.delay/2 || got > tt.delay {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", tt.attempts, got, tt.delay/2, tt.delay)
+			}
+		}
+	}
+}
+
+func TestWebhookDispatcherSend(t *testing.T) {
+	const secret = "whsec_test"
+
+	var (
+		mu       sync.Mutex
+		received *http.Request
+		body     []byte
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	w := newTestDispatcher(t, 10)
+	delivery := newTestDelivery()
+	delivery.Attempts = 1
+
+	attempt := w.send(context.Background(), &models.WebhookSubscription{URL: receiver.URL, Secret: secret}, delivery)
+	if attempt.Error != "" {
+		t.Fatalf("send() error = %q", attempt.Error)
+	}
+	if attempt.StatusCode != http.StatusNoContent || attempt.Attempt != 1 || attempt.DeliveryID != delivery.DeliveryID {
+		t.Fatalf("send() = %+v", attempt)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if string(body) != delivery.Payload {
+		t.Fatalf("receiver got body %q, want %q", body, delivery.Payload)
+	}
+	if got := received.Header.Get(utilities.WebhookIDHeader); got != delivery.DeliveryID {
+		t.Fatalf("%s = %q, want %q", utilities.WebhookIDHeader, got, delivery.DeliveryID)
+	}
+	if got := received.Header.Get(utilities.WebhookEventHeader); got != delivery.EventType {
+		t.Fatalf("%s = %q, want %q", utilities.WebhookEventHeader, got, delivery.EventType)
+	}
+	timestamp, err := strconv.ParseInt(received.Header.Get(utilities.WebhookTimestampHeader), 10, 64)
+	if err != nil {
+		t.Fatalf("%s: %s", utilities.WebhookTimestampHeader, err)
+	}
+	if got, want := received.Header.Get(utilities.WebhookSignatureHeader), utilities.SignWebhookPayload(secret, timestamp, body); got != want {
+		t.Fatalf("%s = %q, want %q", utilities.WebhookSignatureHeader, got, want)
+	}
+}
+
+func TestWebhookDispatcherDeadLetter(t *testing.T) {
+	const maxAttempts = 3
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(rw, "unavailable")
+	}))
+	defer receiver.Close()
+
+	w := newTestDispatcher(t, maxAttempts)
+	subscription := &models.WebhookSubscription{URL: receiver.URL, Secret: "whsec_test"}
+	delivery := newTestDelivery()
+
+	for i := 1; i <= maxAttempts; i++ {
+		// claim counts the attempt before the send
+		delivery.Attempts++
+		attempt := w.send(context.Background(), subscription, delivery)
+		if attempt.StatusCode != http.StatusInternalServerError || attempt.Error == "" || attempt.ResponseBody != "unavailable" {
+			t.Fatalf("attempt %d: send() = %+v", i, attempt)
+		}
+
+		now := time.Now()
+		w.applyAttempt(delivery, attempt, now)
+
+		if i < maxAttempts {
+			if delivery.Status != enums.WebhookPending.String() {
+				t.Fatalf("attempt %d: status = %q, want %q", i, delivery.Status, enums.WebhookPending)
+			}
+			if !delivery.NextAttemptAt.After(now) {
+				t.Fatalf("attempt %d: next_attempt_at %s is not after %s", i, delivery.NextAttemptAt, now)
+			}
+			continue
+		}
+		if delivery.Status != enums.WebhookDeadLetter.String() {
+			t.Fatalf("attempt %d: status = %q, want %q", i, delivery.Status, enums.WebhookDeadLetter)
+		}
+		if delivery.LastError != attempt.Error {
+			t.Fatalf("last_error = %q, want %q", delivery.LastError, attempt.Error)
+		}
+	}
+}
+
+func TestWebhookDispatcherSucceeded(t *testing.T) {
+	w := newTestDispatcher(t, 3)
+	delivery := newTestDelivery()
+	delivery.Attempts = 2
+	delivery.LastError = "unexpected status 500"
+
+	now := time.Now()
+	w.applyAttempt(delivery, &models.WebhookDeliveryAttempt{StatusCode: http.StatusOK}, now)
+
+	if delivery.Status != enums.WebhookSucceeded.String() || delivery.LastError != "" {
+		t.Fatalf("applyAttempt() = status %q, last_error %q", delivery.Status, delivery.LastError)
+	}
+	if delivery.DeliveredAt == nil || !delivery.DeliveredAt.Equal(now) {
+		t.Fatalf("delivered_at = %v, want %s", delivery.DeliveredAt, now)
+	}
+}
+
+func TestWebhookDispatcherRefusesInternalAddresses(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		t.Error("receiver on loopback was reached")
+	}))
+	defer receiver.Close()
+
+	w := newTestDispatcher(t, 3)
+	w.allowInsecureURLs = false
+	w.client.Transport = utilities.NewWebhookTransport(time.Second, false)
+
+	attempt := w.send(context.Background(), &models.WebhookSubscription{URL: receiver.URL, Secret: "whsec_test"}, newTestDelivery())
+	if attempt.Error == "" {
+		t.Fatal("send() to a loopback http URL succeeded")
+	}
+}
+
+func TestWebhookDispatcherHoldsLease(t *testing.T) {
+	leaseEnd := time.Now().Add(time.Minute).Truncate(time.Microsecond)
+	claimed := newTestDelivery()
+	claimed.Attempts = 1
+	claimed.NextAttemptAt = leaseEnd
+
+	tests := []struct {
+		name   string
+		modify func(current *models.WebhookDelivery)
+		held   bool
+	}{
+		{name: "unchanged", modify: func(*models.WebhookDelivery) {}, held: true},
+		{name: "read back in another location", modify: func(current *models.WebhookDelivery) {
+			current.NextAttemptAt = leaseEnd.UTC()
+		}, held: true},
+		{name: "claimed again", modify: func(current *models.WebhookDelivery) {
+			current.Attempts = 2
+			current.NextAttemptAt = leaseEnd.Add(time.Minute)
+		}, held: false},
+		{name: "redelivered and claimed again", modify: func(current *models.WebhookDelivery) {
+			// a redelivery restarts the attempts, so the count matches
+			current.NextAttemptAt = leaseEnd.Add(time.Second)
+		}, held: false},
+		{name: "dead-lettered", modify: func(current *models.WebhookDelivery) {
+			current.Status = enums.WebhookDeadLetter.String()
+		}, held: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := *claimed
+			tt.modify(&current)
+			if got := holdsLease(&current, claimed); got != tt.held {
+				t.Fatalf("holdsLease() = %v, want %v", got, tt.held)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"banking-service/domains"
+	"banking-service/enums"
+	"banking-service/models"
+	"banking-service/repositories"
+	"banking-service/utilities"
+
+	"gorm.io/gorm"
+)
+
+// WebhookSink is the outbox sink that fans events out to the matching webhook
+// subscriptions by queueing one delivery per subscription. The webhook
+// dispatcher sends them.
+type WebhookSink struct {
+	db                            *gorm.DB
+	idGenerator                   utilities.SnowflakeIDGenerator
+	accountRepository             repositories.AccountRepositoryI
+	webhookSubscriptionRepository repositories.WebhookSubscriptionRepositoryI
+	webhookDeliveryRepository     repositories.WebhookDeliveryRepositoryI
+}
+
+func NewWebhookSink(db *gorm.DB, idGenerator utilities.SnowflakeIDGenerator) *WebhookSink {
+	return &WebhookSink{
+		db:                            db,
+		idGenerator:                   idGenerator,
+		accountRepository:             repositories.NewAccountRepository(),
+		webhookSubscriptionRepository: repositories.NewWebhookSubscriptionRepository(),
+		webhookDeliveryRepository:     repositories.NewWebhookDeliveryRepository(),
+	}
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+func (s *WebhookSink) Publish(ctx context.Context, event *domains.Event) error {
+	subscriptions, err := s.webhookSubscriptionRepository.GetWebhookSubscriptions(ctx, s.db, &repositories.GetWebhookSubscriptionsArgs{
+		ActiveOnly: true,
+		EventType:  event.Type,
+		Limit:      -1,
+	})
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	owners, err := s.accountOwners(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	for _, subscription := range subscriptions {
+		if !matchesSubscription(subscription, owners) {
+			continue
+		}
+
+		subscriptionEvent := event
+		if subscription.UserID != "" {
+			// hide the balance of the other side of a transfer
+			subscriptionEvent = event.RedactCounterparty(func(accountID string) bool {
+				return owners[accountID] == subscription.UserID
+			})
+		}
+		body, err := json.Marshal(subscriptionEvent)
+		if err != nil {
+			return err
+		}
+
+		if err := s.webhookDeliveryRepository.Create(ctx, s.db, &models.WebhookDelivery{
+			DeliveryID:     s.idGenerator.Next().String(),
+			SubscriptionID: subscription.SubscriptionID,
+			EventID:        event.EventID,
+			EventType:      event.Type,
+			Payload:        string(body),
+			Status:         enums.WebhookPending.String(),
+			NextAttemptAt:  time.Now(),
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// accountOwners maps the accounts touched by event to their owners.
+func (s *WebhookSink) accountOwners(ctx context.Context, event *domains.Event) (map[string]string, error) {
+	var owner struct {
+		UserID string `json:"user_id"`
+	}
+	if err := json.Unmarshal(event.Payload, &owner); err != nil {
+		return nil, err
+	}
+
+	accountIDs := event.AccountIDs()
+	owners := make(map[string]string, len(accountIDs))
+	// single account payloads carry the owner, which also covers closed
+	// accounts; transfer payloads only carry the account IDs
+	if len(accountIDs) == 1 && owner.UserID != "" {
+		owners[accountIDs[0]] = owner.UserID
+		return owners, nil
+	}
+
+	for _, accountID := range accountIDs {
+		account, err := s.accountRepository.GetAccount(ctx, s.db, &repositories.GetAccountArgs{
+			AccountID: accountID,
+		})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		owners[accountID] = account.UserID
+	}
+
+	return owners, nil
+}
+
+func matchesSubscription(subscription *models.WebhookSubscription, owners map[string]string) bool {
+	for accountID, userID := range owners {
+		if subscription.UserID != "" && subscription.UserID != userID {
+			continue
+		}
+		if len(subscription.AccountIDs) != 0 && !containsString(subscription.AccountIDs, accountID) {
+			continue
+		}
+		return true
+	}
+
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}